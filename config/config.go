@@ -1,11 +1,14 @@
 package config
 
 import (
+	"api/models"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"go/build"
@@ -26,6 +29,19 @@ type Secrets struct {
 	CurrentDatabase  string      `json:"CURRENT_DATABASE"`
 	JwtSecret        string      `json:"JWT_SECRET"`
 	ElasticSearchUrl string      `json:"ELASTIC_SEARCH_URL"`
+	SeedDefaultUsers bool        `json:"SEED_DEFAULT_USERS"`
+	PasswordPolicy   models.PasswordPolicy
+
+	// RankingWeights and CompatibilityWeights override the default discovery ranking weights.
+	RankingWeights       models.Weights `json:"RANKING_WEIGHTS"`
+	CompatibilityWeights models.Weights `json:"COMPATIBILITY_WEIGHTS"`
+
+	SwipeLimits models.SwipeLimits
+	MatchExpiry models.MatchExpiryPolicy
+	Notifiers   models.NotifierSettings
+
+	// AdminUserIDs are the users promoted to admin at startup.
+	AdminUserIDs []string `json:"ADMIN_USER_IDS"`
 }
 
 var secrets Secrets
@@ -55,6 +71,42 @@ func initializeSecrets() {
 		DatabaseName:     os.Getenv("DATABASE_NAME"),
 		JwtSecret:        os.Getenv("JWT_SECRET"),
 		ElasticSearchUrl: os.Getenv("ELASTIC_SEARCH_URL"),
+		SeedDefaultUsers: getEnvBool("SEED_DEFAULT_USERS", false),
+		PasswordPolicy: models.PasswordPolicy{
+			MinLength:        getEnvInt("PASSWORD_MIN_LENGTH", 8),
+			RequireUppercase: getEnvBool("PASSWORD_REQUIRE_UPPERCASE", true),
+			RequireLowercase: getEnvBool("PASSWORD_REQUIRE_LOWERCASE", true),
+			RequireDigit:     getEnvBool("PASSWORD_REQUIRE_DIGIT", true),
+			RequireSymbol:    getEnvBool("PASSWORD_REQUIRE_SYMBOL", false),
+		},
+		RankingWeights:       getEnvWeights("RANKING_WEIGHTS"),
+		CompatibilityWeights: getEnvWeights("COMPATIBILITY_WEIGHTS"),
+		SwipeLimits: models.SwipeLimits{
+			DailyLikes:      getEnvInt("DAILY_LIKE_LIMIT", 100),
+			DailyPasses:     getEnvInt("DAILY_PASS_LIMIT", 500),
+			DailyRewinds:    getEnvInt("DAILY_REWIND_LIMIT", 3),
+			DailySuperLikes: getEnvInt("DAILY_SUPER_LIKE_LIMIT", 1),
+			RewindWindow:    getEnvDuration("REWIND_WINDOW", 5*time.Minute),
+		},
+		MatchExpiry: models.MatchExpiryPolicy{
+			ExpireAfter:   time.Duration(getEnvInt("MATCH_EXPIRY_DAYS", 0)) * 24 * time.Hour,
+			Extension:     getEnvDuration("MATCH_EXTENSION", 24*time.Hour),
+			WarnBefore:    getEnvDuration("MATCH_EXPIRY_WARNING", 24*time.Hour),
+			SweepInterval: getEnvDuration("MATCH_SWEEP_INTERVAL", 5*time.Minute),
+		},
+		Notifiers: models.NotifierSettings{
+			Channels:      getEnvListOr("NOTIFIERS", []string{"log"}),
+			PushURL:       os.Getenv("PUSH_URL"),
+			PushAPIKey:    os.Getenv("PUSH_API_KEY"),
+			SMTPAddr:      os.Getenv("SMTP_ADDR"),
+			SMTPUsername:  os.Getenv("SMTP_USERNAME"),
+			SMTPPassword:  os.Getenv("SMTP_PASSWORD"),
+			EmailFrom:     os.Getenv("EMAIL_FROM"),
+			WebhookURL:    os.Getenv("WEBHOOK_URL"),
+			WebhookSecret: os.Getenv("WEBHOOK_SECRET"),
+			FilePath:      os.Getenv("NOTIFICATIONS_FILE"),
+		},
+		AdminUserIDs: getEnvList("ADMIN_USER_IDS"),
 	}
 
 	setCurrentDatabase()
@@ -76,9 +128,11 @@ func setCurrentDatabase() {
 // setEnvironment sets the environment.
 func setEnvironment() {
 	if envStr := os.Getenv("ENVIRONMENT"); envStr != "" {
-		if env := Environment(envStr); env.IsValid() != nil {
+		env := Environment(envStr)
+		if env.IsValid() != nil {
 			log.Fatal("Error in environment variables: ", env.IsValid())
 		}
+		secrets.Environment = env
 	} else {
 		log.Fatal("ENVIRONMENT is not set.")
 	}
@@ -93,6 +147,87 @@ func setPort() {
 	}
 }
 
+// getEnvInt returns the integer value of an environment variable, or the fallback when it is unset.
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid value for %s: %v", key, err)
+	}
+	return parsed
+}
+
+// getEnvDuration returns the duration value of an environment variable, such as "5m", or the
+// fallback when it is unset.
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid value for %s: %v", key, err)
+	}
+	return parsed
+}
+
+// getEnvBool returns the boolean value of an environment variable, or the fallback when it is unset.
+func getEnvBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("Invalid value for %s: %v", key, err)
+	}
+	return parsed
+}
+
+// getEnvList returns the comma-separated values of an environment variable, dropping empty entries.
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvListOr returns the comma-separated values of an environment variable, or the fallback when
+// it is unset. Set to "none", it returns no values.
+func getEnvListOr(key string, fallback []string) []string {
+	if os.Getenv(key) == "" {
+		return fallback
+	}
+	if os.Getenv(key) == "none" {
+		return nil
+	}
+	return getEnvList(key)
+}
+
+// getEnvWeights parses weights written as "name=weight,name=weight", e.g. "proximity=0.4,recency=0.1".
+func getEnvWeights(key string) models.Weights {
+	weights := models.Weights{}
+	value := os.Getenv(key)
+	if value == "" {
+		return weights
+	}
+	for _, pair := range strings.Split(value, ",") {
+		name, weight, found := strings.Cut(strings.TrimSpace(pair), "=")
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+		if !found || err != nil {
+			log.Fatalf("Invalid value for %s: %q must look like name=weight", key, pair)
+		}
+		weights[strings.TrimSpace(name)] = parsed
+	}
+	return weights
+}
+
 // GetSecrets returns the initialized Secrets.
 func GetSecrets() Secrets {
 	return secrets
@@ -17,9 +17,69 @@ const DefaultUserCount = 100
 
 const DefaultPassword = "password"
 
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+	// DiscoverCandidateWindow is how many of the nearest profiles, after the super-likers, discover
+	// ranks and pages through. Ranking runs in Go, so it is capped to bound the profiles read for
+	// every page. Swiping through them brings the next nearest profiles in; those that rank above
+	// the cursor of a session wait for the next session.
+	DiscoverCandidateWindow = 500
+)
+
+const (
+	MinimumAge        = 18
+	DateOfBirthLayout = "2006-01-02"
+)
+
 const UserCollection = "users"
 const MatchCollection = "matches"
 const SwipeCollection = "swipes"
+const BlockCollection = "blocks"
+const ReportCollection = "reports"
+const AuditCollection = "moderation_audit"
+const MessageCollection = "messages"
+const NotificationCollection = "notifications"
+
+// Topics of the events published on the event store. Event data is JSON.
+const (
+	MatchUnmatchedTopic    = "match.unmatched"
+	SwipeSuperLikedTopic   = "swipe.super_liked"
+	SwipeLikedTopic        = "swipe.liked"
+	MessageSentTopic       = "message.sent"
+	MatchCreatedTopic      = "match.created"
+	MessageReadTopic       = "message.read"
+	TypingStartedTopic     = "typing.started"
+	TypingStoppedTopic     = "typing.stopped"
+	MatchExpiringSoonTopic = "match.expiring_soon"
+	MatchExpiredTopic      = "match.expired"
+)
+
+const (
+	// GatewayHeartbeatInterval is how often an idle event stream is pinged, and its token checked again.
+	GatewayHeartbeatInterval = 25 * time.Second
+	// GatewayBufferSize is how many events a stream can fall behind before it is dropped.
+	GatewayBufferSize = 32
+	// SubscriberBufferSize is how many events a subscription handler that drops when full can
+	// fall behind before the events of its topic are dropped for it.
+	SubscriberBufferSize = 64
+)
+
+// MatchSweepBatchSize is how many matches the expiry sweeper handles at a time.
+const MatchSweepBatchSize = 100
+
+const (
+	// NotificationTimeout bounds the handling of one event by the notification service, which keeps
+	// its notifications in the inbox and queues them for delivery.
+	NotificationTimeout = 30 * time.Second
+	// NotificationDeliveryTimeout bounds the delivery of one notification through one channel.
+	NotificationDeliveryTimeout = 30 * time.Second
+	// NotificationQueueSize is how many notifications a delivery channel can fall behind before
+	// notifications are no longer delivered through it. They are in the inbox either way.
+	NotificationQueueSize = 256
+	// NotificationPreviewLength is how many characters of a message a notification shows.
+	NotificationPreviewLength = 100
+)
 
 const (
 	TokenIssuer         = "Muzz Dating"
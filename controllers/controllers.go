@@ -1,16 +1,22 @@
 package controllers
 
 import (
+	"api/constants"
 	"api/interceptors"
 	"api/models"
+	"api/services"
 	"api/setup"
 	"api/utils"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/go-chi/chi"
+	"io"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Controller struct {
@@ -35,29 +41,42 @@ func (c *Controller) Home(w http.ResponseWriter, r *http.Request) {
 }
 
 // RegisterUser godoc
-// @Summary  Create a random user
-// @Description Generate a random user and return the user details
+// @Summary  Register a user
+// @Description Register a user with their name, email, password, gender and date of birth
 // @Produce			application/json
 // @Tags   user
 // @Accept   json
+// @Param			user body models.RegistrationPayload{} true "Registration Payload"
 // @Success 200 {object} models.RegistrationResponse{} "Successful response"
 // @Failure  400 {object} controllers.ErrorResponse{}
 // @Router   /user/create [POST]
 func (c *Controller) RegisterUser(w http.ResponseWriter, r *http.Request) {
-	registrationResponse, err := c.UserService.Register(r.Context())
+	var payload models.RegistrationPayload
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		HttpResponse(w, errors.New("invalid payload"), nil, 400)
+		return
+	}
+	err = payload.Validate()
+	if err != nil {
+		HttpResponse(w, err, nil, 400)
+		return
+	}
+	registrationResponse, err := c.UserService.Register(r.Context(), payload)
 	HttpResponse(w, err, registrationResponse, 0)
 	return
 }
 
 // LoginUser godoc
 // @Summary  Login a user with email and password
-// @Description Login a user with email and password
+// @Description Login a user with email and password. If the user deactivated their account, deactivated is set and the token can be used to reactivate it.
 // @Produce			application/json
 // @Tags   user
 // @Accept   json
 // @Param			user body models.LoginPayload{} true "Login Payload"
 // @Success  200 {object} models.LoginResponse{}
 // @Failure  400 {object} controllers.ErrorResponse{}
+// @Failure  403 {object} controllers.ErrorResponse{}
 // @Router   /login [POST]
 func (c *Controller) LoginUser(w http.ResponseWriter, r *http.Request) {
 	var payload models.LoginPayload // Declare payload as a non-pointer
@@ -74,6 +93,11 @@ func (c *Controller) LoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	loginResponse, err := c.UserService.Login(r.Context(), payload.Email, payload.Password)
+	var suspended *services.AccountSuspendedError
+	if errors.Is(err, services.ErrAccountBanned) || errors.As(err, &suspended) {
+		HttpResponse(w, err, nil, http.StatusForbidden)
+		return
+	}
 	HttpResponse(w, err, loginResponse, 0)
 }
 
@@ -99,9 +123,185 @@ func (c *Controller) GetUser(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// UpdateUser godoc
+// @Summary  Update the current user's profile
+// @Description Update only the profile fields provided in the request body
+// @Produce			application/json
+// @Tags   user
+// @Accept   json
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param			user body models.UpdateUserPayload{} true "Update User Payload"
+// @Success  200 {object} models.User{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Router   /user [PATCH]
+func (c *Controller) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	var payload models.UpdateUserPayload
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		HttpResponse(w, errors.New("invalid payload"), nil, 400)
+		return
+	}
+	err = payload.Validate()
+	if err != nil {
+		HttpResponse(w, err, nil, 400)
+		return
+	}
+	profile, err := c.UserService.UpdateProfile(r.Context(), account.ID, payload)
+	HttpResponse(w, err, profile, 0)
+	return
+}
+
+// UpdatePassword godoc
+// @Summary  Change the current user's password
+// @Description Change the password and revoke every previously issued token. A new token is returned.
+// @Produce			application/json
+// @Tags   user
+// @Accept   json
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param			user body models.UpdatePasswordPayload{} true "Update Password Payload"
+// @Success  200 {object} models.LoginResponse{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Router   /user/password [PUT]
+func (c *Controller) UpdatePassword(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	var payload models.UpdatePasswordPayload
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		HttpResponse(w, errors.New("invalid payload"), nil, 400)
+		return
+	}
+	err = payload.Validate()
+	if err != nil {
+		HttpResponse(w, err, nil, 400)
+		return
+	}
+	loginResponse, err := c.UserService.ChangePassword(r.Context(), account.ID, payload)
+	HttpResponse(w, err, loginResponse, 0)
+	return
+}
+
+// DeactivateUser godoc
+// @Summary  Deactivate the current user's account
+// @Description Hide the current user from discovery and from other users' matches, and stop them from swiping, until they reactivate their account.
+// @Produce			application/json
+// @Tags   user
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Success  200 {object} models.User{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Router   /user/deactivate [POST]
+func (c *Controller) DeactivateUser(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	profile, err := c.UserService.Deactivate(r.Context(), account.ID)
+	if errors.Is(err, services.ErrAccountBanned) {
+		HttpResponse(w, err, nil, http.StatusForbidden)
+		return
+	}
+	HttpResponse(w, err, profile, 0)
+	return
+}
+
+// ReactivateUser godoc
+// @Summary  Reactivate the current user's account
+// @Description Reactivate an account the current user deactivated.
+// @Produce			application/json
+// @Tags   user
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Success  200 {object} models.User{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Router   /user/reactivate [POST]
+func (c *Controller) ReactivateUser(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	profile, err := c.UserService.Reactivate(r.Context(), account.ID)
+	if errors.Is(err, services.ErrAccountBanned) {
+		HttpResponse(w, err, nil, http.StatusForbidden)
+		return
+	}
+	HttpResponse(w, err, profile, 0)
+	return
+}
+
+// GetPreferences godoc
+// @Summary  Get the current user's dating preferences
+// @Description Get the current user's saved dating preferences
+// @Produce			application/json
+// @Tags   user
+// @Accept   json
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Success  200 {object} models.Preferences{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Router   /user/preferences [GET]
+func (c *Controller) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	preferences, err := c.UserService.GetPreferences(r.Context(), *account)
+	HttpResponse(w, err, preferences, 0)
+	return
+}
+
+// UpdatePreferences godoc
+// @Summary  Save the current user's dating preferences
+// @Description Replace the current user's dating preferences. Deal-breakers filter discovery results, the rest rank them.
+// @Produce			application/json
+// @Tags   user
+// @Accept   json
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param			user body models.Preferences{} true "Preferences"
+// @Success  200 {object} models.Preferences{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Router   /user/preferences [PUT]
+func (c *Controller) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	var payload models.Preferences
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		HttpResponse(w, errors.New("invalid payload"), nil, 400)
+		return
+	}
+	err = payload.Validate()
+	if err != nil {
+		HttpResponse(w, err, nil, 400)
+		return
+	}
+	preferences, err := c.UserService.SetPreferences(r.Context(), account.ID, payload)
+	HttpResponse(w, err, preferences, 0)
+	return
+}
+
 // DiscoverUsers godoc
 // @Summary  Discover users
-// @Description Discover users
+// @Description Discover users, the ones who super-liked the user first, then everyone else, each ranked by how well they suit the user. The 500 nearest candidates are ranked and paged through. Without any query filter, the user's saved preferences are used.
 // @Produce			application/json
 // @Tags   discover
 // @Accept   json
@@ -110,6 +310,19 @@ func (c *Controller) GetUser(w http.ResponseWriter, r *http.Request) {
 // @Param min_age query int false "Minimum Age"
 // @Param max_age query int false "Maximum Age"
 // @Param max_distance query int false "Maximum Distance"
+// @Param min_height query int false "Minimum Height"
+// @Param max_height query int false "Maximum Height"
+// @Param desired_ethnicity query string false "Comma-separated ethnicities"
+// @Param desired_pets query string false "Comma-separated pets"
+// @Param desired_sexuality query string false "Comma-separated sexualities"
+// @Param desired_drinking query string false "Comma-separated drinking habits"
+// @Param desired_smoking query string false "Comma-separated smoking habits"
+// @Param desired_drugs query string false "Comma-separated drug habits"
+// @Param desired_intentions query string false "Comma-separated dating intentions"
+// @Param desired_religion query string false "Comma-separated religions"
+// @Param limit query int false "Page size, 20 by default and at most 100"
+// @Param cursor query string false "The next_cursor of the previous page"
+// @Param explain query bool false "Include the breakdown of each profile's ranking score"
 // @Success  200 {object} []models.User{}
 // @Failure  400 {object} controllers.ErrorResponse{}
 // @Router   /discover [GET]
@@ -128,20 +341,26 @@ func (c *Controller) DiscoverUsers(w http.ResponseWriter, r *http.Request) {
 	//filter.Latitude, _ = strconv.ParseFloat(q.Get("latitude"), 64)
 	//filter.Longitude, _ = strconv.ParseFloat(q.Get("longitude"), 64)
 	filter.MaxDistance, _ = strconv.Atoi(q.Get("max_distance"))
-	filter.DesiredEthnicity = strings.ToLower(q.Get("desired_ethnicity"))
-	filter.DesiredPets = strings.ToLower(q.Get("desired_pets"))
-	filter.DesiredSexuality = strings.ToLower(q.Get("desired_sexuality"))
-	filter.DesiredDrinking = strings.ToLower(q.Get("desired_drinking"))
-	filter.DesiredSmoking = strings.ToLower(q.Get("desired_smoking"))
-	filter.DesiredDrugs = strings.ToLower(q.Get("desired_drugs"))
-	filter.DesiredIntentions = strings.ToLower(q.Get("desired_intentions"))
-	filter.DesiredReligion = strings.ToLower(q.Get("desired_religion"))
+	filter.DesiredEthnicity = parseListParam(q.Get("desired_ethnicity"))
+	filter.DesiredPets = parseListParam(q.Get("desired_pets"))
+	filter.DesiredSexuality = parseListParam(q.Get("desired_sexuality"))
+	filter.DesiredDrinking = parseListParam(q.Get("desired_drinking"))
+	filter.DesiredSmoking = parseListParam(q.Get("desired_smoking"))
+	filter.DesiredDrugs = parseListParam(q.Get("desired_drugs"))
+	filter.DesiredIntentions = parseListParam(q.Get("desired_intentions"))
+	filter.DesiredReligion = parseListParam(q.Get("desired_religion"))
 	if err := filter.Validate(); err != nil {
 		HttpResponse(w, err, nil, http.StatusBadRequest)
 		return
 	}
-	profiles, err := c.UserService.Discover(r.Context(), *account, filter)
-	HttpResponse(w, err, profiles, 0)
+	page, err := parsePage(r)
+	if err != nil {
+		HttpResponse(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	explain, _ := strconv.ParseBool(q.Get("explain"))
+	profiles, nextCursor, err := c.UserService.Discover(r.Context(), *account, filter, page, explain)
+	HttpPaginatedResponse(w, err, profiles, nextCursor, 0)
 	return
 }
 
@@ -156,6 +375,8 @@ func (c *Controller) DiscoverUsers(w http.ResponseWriter, r *http.Request) {
 // @Param			user body models.SwipePayload{} true "Login Payload"
 // @Success  200 {object} []models.SwipeResponse{}
 // @Failure  400 {object} controllers.ErrorResponse{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Failure  429 {object} controllers.ErrorResponse{}
 func (c *Controller) SwipeUser(w http.ResponseWriter, r *http.Request) {
 	account, err := interceptors.GetAuthenticatedAccount(r.Context())
 	if err != nil {
@@ -173,7 +394,974 @@ func (c *Controller) SwipeUser(w http.ResponseWriter, r *http.Request) {
 		HttpResponse(w, err, nil, 400)
 		return
 	}
-	swipeResponse, err := c.SwipeService.Swipe(r.Context(), account.ID, payload)
+	swipeResponse, err := c.SwipeService.Swipe(r.Context(), *account, payload)
+	if respondQuotaExceeded(w, err) {
+		return
+	}
+	if errors.Is(err, services.ErrProspectBlocked) || errors.Is(err, services.ErrSwiperDeactivated) {
+		HttpResponse(w, err, nil, http.StatusForbidden)
+		return
+	}
 	HttpResponse(w, err, swipeResponse, 0)
 	return
 }
+
+// UndoSwipe godoc
+// @Summary  Undo the last swipe
+// @Description Revert the user's most recent swipe within the rewind window, rolling back the match it made
+// @Produce			application/json
+// @Tags   swipe
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Success  200 {object} models.Swipe{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Failure  429 {object} controllers.ErrorResponse{}
+// @Router   /swipe/undo [post]
+func (c *Controller) UndoSwipe(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	swipe, err := c.SwipeService.Undo(r.Context(), *account)
+	if respondQuotaExceeded(w, err) {
+		return
+	}
+	if errors.Is(err, services.ErrNothingToUndo) {
+		HttpResponse(w, err, nil, http.StatusNotFound)
+		return
+	}
+	HttpResponse(w, err, swipe, 0)
+	return
+}
+
+// respondQuotaExceeded answers 429, with the time left until the quota resets in Retry-After,
+// when err is a services.QuotaExceededError. It reports whether it did.
+func respondQuotaExceeded(w http.ResponseWriter, err error) bool {
+	var quotaErr *services.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+	retryAfter := int(math.Ceil(time.Until(quotaErr.ResetsAt).Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 0)))
+	HttpResponse(w, err, nil, http.StatusTooManyRequests)
+	return true
+}
+
+// GetSwipeQuota godoc
+// @Summary  Get the daily swipe quota
+// @Description Get the likes, passes and rewinds left today, and when the quota resets at the user's local midnight
+// @Produce			application/json
+// @Tags   swipe
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Success  200 {object} models.SwipeQuota{}
+// @Failure  401 {object} controllers.ErrorResponse{}
+// @Router   /swipe/quota [get]
+func (c *Controller) GetSwipeQuota(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	HttpResponse(w, nil, c.SwipeService.GetQuota(*account), 0)
+	return
+}
+
+// GetMatches godoc
+// @Summary  List matches
+// @Description List the user's matches, newest first, each with the other party of the match
+// @Produce			application/json
+// @Tags   match
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param limit query int false "Page size, 20 by default and at most 100"
+// @Param cursor query string false "The next_cursor of the previous page"
+// @Success  200 {object} []models.MatchedUser{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Router   /matches [get]
+func (c *Controller) GetMatches(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	page, err := parsePage(r)
+	if err != nil {
+		HttpResponse(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	matches, nextCursor, err := c.MatchService.GetMatches(r.Context(), *account, page)
+	HttpPaginatedResponse(w, err, matches, nextCursor, 0)
+	return
+}
+
+// Unmatch godoc
+// @Summary  Unmatch
+// @Description End a match. Either participant can unmatch, and the pair is never shown to each other again.
+// @Produce			application/json
+// @Tags   match
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "Match ID"
+// @Success  200 {object} models.Match{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Router   /matches/{id} [delete]
+func (c *Controller) Unmatch(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	match, err := c.MatchService.Unmatch(r.Context(), *account, chi.URLParam(r, "id"))
+	if errors.Is(err, services.ErrMatchNotFound) {
+		HttpResponse(w, err, nil, http.StatusNotFound)
+		return
+	}
+	HttpResponse(w, err, match, 0)
+	return
+}
+
+// ExtendMatch godoc
+// @Summary  Extend a match
+// @Description Push back the expiry of a match that is about to expire for lack of messages. Each participant can extend a match once.
+// @Produce			application/json
+// @Tags   match
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "Match ID"
+// @Success  200 {object} models.MatchExpiry{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Failure  409 {object} controllers.ErrorResponse{}
+// @Router   /matches/{id}/extend [post]
+func (c *Controller) ExtendMatch(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	expiry, err := c.MatchService.Extend(r.Context(), *account, chi.URLParam(r, "id"))
+	switch {
+	case errors.Is(err, services.ErrMatchNotFound):
+		HttpResponse(w, err, nil, http.StatusNotFound)
+		return
+	case errors.Is(err, services.ErrMatchAlreadyExtended), errors.Is(err, services.ErrMatchExpiryDisabled):
+		HttpResponse(w, err, nil, http.StatusConflict)
+		return
+	}
+	HttpResponse(w, err, expiry, 0)
+	return
+}
+
+// SendMessage godoc
+// @Summary  Send a message
+// @Description Send a message to the other participant of a match. Only matched pairs can chat, so messages are rejected after an unmatch or a block.
+// @Produce			application/json
+// @Tags   message
+// @Accept   json
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "Match ID"
+// @Param			message body models.MessagePayload{} true "Message Payload"
+// @Success  200 {object} models.Message{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Router   /matches/{id}/messages [post]
+func (c *Controller) SendMessage(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	var payload models.MessagePayload
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		HttpResponse(w, errors.New("invalid payload"), nil, 400)
+		return
+	}
+	err = payload.Validate()
+	if err != nil {
+		HttpResponse(w, err, nil, 400)
+		return
+	}
+	message, err := c.MessageService.Send(r.Context(), *account, chi.URLParam(r, "id"), payload)
+	if respondMessageError(w, err) {
+		return
+	}
+	HttpResponse(w, err, message, 0)
+	return
+}
+
+// ListMessages godoc
+// @Summary  List messages
+// @Description List the messages of a match, newest first. Only the participants of a current match can read them.
+// @Produce			application/json
+// @Tags   message
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "Match ID"
+// @Param limit query int false "Page size, 20 by default and at most 100"
+// @Param cursor query string false "The next_cursor of the previous page"
+// @Success  200 {object} []models.Message{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Router   /matches/{id}/messages [get]
+func (c *Controller) ListMessages(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	page, err := parsePage(r)
+	if err != nil {
+		HttpResponse(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	messages, nextCursor, err := c.MessageService.ListMessages(r.Context(), *account, chi.URLParam(r, "id"), page)
+	if respondMessageError(w, err) {
+		return
+	}
+	HttpPaginatedResponse(w, err, messages, nextCursor, 0)
+	return
+}
+
+// MarkMessagesRead godoc
+// @Summary  Mark messages read
+// @Description Mark the messages the current user received in a match as read, up to and including the given message, or all of them when the body is empty. The other participant gets a message.read event when anything new was read.
+// @Produce			application/json
+// @Tags   message
+// @Accept   json
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "Match ID"
+// @Param			read body models.ReadPayload{} false "Read Payload"
+// @Success  200 {object} models.ReadReceipt{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Router   /matches/{id}/read [post]
+func (c *Controller) MarkMessagesRead(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	// The body is optional: without one, everything received is read.
+	var payload models.ReadPayload
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil && !errors.Is(err, io.EOF) {
+		HttpResponse(w, errors.New("invalid payload"), nil, 400)
+		return
+	}
+	receipt, err := c.MessageService.MarkRead(r.Context(), *account, chi.URLParam(r, "id"), payload)
+	if respondMessageError(w, err) {
+		return
+	}
+	HttpResponse(w, err, receipt, 0)
+	return
+}
+
+// SetTyping godoc
+// @Summary  Set typing indicator
+// @Description Tell the other participant of a match that the current user started or stopped typing, through typing.started and typing.stopped events. Nothing is stored, so clients should send a stop when the user stops typing.
+// @Produce			application/json
+// @Tags   message
+// @Accept   json
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "Match ID"
+// @Param			typing body models.TypingPayload{} true "Typing Payload"
+// @Success  200 {object} models.TypingPayload{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Router   /matches/{id}/typing [post]
+func (c *Controller) SetTyping(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	var payload models.TypingPayload
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		HttpResponse(w, errors.New("invalid payload"), nil, 400)
+		return
+	}
+	err = payload.Validate()
+	if err != nil {
+		HttpResponse(w, err, nil, 400)
+		return
+	}
+	err = c.MessageService.SetTyping(r.Context(), *account, chi.URLParam(r, "id"), *payload.Typing)
+	if respondMessageError(w, err) {
+		return
+	}
+	HttpResponse(w, err, payload, 0)
+	return
+}
+
+// respondMessageError responds 404 to a match the user is not part of or a message that is not
+// in it, and 403 to a conversation that has ended, and reports whether it responded.
+func respondMessageError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrMatchNotFound), errors.Is(err, services.ErrMessageNotFound):
+		HttpResponse(w, err, nil, http.StatusNotFound)
+	case errors.Is(err, services.ErrConversationClosed):
+		HttpResponse(w, err, nil, http.StatusForbidden)
+	default:
+		return false
+	}
+	return true
+}
+
+// ListNotifications godoc
+// @Summary  List notifications
+// @Description List the current user's in-app notifications, newest first: new matches, matches about to expire or expired, messages, likes and super-likes.
+// @Produce			application/json
+// @Tags   notification
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param limit query int false "Page size, 20 by default and at most 100"
+// @Param cursor query string false "The next_cursor of the previous page"
+// @Success  200 {object} []models.Notification{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Router   /notifications [get]
+func (c *Controller) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	page, err := parsePage(r)
+	if err != nil {
+		HttpResponse(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	notifications, nextCursor, err := c.NotificationService.ListNotifications(r.Context(), *account, page)
+	HttpPaginatedResponse(w, err, notifications, nextCursor, 0)
+	return
+}
+
+// MarkNotificationsRead godoc
+// @Summary  Mark notifications read
+// @Description Mark the given notifications of the current user as read, or all of them when the body is empty.
+// @Produce			application/json
+// @Tags   notification
+// @Accept   json
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param			read body models.ReadNotificationsPayload{} false "Read Payload"
+// @Success  200 {object} models.NotificationsRead{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Router   /notifications/read [post]
+func (c *Controller) MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	// The body is optional: without one, every notification is read.
+	var payload models.ReadNotificationsPayload
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil && !errors.Is(err, io.EOF) {
+		HttpResponse(w, errors.New("invalid payload"), nil, 400)
+		return
+	}
+	err = payload.Validate()
+	if err != nil {
+		HttpResponse(w, err, nil, 400)
+		return
+	}
+	read, err := c.NotificationService.MarkRead(r.Context(), *account, payload)
+	HttpResponse(w, err, read, 0)
+	return
+}
+
+// StreamEvents godoc
+// @Summary  Stream real-time events
+// @Description Push the events that concern the current user as server-sent events: match.created, match.unmatched, match.expiring_soon, match.expired, message.sent, message.read, typing.started, typing.stopped and swipe.super_liked. Each event's data is its JSON. The stream is pinged every 25 seconds, and ends when the token is revoked or the client falls too far behind; clients then reconnect and catch up through the REST endpoints.
+// @Produce			text/event-stream
+// @Tags   events
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Success  200 {string} string "text/event-stream"
+// @Failure  401 {object} controllers.ErrorResponse{}
+// @Router   /events [get]
+func (c *Controller) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	token, err := interceptors.GetAuthenticatedAccountToken(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		HttpResponse(w, errors.New("streaming is not supported"), nil, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Stops reverse proxies from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	conn := c.Gateway.Connect(account.ID)
+	defer c.Gateway.Disconnect(conn)
+	heartbeat := time.NewTicker(constants.GatewayHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-conn.Done():
+			return
+		case event := <-conn.Events():
+			if err := writeServerSentEvent(w, flusher, event.Topic(), event.Data()); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			// The stream outlives single requests, so the token is checked again, the same way
+			// AuthMiddleware checks it: a revoked token, a ban or a suspension ends the stream.
+			if _, err := c.Middlewares.ValidateHeaders(r.Context(), token, true); err != nil {
+				_ = writeServerSentEvent(w, flusher, "unauthorized", []byte(`{"message":"session is invalid"}`))
+				return
+			}
+			if err := writeServerSentComment(w, flusher, "heartbeat"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// ListSwipes godoc
+// @Summary  List my swipes
+// @Description List the user's own likes and passes, newest first, optionally within a date range
+// @Produce			application/json
+// @Tags   swipe
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param interested query bool false "true for likes only, false for passes only"
+// @Param prospect_id query string false "Only swipes on this user"
+// @Param from query string false "Earliest swipe time, RFC 3339, inclusive"
+// @Param to query string false "Latest swipe time, RFC 3339, inclusive"
+// @Param limit query int false "Page size, 20 by default and at most 100"
+// @Param cursor query string false "The next_cursor of the previous page"
+// @Success  200 {object} []models.Swipe{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Router   /swipes [get]
+func (c *Controller) ListSwipes(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	filter, err := parseSwipeFilter(r)
+	if err != nil {
+		HttpResponse(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	page, err := parsePage(r)
+	if err != nil {
+		HttpResponse(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	swipes, nextCursor, err := c.SwipeService.ListSwipes(r.Context(), *account, filter, page)
+	HttpPaginatedResponse(w, err, swipes, nextCursor, 0)
+	return
+}
+
+// GetReceivedLikes godoc
+// @Summary  List received likes
+// @Description List the likes the user received from users they have not swiped on yet, newest first
+// @Produce			application/json
+// @Tags   swipe
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param limit query int false "Page size, 20 by default and at most 100"
+// @Param cursor query string false "The next_cursor of the previous page"
+// @Success  200 {object} []models.ReceivedLike{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Router   /likes/received [get]
+func (c *Controller) GetReceivedLikes(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	page, err := parsePage(r)
+	if err != nil {
+		HttpResponse(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	likes, nextCursor, err := c.SwipeService.GetReceivedLikes(r.Context(), *account, page)
+	HttpPaginatedResponse(w, err, likes, nextCursor, 0)
+	return
+}
+
+// LikeBack godoc
+// @Summary  Like back
+// @Description Like a user who liked you, which makes a match
+// @Produce			application/json
+// @Tags   swipe
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "ID of the user who liked you"
+// @Success  200 {object} models.SwipeResponse{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Failure  429 {object} controllers.ErrorResponse{}
+// @Router   /likes/received/{id}/like [post]
+func (c *Controller) LikeBack(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	swipeResponse, err := c.SwipeService.LikeBack(r.Context(), *account, chi.URLParam(r, "id"))
+	if respondQuotaExceeded(w, err) {
+		return
+	}
+	if errors.Is(err, services.ErrLikeNotFound) {
+		HttpResponse(w, err, nil, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, services.ErrProspectBlocked) || errors.Is(err, services.ErrSwiperDeactivated) {
+		HttpResponse(w, err, nil, http.StatusForbidden)
+		return
+	}
+	HttpResponse(w, err, swipeResponse, 0)
+	return
+}
+
+// BlockUser godoc
+// @Summary  Block a user
+// @Description Block a user. Neither user sees, swipes on or matches with the other again, and their match, if any, ends.
+// @Produce			application/json
+// @Tags   safety
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "ID of the user to block"
+// @Success  200 {object} models.Block{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Router   /users/{id}/block [post]
+func (c *Controller) BlockUser(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	block, err := c.SafetyService.Block(r.Context(), *account, chi.URLParam(r, "id"))
+	if errors.Is(err, services.ErrUserNotFound) {
+		HttpResponse(w, err, nil, http.StatusNotFound)
+		return
+	}
+	HttpResponse(w, err, block, 0)
+	return
+}
+
+// ReportUser godoc
+// @Summary  Report a user
+// @Description Report a user to the moderators, with a reason and optional details
+// @Produce			application/json
+// @Tags   safety
+// @Accept   json
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "ID of the user to report"
+// @Param			report body models.ReportPayload{} true "Report Payload"
+// @Success  200 {object} models.Report{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Router   /users/{id}/report [post]
+func (c *Controller) ReportUser(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	var payload models.ReportPayload
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		HttpResponse(w, errors.New("invalid payload"), nil, 400)
+		return
+	}
+	err = payload.Validate()
+	if err != nil {
+		HttpResponse(w, err, nil, 400)
+		return
+	}
+	report, err := c.SafetyService.Report(r.Context(), *account, chi.URLParam(r, "id"), payload)
+	if errors.Is(err, services.ErrUserNotFound) {
+		HttpResponse(w, err, nil, http.StatusNotFound)
+		return
+	}
+	HttpResponse(w, err, report, 0)
+	return
+}
+
+// ListReports godoc
+// @Summary  List reports
+// @Description List the reports in the moderation queue, oldest first. Moderators and admins only.
+// @Produce			application/json
+// @Tags   admin
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param state query string false "open, reviewing, actioned or dismissed"
+// @Param limit query int false "Page size, 20 by default and at most 100"
+// @Param cursor query string false "The next_cursor of the previous page"
+// @Success  200 {object} []models.Report{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Router   /admin/reports [get]
+func (c *Controller) ListReports(w http.ResponseWriter, r *http.Request) {
+	var filter models.ReportFilter
+	if state := models.ReportState(r.URL.Query().Get("state")); state != "" {
+		filter.State = &state
+	}
+	if err := filter.Validate(); err != nil {
+		HttpResponse(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	page, err := parsePage(r)
+	if err != nil {
+		HttpResponse(w, err, nil, http.StatusBadRequest)
+		return
+	}
+	reports, nextCursor, err := c.ModerationService.ListReports(r.Context(), filter, page)
+	HttpPaginatedResponse(w, err, reports, nextCursor, 0)
+	return
+}
+
+// ClaimReport godoc
+// @Summary  Claim a report
+// @Description Put an open report under review by the current moderator. Moderators and admins only.
+// @Produce			application/json
+// @Tags   admin
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "Report ID"
+// @Success  200 {object} models.Report{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Failure  409 {object} controllers.ErrorResponse{}
+// @Router   /admin/reports/{id}/claim [post]
+func (c *Controller) ClaimReport(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	report, err := c.ModerationService.ClaimReport(r.Context(), *account, chi.URLParam(r, "id"))
+	if respondModerationError(w, err) {
+		return
+	}
+	HttpResponse(w, err, report, 0)
+	return
+}
+
+// ResolveReport godoc
+// @Summary  Resolve a report
+// @Description Dismiss a report under review by the current moderator, or action it by warning, suspending or deactivating the reported user. Moderators and admins only.
+// @Produce			application/json
+// @Tags   admin
+// @Accept   json
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "Report ID"
+// @Param			resolution body models.ReportResolution{} true "Report Resolution"
+// @Success  200 {object} models.Report{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Failure  409 {object} controllers.ErrorResponse{}
+// @Router   /admin/reports/{id}/resolve [post]
+func (c *Controller) ResolveReport(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	var payload models.ReportResolution
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		HttpResponse(w, errors.New("invalid payload"), nil, 400)
+		return
+	}
+	err = payload.Validate()
+	if err != nil {
+		HttpResponse(w, err, nil, 400)
+		return
+	}
+	report, err := c.ModerationService.ResolveReport(r.Context(), *account, chi.URLParam(r, "id"), payload)
+	if respondModerationError(w, err) {
+		return
+	}
+	HttpResponse(w, err, report, 0)
+	return
+}
+
+// GetReportAuditTrail godoc
+// @Summary  Get the audit trail of a report
+// @Description List every moderation step taken on a report, oldest first. Moderators and admins only.
+// @Produce			application/json
+// @Tags   admin
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "Report ID"
+// @Success  200 {object} []models.AuditEntry{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Router   /admin/reports/{id}/audit [get]
+func (c *Controller) GetReportAuditTrail(w http.ResponseWriter, r *http.Request) {
+	entries, err := c.ModerationService.GetAuditTrail(r.Context(), chi.URLParam(r, "id"))
+	if respondModerationError(w, err) {
+		return
+	}
+	HttpResponse(w, err, entries, 0)
+	return
+}
+
+// GetUserAsAdmin godoc
+// @Summary  Get any user
+// @Description Get the profile of any user, including their status and role. Admins only.
+// @Produce			application/json
+// @Tags   admin
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "User ID"
+// @Success  200 {object} models.User{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Router   /admin/users/{id} [get]
+func (c *Controller) GetUserAsAdmin(w http.ResponseWriter, r *http.Request) {
+	user, err := c.AdminService.GetUser(r.Context(), chi.URLParam(r, "id"))
+	if respondAdminError(w, err) {
+		return
+	}
+	HttpResponse(w, err, user, 0)
+	return
+}
+
+// GetUserAuditTrail godoc
+// @Summary  Get the audit trail of a user
+// @Description List every moderation step and admin change taken on a user, oldest first. Admins only.
+// @Produce			application/json
+// @Tags   admin
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "User ID"
+// @Success  200 {object} []models.AuditEntry{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Router   /admin/users/{id}/audit [get]
+func (c *Controller) GetUserAuditTrail(w http.ResponseWriter, r *http.Request) {
+	entries, err := c.AdminService.GetUserAuditTrail(r.Context(), chi.URLParam(r, "id"))
+	if respondAdminError(w, err) {
+		return
+	}
+	HttpResponse(w, err, entries, 0)
+	return
+}
+
+// ChangeUserStatus godoc
+// @Summary  Change a user's status
+// @Description Activate or deactivate a user. A deactivated user cannot log in until an admin activates them again. Admins only.
+// @Produce			application/json
+// @Tags   admin
+// @Accept   json
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "User ID"
+// @Param			status body models.StatusPayload{} true "Status"
+// @Success  200 {object} models.User{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Router   /admin/users/{id}/status [put]
+func (c *Controller) ChangeUserStatus(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	var payload models.StatusPayload
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		HttpResponse(w, errors.New("invalid payload"), nil, 400)
+		return
+	}
+	err = payload.Validate()
+	if err != nil {
+		HttpResponse(w, err, nil, 400)
+		return
+	}
+	user, err := c.AdminService.ChangeStatus(r.Context(), *account, chi.URLParam(r, "id"), payload)
+	if respondAdminError(w, err) {
+		return
+	}
+	HttpResponse(w, err, user, 0)
+	return
+}
+
+// ChangeUserRole godoc
+// @Summary  Change a user's role
+// @Description Make a user a regular user, a moderator or an admin. The user's tokens are revoked. Admins only.
+// @Produce			application/json
+// @Tags   admin
+// @Accept   json
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "User ID"
+// @Param			role body models.RolePayload{} true "Role"
+// @Success  200 {object} models.User{}
+// @Failure  400 {object} controllers.ErrorResponse{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Router   /admin/users/{id}/role [put]
+func (c *Controller) ChangeUserRole(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	var payload models.RolePayload
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		HttpResponse(w, errors.New("invalid payload"), nil, 400)
+		return
+	}
+	err = payload.Validate()
+	if err != nil {
+		HttpResponse(w, err, nil, 400)
+		return
+	}
+	user, err := c.AdminService.ChangeRole(r.Context(), *account, chi.URLParam(r, "id"), payload)
+	if respondAdminError(w, err) {
+		return
+	}
+	HttpResponse(w, err, user, 0)
+	return
+}
+
+// ResetUserPassword godoc
+// @Summary  Reset a user's password
+// @Description Replace a user's password with a temporary password and revoke their tokens. The temporary password is returned once. Admins only.
+// @Produce			application/json
+// @Tags   admin
+// @Security BearerToken
+// @Param Authorization header string true "Bearer Token" default(bearer)
+// @Param id path string true "User ID"
+// @Success  200 {object} models.PasswordResetResponse{}
+// @Failure  403 {object} controllers.ErrorResponse{}
+// @Failure  404 {object} controllers.ErrorResponse{}
+// @Router   /admin/users/{id}/password-reset [post]
+func (c *Controller) ResetUserPassword(w http.ResponseWriter, r *http.Request) {
+	account, err := interceptors.GetAuthenticatedAccount(r.Context())
+	if err != nil {
+		HttpResponse(w, errors.New("unauthorized account"), nil, 401)
+		return
+	}
+	response, err := c.AdminService.ResetPassword(r.Context(), *account, chi.URLParam(r, "id"))
+	if respondAdminError(w, err) {
+		return
+	}
+	HttpResponse(w, err, response, 0)
+	return
+}
+
+// respondAdminError responds 404 to an unknown user and 403 to an admin changing their own
+// access, and reports whether it responded.
+func respondAdminError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		HttpResponse(w, err, nil, http.StatusNotFound)
+	case errors.Is(err, services.ErrCannotChangeOwnAccess):
+		HttpResponse(w, err, nil, http.StatusForbidden)
+	default:
+		return false
+	}
+	return true
+}
+
+// respondModerationError responds 404 to an unknown report and 409 to a report in the wrong
+// state, and reports whether it responded.
+func respondModerationError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrReportNotFound):
+		HttpResponse(w, err, nil, http.StatusNotFound)
+	case errors.Is(err, services.ErrReportNotOpen), errors.Is(err, services.ErrReportNotClaimed):
+		HttpResponse(w, err, nil, http.StatusConflict)
+	case errors.Is(err, services.ErrCannotModerateSelf), errors.Is(err, services.ErrCannotModerateStaff):
+		HttpResponse(w, err, nil, http.StatusForbidden)
+	default:
+		return false
+	}
+	return true
+}
+
+// parseListParam splits a comma-separated query parameter such as "muslim,other" into
+// lowercase values, dropping empty entries.
+func parseListParam(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// parseSwipeFilter reads the interested, prospect_id, from and to query parameters of a swipe listing.
+func parseSwipeFilter(r *http.Request) (models.SwipeFilter, error) {
+	q := r.URL.Query()
+	var filter models.SwipeFilter
+	if value := q.Get("interested"); value != "" {
+		interested, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("interested must be true or false")
+		}
+		filter.Interested = &interested
+	}
+	if value := q.Get("prospect_id"); value != "" {
+		filter.ProspectID = &value
+	}
+	var err error
+	if value := q.Get("from"); value != "" {
+		if filter.MinSwipeDate, err = time.Parse(time.RFC3339, value); err != nil {
+			return filter, errors.New("from must be an RFC 3339 time")
+		}
+	}
+	if value := q.Get("to"); value != "" {
+		if filter.MaxSwipeDate, err = time.Parse(time.RFC3339, value); err != nil {
+			return filter, errors.New("to must be an RFC 3339 time")
+		}
+	}
+	return filter, filter.Validate()
+}
+
+// parsePage reads the limit and cursor pagination query parameters.
+func parsePage(r *http.Request) (models.Page, error) {
+	q := r.URL.Query()
+	page := models.Page{Cursor: q.Get("cursor")}
+	if limit := q.Get("limit"); limit != "" {
+		var err error
+		if page.Limit, err = strconv.Atoi(limit); err != nil {
+			return page, errors.New("limit must be a number")
+		}
+	}
+	return page, page.Validate()
+}
@@ -2,11 +2,13 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 )
 
 type Result struct {
-	Data interface{} `json:"results"`
+	Data       interface{} `json:"results"`
+	NextCursor string      `json:"next_cursor,omitempty"`
 }
 
 type ErrorResponse struct {
@@ -24,10 +26,21 @@ func HttpResponse(w http.ResponseWriter, err error, data interface{}, code int)
 	respondWithJSON(w, http.StatusOK, data)
 }
 
-func respondWithJSON(w http.ResponseWriter, status int, data interface{}) {
-	result := Result{
-		Data: data,
+// HttpPaginatedResponse works like HttpResponse and also returns the cursor of the next page,
+// which is left out of the response on the last page.
+func HttpPaginatedResponse(w http.ResponseWriter, err error, data interface{}, nextCursor string, code int) {
+	if err != nil {
+		HttpResponse(w, err, nil, code)
+		return
 	}
+	respondWithResult(w, http.StatusOK, Result{Data: data, NextCursor: nextCursor})
+}
+
+func respondWithJSON(w http.ResponseWriter, status int, data interface{}) {
+	respondWithResult(w, status, Result{Data: data})
+}
+
+func respondWithResult(w http.ResponseWriter, status int, result Result) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	err := json.NewEncoder(w).Encode(result)
@@ -47,3 +60,22 @@ func respondWithError(w http.ResponseWriter, status int, message string) {
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 	}
 }
+
+// writeServerSentEvent writes one event of a text/event-stream response and flushes it to the
+// client. Event data is single-line JSON, so it fits one data field.
+func writeServerSentEvent(w http.ResponseWriter, flusher http.Flusher, event string, data []byte) error {
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
+
+// writeServerSentComment writes a comment line, which clients ignore, to keep an idle stream open.
+func writeServerSentComment(w http.ResponseWriter, flusher http.Flusher, comment string) error {
+	if _, err := fmt.Fprintf(w, ": %s\n\n", comment); err != nil {
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
@@ -44,24 +44,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/discover": {
+        "/admin/reports": {
             "get": {
                 "security": [
                     {
                         "BearerToken": []
                     }
                 ],
-                "description": "Discover users",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "List the reports in the moderation queue, oldest first. Moderators and admins only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "discover"
+                    "admin"
                 ],
-                "summary": "Discover users",
+                "summary": "List reports",
                 "parameters": [
                     {
                         "type": "string",
@@ -72,21 +69,21 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Minimum Age",
-                        "name": "min_age",
+                        "type": "string",
+                        "description": "open, reviewing, actioned or dismissed",
+                        "name": "state",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Maximum Age",
-                        "name": "max_age",
+                        "description": "Page size, 20 by default and at most 100",
+                        "name": "limit",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Maximum Distance",
-                        "name": "max_distance",
+                        "type": "string",
+                        "description": "The next_cursor of the previous page",
+                        "name": "cursor",
                         "in": "query"
                     }
                 ],
@@ -96,7 +93,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.User"
+                                "$ref": "#/definitions/models.Report"
                             }
                         }
                     },
@@ -105,13 +102,141 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/login": {
+        "/admin/reports/{id}/audit": {
+            "get": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "List every moderation step taken on a report, oldest first. Moderators and admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get the audit trail of a report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Report ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AuditEntry"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reports/{id}/claim": {
+            "post": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Put an open report under review by the current moderator. Moderators and admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Claim a report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Report ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Report"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reports/{id}/resolve": {
             "post": {
-                "description": "Login a user with email and password",
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Dismiss a report under review by the current moderator, or action it by warning, suspending or deactivating the reported user. Moderators and admins only.",
                 "consumes": [
                     "application/json"
                 ],
@@ -119,17 +244,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "admin"
                 ],
-                "summary": "Login a user with email and password",
+                "summary": "Resolve a report",
                 "parameters": [
                     {
-                        "description": "Login Payload",
-                        "name": "user",
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Report ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Report Resolution",
+                        "name": "resolution",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.LoginPayload"
+                            "$ref": "#/definitions/models.ReportResolution"
                         }
                     }
                 ],
@@ -137,7 +277,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.LoginResponse"
+                            "$ref": "#/definitions/models.Report"
                         }
                     },
                     "400": {
@@ -145,28 +285,43 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/user": {
+        "/admin/users/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerToken": []
                     }
                 ],
-                "description": "Get a user",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get the profile of any user, including their status and role. Admins only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "admin"
                 ],
-                "summary": "Get a user",
+                "summary": "Get any user",
                 "parameters": [
                     {
                         "type": "string",
@@ -175,6 +330,13 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -184,8 +346,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.User"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
@@ -193,73 +361,2426 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/create": {
-            "post": {
-                "description": "Generate a random user and return the user details",
-                "consumes": [
-                    "application/json"
+        "/admin/users/{id}/audit": {
+            "get": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
                 ],
+                "description": "List every moderation step and admin change taken on a user, oldest first. Admins only.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "admin"
+                ],
+                "summary": "Get the audit trail of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Create a random user",
                 "responses": {
                     "200": {
-                        "description": "Successful response",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.RegistrationResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AuditEntry"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/controllers.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "controllers.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "message": {
-                    "type": "string"
+        },
+        "/admin/users/{id}/password-reset": {
+            "post": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Replace a user's password with a temporary password and revoke their tokens. The temporary password is returned once. Admins only.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reset a user's password",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PasswordResetResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "models.Gender": {
-            "type": "string",
-            "enum": [
-                "male",
-                "female",
-                "non-binary"
-            ],
+        "/admin/users/{id}/role": {
+            "put": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Make a user a regular user, a moderator or an admin. The user's tokens are revoked. Admins only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Change a user's role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RolePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/status": {
+            "put": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Activate or deactivate a user. A deactivated user cannot log in until an admin activates them again. Admins only.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Change a user's status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Status",
+                        "name": "status",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.StatusPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/discover": {
+            "get": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Discover users, the ones who super-liked the user first, then everyone else, each ranked by how well they suit the user. The 500 nearest candidates are ranked and paged through. Without any query filter, the user's saved preferences are used.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "discover"
+                ],
+                "summary": "Discover users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum Age",
+                        "name": "min_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum Age",
+                        "name": "max_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum Distance",
+                        "name": "max_distance",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum Height",
+                        "name": "min_height",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum Height",
+                        "name": "max_height",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated ethnicities",
+                        "name": "desired_ethnicity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated pets",
+                        "name": "desired_pets",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated sexualities",
+                        "name": "desired_sexuality",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated drinking habits",
+                        "name": "desired_drinking",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated smoking habits",
+                        "name": "desired_smoking",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated drug habits",
+                        "name": "desired_drugs",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated dating intentions",
+                        "name": "desired_intentions",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated religions",
+                        "name": "desired_religion",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 20 by default and at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include the breakdown of each profile's ranking score",
+                        "name": "explain",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Push the events that concern the current user as server-sent events: match.created, match.unmatched, match.expiring_soon, match.expired, message.sent, message.read, typing.started, typing.stopped and swipe.super_liked. Each event's data is its JSON. The stream is pinged every 25 seconds, and ends when the token is revoked or the client falls too far behind; clients then reconnect and catch up through the REST endpoints.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Stream real-time events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "text/event-stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/likes/received": {
+            "get": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "List the likes the user received from users they have not swiped on yet, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swipe"
+                ],
+                "summary": "List received likes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 20 by default and at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ReceivedLike"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/likes/received/{id}/like": {
+            "post": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Like a user who liked you, which makes a match",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swipe"
+                ],
+                "summary": "Like back",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user who liked you",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SwipeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "Login a user with email and password. If the user deactivated their account, deactivated is set and the token can be used to reactivate it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Login a user with email and password",
+                "parameters": [
+                    {
+                        "description": "Login Payload",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches": {
+            "get": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "List the user's matches, newest first, each with the other party of the match",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "match"
+                ],
+                "summary": "List matches",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 20 by default and at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.MatchedUser"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "End a match. Either participant can unmatch, and the pair is never shown to each other again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "match"
+                ],
+                "summary": "Unmatch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Match"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/{id}/extend": {
+            "post": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Push back the expiry of a match that is about to expire for lack of messages. Each participant can extend a match once.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "match"
+                ],
+                "summary": "Extend a match",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.MatchExpiry"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/{id}/messages": {
+            "get": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "List the messages of a match, newest first. Only the participants of a current match can read them.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "message"
+                ],
+                "summary": "List messages",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 20 by default and at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Message"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Send a message to the other participant of a match. Only matched pairs can chat, so messages are rejected after an unmatch or a block.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "message"
+                ],
+                "summary": "Send a message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Message Payload",
+                        "name": "message",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.MessagePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/{id}/read": {
+            "post": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Mark the messages the current user received in a match as read, up to and including the given message, or all of them when the body is empty. The other participant gets a message.read event when anything new was read.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "message"
+                ],
+                "summary": "Mark messages read",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Read Payload",
+                        "name": "read",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReadPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReadReceipt"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/matches/{id}/typing": {
+            "post": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Tell the other participant of a match that the current user started or stopped typing, through typing.started and typing.stopped events. Nothing is stored, so clients should send a stop when the user stops typing.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "message"
+                ],
+                "summary": "Set typing indicator",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Match ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Typing Payload",
+                        "name": "typing",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TypingPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TypingPayload"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications": {
+            "get": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "List the current user's in-app notifications, newest first: new matches, matches about to expire or expired, messages, likes and super-likes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notification"
+                ],
+                "summary": "List notifications",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 20 by default and at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Notification"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/read": {
+            "post": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Mark the given notifications of the current user as read, or all of them when the body is empty.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notification"
+                ],
+                "summary": "Mark notifications read",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Read Payload",
+                        "name": "read",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.ReadNotificationsPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.NotificationsRead"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/swipe/quota": {
+            "get": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Get the likes, passes and rewinds left today, and when the quota resets at the user's local midnight",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swipe"
+                ],
+                "summary": "Get the daily swipe quota",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SwipeQuota"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/swipe/undo": {
+            "post": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Revert the user's most recent swipe within the rewind window, rolling back the match it made",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swipe"
+                ],
+                "summary": "Undo the last swipe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Swipe"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/swipes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "List the user's own likes and passes, newest first, optionally within a date range",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swipe"
+                ],
+                "summary": "List my swipes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "true for likes only, false for passes only",
+                        "name": "interested",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only swipes on this user",
+                        "name": "prospect_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earliest swipe time, RFC 3339, inclusive",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest swipe time, RFC 3339, inclusive",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 20 by default and at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "The next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Swipe"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "get": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Get a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Update only the profile fields provided in the request body",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Update the current user's profile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Update User Payload",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateUserPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/create": {
+            "post": {
+                "description": "Register a user with their name, email, password, gender and date of birth",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Register a user",
+                "parameters": [
+                    {
+                        "description": "Registration Payload",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.RegistrationPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response",
+                        "schema": {
+                            "$ref": "#/definitions/models.RegistrationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/deactivate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Hide the current user from discovery and from other users' matches, and stop them from swiping, until they reactivate their account.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Deactivate the current user's account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/password": {
+            "put": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Change the password and revoke every previously issued token. A new token is returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Change the current user's password",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Update Password Payload",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdatePasswordPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/preferences": {
+            "get": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Get the current user's saved dating preferences",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get the current user's dating preferences",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Preferences"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Replace the current user's dating preferences. Deal-breakers filter discovery results, the rest rank them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Save the current user's dating preferences",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Preferences",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Preferences"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Preferences"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/reactivate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Reactivate an account the current user deactivated.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Reactivate the current user's account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/block": {
+            "post": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Block a user. Neither user sees, swipes on or matches with the other again, and their match, if any, ends.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "safety"
+                ],
+                "summary": "Block a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user to block",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Block"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/report": {
+            "post": {
+                "security": [
+                    {
+                        "BearerToken": []
+                    }
+                ],
+                "description": "Report a user to the moderators, with a reason and optional details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "safety"
+                ],
+                "summary": "Report a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "bearer",
+                        "description": "Bearer Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user to report",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Report Payload",
+                        "name": "report",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReportPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "controllers.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AgeRange": {
+            "type": "object",
+            "properties": {
+                "deal_breaker": {
+                    "type": "boolean"
+                },
+                "max": {
+                    "type": "integer"
+                },
+                "min": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/models.ModerationAction"
+                },
+                "change": {
+                    "description": "Change is the new status or role of a status or role change.",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "$ref": "#/definitions/models.AuditEvent"
+                },
+                "from_state": {
+                    "$ref": "#/definitions/models.ReportState"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "moderator_id": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "report_id": {
+                    "type": "string"
+                },
+                "target_user_id": {
+                    "type": "string"
+                },
+                "to_state": {
+                    "$ref": "#/definitions/models.ReportState"
+                }
+            }
+        },
+        "models.AuditEvent": {
+            "type": "string",
+            "enum": [
+                "report_claimed",
+                "report_resolved",
+                "user_moderated",
+                "status_changed",
+                "role_changed",
+                "password_reset"
+            ],
+            "x-enum-varnames": [
+                "ReportClaimedEvent",
+                "ReportResolvedEvent",
+                "UserModeratedEvent",
+                "StatusChangedEvent",
+                "RoleChangedEvent",
+                "PasswordResetEvent"
+            ]
+        },
+        "models.Block": {
+            "type": "object",
+            "properties": {
+                "blocked_id": {
+                    "type": "string"
+                },
+                "blocker_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DrinkingHabit": {
+            "type": "string",
+            "enum": [
+                "yes",
+                "no"
+            ],
+            "x-enum-varnames": [
+                "YesDrinkingHabit",
+                "NoDrinkingHabit"
+            ]
+        },
+        "models.DrinkingPreference": {
+            "type": "object",
+            "properties": {
+                "deal_breaker": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.DrinkingHabit"
+                }
+            }
+        },
+        "models.DrugHabit": {
+            "type": "string",
+            "enum": [
+                "yes",
+                "no"
+            ],
+            "x-enum-varnames": [
+                "YesDrugHabit",
+                "NoDrugHabit"
+            ]
+        },
+        "models.DrugPreference": {
+            "type": "object",
+            "properties": {
+                "deal_breaker": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.DrugHabit"
+                }
+            }
+        },
+        "models.EducationLevel": {
+            "type": "string",
+            "enum": [
+                "High School",
+                "College",
+                "Associates Degree",
+                "Bachelors Degree",
+                "Masters Degree",
+                "PhD/Post Doctoral",
+                "Open to All"
+            ],
+            "x-enum-varnames": [
+                "HighSchool",
+                "College",
+                "AssociatesDegree",
+                "BachelorsDegree",
+                "MastersDegree",
+                "PhDPostDoctoral",
+                "OpenToAll"
+            ]
+        },
+        "models.Ethnicity": {
+            "type": "string",
+            "enum": [
+                "white",
+                "black",
+                "asian",
+                "latino",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "White",
+                "Black",
+                "Asian",
+                "Latino",
+                "Other"
+            ]
+        },
+        "models.Gender": {
+            "type": "string",
+            "enum": [
+                "male",
+                "female",
+                "non-binary"
+            ],
             "x-enum-varnames": [
                 "Male",
                 "Female",
                 "NonBinary"
             ]
         },
-        "models.LoginPayload": {
+        "models.HeightRange": {
+            "type": "object",
+            "properties": {
+                "deal_breaker": {
+                    "type": "boolean"
+                },
+                "max": {
+                    "type": "integer"
+                },
+                "min": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Intentions": {
+            "type": "string",
+            "enum": [
+                "life partner",
+                "shorter time",
+                "none",
+                "figuring out",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "LifePartner",
+                "ShorterTime",
+                "NoneIntention",
+                "FiguringOut",
+                "OtherIntention"
+            ]
+        },
+        "models.LoginPayload": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "deactivated": {
+                    "description": "Deactivated is set when the user deactivated their account, which they can reactivate\nwith the token.",
+                    "type": "boolean"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Match": {
+            "type": "object",
+            "properties": {
+                "expired_at": {
+                    "type": "string"
+                },
+                "extended_by": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "extended_until": {
+                    "description": "ExtendedUntil is the expiry the match was pushed back to by its participants, and ExtendedBy\nthe participants who used their one extension.",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_activity_at": {
+                    "type": "string"
+                },
+                "matched": {
+                    "type": "boolean"
+                },
+                "matched_at": {
+                    "type": "string"
+                },
+                "profiles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "unmatched_at": {
+                    "type": "string"
+                },
+                "unmatched_by": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MatchExpiry": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "extended_by": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "match_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MatchedUser": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "ExpiresAt is when the match expires if it stays quiet, when matches expire.",
+                    "type": "string"
+                },
+                "last_activity_at": {
+                    "type": "string"
+                },
+                "match_id": {
+                    "type": "string"
+                },
+                "matched_at": {
+                    "type": "string"
+                },
+                "unread_count": {
+                    "description": "UnreadCount is the number of messages from the other party the user has not read.",
+                    "type": "integer"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
+                }
+            }
+        },
+        "models.Message": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "description": "DeliveredAt is set when the recipient first fetches the message, ReadAt when they read it.",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "match_id": {
+                    "type": "string"
+                },
+                "read_at": {
+                    "type": "string"
+                },
+                "sender_id": {
+                    "type": "string"
+                },
+                "sent_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.MessagePayload": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ModerationAction": {
+            "type": "string",
+            "enum": [
+                "warn",
+                "suspend",
+                "deactivate"
+            ],
+            "x-enum-varnames": [
+                "WarnAction",
+                "SuspendAction",
+                "DeactivateAction"
+            ]
+        },
+        "models.Notification": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "data": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "read_at": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.NotificationsRead": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.PasswordResetResponse": {
+            "type": "object",
+            "properties": {
+                "temporary_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Preferences": {
+            "type": "object",
+            "properties": {
+                "age_range": {
+                    "$ref": "#/definitions/models.AgeRange"
+                },
+                "children": {
+                    "description": "Doesn't have children, has children, open to all",
+                    "type": "string"
+                },
+                "drinking": {
+                    "description": "Never, sometimes, often, open to all",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DrinkingPreference"
+                        }
+                    ]
+                },
+                "drugs": {
+                    "description": "Never, sometimes, often, open to all",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DrugPreference"
+                        }
+                    ]
+                },
+                "education": {
+                    "description": "High School, Some College, Associates Degree, Bachelors Degree, Masters Degree, PhD/Post Doctoral, open to all",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.EducationLevel"
+                        }
+                    ]
+                },
+                "ethnicity": {
+                    "$ref": "#/definitions/models.Ethnicity"
+                },
+                "family_plans": {
+                    "description": "Doesn't want children, wants children, open to all, not sure yet, might want children",
+                    "type": "string"
+                },
+                "height": {
+                    "$ref": "#/definitions/models.HeightRange"
+                },
+                "interested_in": {
+                    "description": "men, women, non-binary, everyone",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Gender"
+                        }
+                    ]
+                },
+                "max_distance": {
+                    "type": "integer"
+                },
+                "religion": {
+                    "$ref": "#/definitions/models.Religion"
+                },
+                "smoking": {
+                    "description": "Never, sometimes, often, open to all",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SmokingPreference"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.RankingExplanation": {
+            "type": "object",
+            "properties": {
+                "components": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "score": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.ReadNotificationsPayload": {
+            "type": "object",
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.ReadPayload": {
+            "type": "object",
+            "properties": {
+                "message_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ReadReceipt": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "match_id": {
+                    "type": "string"
+                },
+                "reader_id": {
+                    "type": "string"
+                },
+                "sender_id": {
+                    "type": "string"
+                },
+                "up_to": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ReceivedLike": {
             "type": "object",
             "properties": {
-                "email": {
+                "kind": {
+                    "$ref": "#/definitions/models.SwipeKind"
+                },
+                "liked_at": {
                     "type": "string"
                 },
-                "password": {
+                "swipe_id": {
                     "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/models.User"
                 }
             }
         },
-        "models.LoginResponse": {
+        "models.RegistrationPayload": {
             "type": "object",
             "properties": {
-                "token": {
+                "date_of_birth": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "gender": {
+                    "$ref": "#/definitions/models.Gender"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "password": {
                     "type": "string"
                 }
             }
@@ -281,23 +2802,300 @@ const docTemplate = `{
                 },
                 "name": {
                     "type": "string"
+                }
+            }
+        },
+        "models.Religion": {
+            "type": "string",
+            "enum": [
+                "christian",
+                "muslim",
+                "hindu",
+                "buddhist",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "Christian",
+                "Muslim",
+                "Hindu",
+                "Buddhist",
+                "OtherReligion"
+            ]
+        },
+        "models.Report": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/models.ModerationAction"
+                },
+                "claimed_at": {
+                    "type": "string"
                 },
-                "password": {
+                "claimed_by": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "reason": {
+                    "$ref": "#/definitions/models.ReportReason"
+                },
+                "reported_id": {
+                    "type": "string"
+                },
+                "reporter_id": {
+                    "type": "string"
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "state": {
+                    "$ref": "#/definitions/models.ReportState"
+                }
+            }
+        },
+        "models.ReportPayload": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "string"
+                },
+                "reason": {
+                    "$ref": "#/definitions/models.ReportReason"
+                }
+            }
+        },
+        "models.ReportReason": {
+            "type": "string",
+            "enum": [
+                "spam",
+                "harassment",
+                "inappropriate_content",
+                "fake_profile",
+                "underage",
+                "scam",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "SpamReason",
+                "HarassmentReason",
+                "InappropriateContentReason",
+                "FakeProfileReason",
+                "UnderageReason",
+                "ScamReason",
+                "OtherReason"
+            ]
+        },
+        "models.ReportResolution": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/models.ModerationAction"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "state": {
+                    "$ref": "#/definitions/models.ReportState"
+                },
+                "suspend_days": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ReportState": {
+            "type": "string",
+            "enum": [
+                "open",
+                "reviewing",
+                "actioned",
+                "dismissed"
+            ],
+            "x-enum-varnames": [
+                "OpenReport",
+                "ReviewingReport",
+                "ActionedReport",
+                "DismissedReport"
+            ]
+        },
+        "models.Role": {
+            "type": "string",
+            "enum": [
+                "user",
+                "moderator",
+                "admin"
+            ],
+            "x-enum-varnames": [
+                "UserRole",
+                "ModeratorRole",
+                "AdminRole"
+            ]
+        },
+        "models.RolePayload": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/models.Role"
+                }
+            }
+        },
+        "models.Sexuality": {
+            "type": "string",
+            "enum": [
+                "straight",
+                "gay",
+                "bisexual",
+                "pansexual",
+                "asexual",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "Straight",
+                "Gay",
+                "Bisexual",
+                "Pansexual",
+                "Asexual",
+                "OtherSexuality"
+            ]
+        },
+        "models.SmokingHabit": {
+            "type": "string",
+            "enum": [
+                "yes",
+                "no"
+            ],
+            "x-enum-varnames": [
+                "YesSmokingHabit",
+                "NoSmokingHabit"
+            ]
+        },
+        "models.SmokingPreference": {
+            "type": "object",
+            "properties": {
+                "deal_breaker": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.SmokingHabit"
+                }
+            }
+        },
+        "models.Status": {
+            "type": "string",
+            "enum": [
+                "activated",
+                "deactivated"
+            ],
+            "x-enum-varnames": [
+                "Activated",
+                "Deactivated"
+            ]
+        },
+        "models.StatusPayload": {
+            "type": "object",
+            "properties": {
+                "note": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.Status"
+                }
+            }
+        },
+        "models.Swipe": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "interested": {
+                    "type": "boolean"
+                },
+                "kind": {
+                    "$ref": "#/definitions/models.SwipeKind"
+                },
+                "prospect_id": {
+                    "type": "string"
+                },
+                "swipe_time": {
+                    "type": "string"
+                },
+                "user_id": {
                     "type": "string"
                 }
             }
         },
+        "models.SwipeKind": {
+            "type": "string",
+            "enum": [
+                "pass",
+                "like",
+                "super_like"
+            ],
+            "x-enum-varnames": [
+                "PassSwipe",
+                "LikeSwipe",
+                "SuperLikeSwipe"
+            ]
+        },
         "models.SwipePayload": {
             "type": "object",
             "properties": {
                 "interested": {
                     "type": "boolean"
                 },
+                "kind": {
+                    "$ref": "#/definitions/models.SwipeKind"
+                },
                 "user_id": {
                     "type": "string"
                 }
             }
         },
+        "models.SwipeQuota": {
+            "type": "object",
+            "properties": {
+                "like_limit": {
+                    "type": "integer"
+                },
+                "likes_remaining": {
+                    "type": "integer"
+                },
+                "pass_limit": {
+                    "type": "integer"
+                },
+                "passes_remaining": {
+                    "type": "integer"
+                },
+                "resets_at": {
+                    "type": "string"
+                },
+                "rewind_limit": {
+                    "type": "integer"
+                },
+                "rewinds_remaining": {
+                    "type": "integer"
+                },
+                "super_like_limit": {
+                    "type": "integer"
+                },
+                "super_likes_remaining": {
+                    "type": "integer"
+                }
+            }
+        },
         "models.SwipeResponse": {
             "type": "object",
             "properties": {
@@ -306,6 +3104,73 @@ const docTemplate = `{
                 },
                 "matched": {
                     "type": "boolean"
+                },
+                "prospect_super_liked": {
+                    "description": "ProspectSuperLiked tells that the prospect's like, which made the match, was a super-like.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.TypingPayload": {
+            "type": "object",
+            "properties": {
+                "typing": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.UpdatePasswordPayload": {
+            "type": "object",
+            "properties": {
+                "new_password": {
+                    "type": "string"
+                },
+                "old_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UpdateUserPayload": {
+            "type": "object",
+            "properties": {
+                "bio": {
+                    "type": "string"
+                },
+                "dating_intentions": {
+                    "$ref": "#/definitions/models.Intentions"
+                },
+                "drinking": {
+                    "$ref": "#/definitions/models.DrinkingHabit"
+                },
+                "drugs": {
+                    "$ref": "#/definitions/models.DrugHabit"
+                },
+                "kids": {
+                    "type": "string"
+                },
+                "location": {
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "occupation": {
+                    "type": "string"
+                },
+                "pets": {
+                    "type": "string"
+                },
+                "religion": {
+                    "$ref": "#/definitions/models.Religion"
+                },
+                "sexuality": {
+                    "$ref": "#/definitions/models.Sexuality"
+                },
+                "smoking": {
+                    "$ref": "#/definitions/models.SmokingHabit"
+                },
+                "timezone": {
+                    "type": "string"
                 }
             }
         },
@@ -321,6 +3186,9 @@ const docTemplate = `{
                 "bio": {
                     "type": "string"
                 },
+                "daily_swipe_budget": {
+                    "type": "integer"
+                },
                 "date_of_birth": {
                     "type": "string"
                 },
@@ -331,10 +3199,10 @@ const docTemplate = `{
                     "type": "number"
                 },
                 "drinking": {
-                    "type": "string"
+                    "$ref": "#/definitions/models.DrinkingHabit"
                 },
                 "drugs": {
-                    "type": "string"
+                    "$ref": "#/definitions/models.DrugHabit"
                 },
                 "email": {
                     "type": "string"
@@ -369,17 +3237,57 @@ const docTemplate = `{
                 "pets": {
                     "type": "string"
                 },
+                "ranking": {
+                    "description": "Ranking explains the discovery ranking of the profile; it is only returned with explain=true.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RankingExplanation"
+                        }
+                    ]
+                },
                 "religion": {
-                    "type": "string"
+                    "$ref": "#/definitions/models.Religion"
+                },
+                "role": {
+                    "description": "Role is empty for plain users.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Role"
+                        }
+                    ]
                 },
                 "sexuality": {
-                    "type": "string"
+                    "$ref": "#/definitions/models.Sexuality"
                 },
                 "smoking": {
+                    "$ref": "#/definitions/models.SmokingHabit"
+                },
+                "status": {
+                    "description": "Status is empty for accounts that were never deactivated, which are active.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Status"
+                        }
+                    ]
+                },
+                "super_liked_you": {
+                    "description": "SuperLikedYou is set on discovered profiles that super-liked the viewer.",
+                    "type": "boolean"
+                },
+                "suspended_until": {
                     "type": "string"
                 },
                 "swipe_count": {
                     "type": "integer"
+                },
+                "swiping_rate": {
+                    "type": "number"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "warned_at": {
+                    "type": "string"
                 }
             }
         }
@@ -16,6 +16,9 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+
+	// Embeds the time zone database for users' local swipe quotas; the runtime image has none.
+	_ "time/tzdata"
 )
 
 func main() {
@@ -47,6 +50,12 @@ func main() {
 		Addr:    address,
 		Handler: router,
 	}
+	// Event streams never end on their own, so they are closed for the shutdown to complete.
+	server.RegisterOnShutdown(opts.Gateway.Close)
+
+	sweeperCtx, stopSweeper := context.WithCancel(context.Background())
+	defer stopSweeper()
+	go opts.MatchService.RunExpirySweeper(sweeperCtx)
 
 	// Handle graceful shutdown on receiving signals.
 	stop := make(chan os.Signal, 1)
@@ -62,6 +71,7 @@ func main() {
 	// Wait for a signal to gracefully shut down the server.
 	<-stop
 	log.Println("Shutting down server...")
+	stopSweeper()
 
 	// Create a context with a timeout to force shutdown after a certain duration.
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
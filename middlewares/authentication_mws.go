@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"slices"
 	"strings"
 )
 
@@ -14,6 +15,7 @@ var (
 	ErrUnauthorized = errors.New("unauthorized: Sorry, you must be authenticated/logged in to continue")
 	ErrTokenMissing = errors.New("token missing in Authorization header")
 	ErrInvalidToken = errors.New("invalid token")
+	ErrForbidden    = errors.New("forbidden: Sorry, you are not allowed to do this")
 )
 
 type ErrorResponse struct {
@@ -58,10 +60,36 @@ func (mw *SystemMiddleware) AuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// RequireRole returns a middleware that only lets through users with one of the given roles.
+// It is meant for chi route groups and must run after AuthMiddleware. The role of the token was
+// checked against the stored role by ValidateHeaders, so the authenticated account's role is used.
+func (mw *SystemMiddleware) RequireRole(roles ...models.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			account, ok := r.Context().Value(constants.AuthenticatedAccountContextKey).(*models.User)
+			if !ok {
+				respondWithError(w, ErrUnauthorized)
+				return
+			}
+			if !slices.Contains(roles, account.Role.OrDefault()) {
+				mw.logger.WithField("user", account.ID).Warnf("RequireRole: forbidden request to %s", r.URL.Path)
+				respondWithStatus(w, http.StatusForbidden, ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // respondWithError responds with an error message.
 func respondWithError(w http.ResponseWriter, err error) {
+	respondWithStatus(w, http.StatusUnauthorized, err)
+}
+
+// respondWithStatus responds with an error message and the given status code.
+func respondWithStatus(w http.ResponseWriter, status int, err error) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusUnauthorized)
+	w.WriteHeader(status)
 	result := ErrorResponse{
 		Message: err.Error(),
 	}
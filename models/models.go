@@ -1,10 +1,17 @@
 package models
 
 import (
+	"api/constants"
 	"errors"
+	"fmt"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 )
 
 type Gender string
@@ -25,17 +32,93 @@ func (g Gender) IsValid() bool {
 }
 
 var (
-	validIntentions     = []interface{}{"life partner", "shorter time", "none", "figuring out", "other"}
-	validGenders        = []interface{}{"male", "female", "non-binary"}
-	validEthnicities    = []interface{}{"white", "black", "asian", "latino", "other"}
-	validPets           = []interface{}{"dog", "cat", "bird", "reptile", "prefer not to say", "none"}
-	validSexualities    = []interface{}{"straight", "gay", "bisexual", "pansexual", "asexual", "other"}
-	validDrinkingHabits = []interface{}{"yes", "no", "none"}
-	validSmokingHabits  = []interface{}{"yes", "no", "none"}
-	validDrugHabits     = []interface{}{"yes", "no", "none"}
-	validReligions      = []interface{}{"christian", "muslim", "hindu", "buddhist", "other"}
+	validIntentions        = []interface{}{"life partner", "shorter time", "none", "figuring out", "other"}
+	validGenders           = []interface{}{"male", "female", "non-binary"}
+	validEthnicities       = []interface{}{"white", "black", "asian", "latino", "other"}
+	validPets              = []interface{}{"dog", "cat", "bird", "reptile", "prefer not to say", "none"}
+	validSexualities       = []interface{}{"straight", "gay", "bisexual", "pansexual", "asexual", "other"}
+	validDrinkingHabits    = []interface{}{"yes", "no", "none"}
+	validSmokingHabits     = []interface{}{"yes", "no", "none"}
+	validDrugHabits        = []interface{}{"yes", "no", "none"}
+	validReligions         = []interface{}{"christian", "muslim", "hindu", "buddhist", "other"}
+	validSwipeKinds        = []interface{}{"pass", "like", "super_like"}
+	validReportReasons     = []interface{}{"spam", "harassment", "inappropriate_content", "fake_profile", "underage", "scam", "other"}
+	validReportStates      = []interface{}{"open", "reviewing", "actioned", "dismissed"}
+	validModerationActions = []interface{}{"warn", "suspend", "deactivate"}
+	validRoles             = []interface{}{"user", "moderator", "admin"}
+	validStatuses          = []interface{}{"activated", "deactivated"}
 )
 
+var ErrUnderMinimumAge = errors.New("you must be at least 18 years old to register")
+
+// inStrings works like validation.In but compares the underlying string of named
+// string types (Gender, Religion, ...) against the plain string lists above.
+func inStrings(values ...interface{}) validation.Rule {
+	return validation.By(func(value interface{}) error {
+		v := reflect.Indirect(reflect.ValueOf(value))
+		if v.Kind() != reflect.String || v.String() == "" {
+			return nil
+		}
+		return validation.In(values...).Validate(v.String())
+	})
+}
+
+// geoJSONPoint checks that a location is a GeoJSON [longitude, latitude] pair.
+func geoJSONPoint(value interface{}) error {
+	location, _ := value.([]float64)
+	if location == nil {
+		return nil
+	}
+	if len(location) != 2 {
+		return errors.New("must be a [longitude, latitude] pair")
+	}
+	if location[0] < -180 || location[0] > 180 {
+		return errors.New("longitude must be between -180 and 180")
+	}
+	if location[1] < -90 || location[1] > 90 {
+		return errors.New("latitude must be between -90 and 90")
+	}
+	return nil
+}
+
+// kidsCount checks that kids is a non-negative whole number.
+func kidsCount(value interface{}) error {
+	kids, _ := value.(*string)
+	if kids == nil {
+		return nil
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(*kids))
+	if err != nil || count < 0 {
+		return errors.New("must be a non-negative whole number")
+	}
+	return nil
+}
+
+// timeZone checks that a time zone is an IANA name such as "Europe/London".
+func timeZone(value interface{}) error {
+	zone, _ := value.(*string)
+	if zone == nil {
+		return nil
+	}
+	if _, err := time.LoadLocation(*zone); err != nil || *zone == "" || *zone == "Local" {
+		return errors.New("must be an IANA time zone such as Europe/London")
+	}
+	return nil
+}
+
+// minimumAge checks that a "2006-01-02" date of birth is at least constants.MinimumAge years ago.
+func minimumAge(value interface{}) error {
+	dateOfBirth, _ := value.(string)
+	dob, err := time.Parse(constants.DateOfBirthLayout, dateOfBirth)
+	if err != nil {
+		return nil
+	}
+	if dob.AddDate(constants.MinimumAge, 0, 0).After(time.Now()) {
+		return ErrUnderMinimumAge
+	}
+	return nil
+}
+
 func (g Gender) String() string {
 	return string(g)
 }
@@ -60,33 +143,105 @@ func (s Status) String() string {
 	return string(s)
 }
 
+// Role is what a user is allowed to do. Moderators work the report queue; admins also manage users.
+type Role string
+
+const (
+	UserRole      Role = "user"
+	ModeratorRole Role = "moderator"
+	AdminRole     Role = "admin"
+)
+
+// OrDefault returns the role, UserRole when none is set.
+func (r Role) OrDefault() Role {
+	if r == "" {
+		return UserRole
+	}
+	return r
+}
+
+// DeactivationReason tells who deactivated an account.
+type DeactivationReason string
+
+const (
+	// ModerationDeactivation is a deactivation by a moderator, which only a moderator can undo.
+	ModerationDeactivation DeactivationReason = "moderation"
+	// SelfDeactivation is a deactivation by the user, who can reactivate their account at any time.
+	SelfDeactivation DeactivationReason = "self"
+)
+
 type User struct {
-	ID               string        `bson:"id,omitempty" json:"id,omitempty"`
-	Name             string        `bson:"name" json:"name,omitempty"`
-	Email            string        `bson:"email" json:"email,omitempty"`
-	Password         string        `bson:"password" json:"-"`
-	Age              int           `json:"age,omitempty"`
-	DateOfBirth      string        `bson:"date_of_birth" json:"date_of_birth,omitempty"`
-	Location         []float64     `bson:"location" json:"location,omitempty"`
-	Height           float64       `bson:"height" json:"height,omitempty"`
-	Ethnicity        string        `bson:"ethnicity" json:"ethnicity,omitempty"`
-	Gender           Gender        `bson:"gender" json:"gender,omitempty"`
-	Distance         float64       `json:"distance,omitempty"`
-	Pets             string        `bson:"pets" json:"pets,omitempty"`
-	Religion         Religion      `bson:"religion" json:"religion,omitempty"`
-	Drinking         DrinkingHabit `bson:"drinking" json:"drinking,omitempty"`
-	Smoking          SmokingHabit  `bson:"smoking" json:"smoking,omitempty"`
-	Drugs            DrugHabit     `bson:"drugs" json:"drugs,omitempty"`
-	DatingIntentions string        `bson:"dating_intentions" json:"dating_intentions,omitempty"`
-	Kids             int           `bson:"kids" json:"kids,omitempty"`
-	Occupation       string        `bson:"occupation" json:"occupation,omitempty"`
-	SwipeCount       int           `bson:"swipe_count" json:"swipe_count,omitempty"`
-	Attractiveness   int           `bson:"attractiveness" json:"attractiveness,omitempty"`
-	Bio              string        `bson:"bio" json:"bio,omitempty"`
-	SwipingRate      float64       `json:"swiping_rate,omitempty"`
-	DailySwipeBudget int           `json:"daily_swipe_budget,omitempty"`
+	ID                string        `bson:"id,omitempty" json:"id,omitempty"`
+	Name              string        `bson:"name" json:"name,omitempty"`
+	Email             string        `bson:"email" json:"email,omitempty"`
+	Password          string        `bson:"password" json:"-"`
+	Age               int           `json:"age,omitempty"`
+	DateOfBirth       string        `bson:"date_of_birth" json:"date_of_birth,omitempty"`
+	Location          []float64     `bson:"location" json:"location,omitempty"`
+	Height            float64       `bson:"height" json:"height,omitempty"`
+	Ethnicity         string        `bson:"ethnicity" json:"ethnicity,omitempty"`
+	Gender            Gender        `bson:"gender" json:"gender,omitempty"`
+	Sexuality         Sexuality     `bson:"sexuality" json:"sexuality,omitempty"`
+	Distance          float64       `json:"distance,omitempty"`
+	Pets              string        `bson:"pets" json:"pets,omitempty"`
+	Religion          Religion      `bson:"religion" json:"religion,omitempty"`
+	Drinking          DrinkingHabit `bson:"drinking" json:"drinking,omitempty"`
+	Smoking           SmokingHabit  `bson:"smoking" json:"smoking,omitempty"`
+	Drugs             DrugHabit     `bson:"drugs" json:"drugs,omitempty"`
+	DatingIntentions  string        `bson:"dating_intentions" json:"dating_intentions,omitempty"`
+	Kids              int           `bson:"kids" json:"kids,omitempty"`
+	Occupation        string        `bson:"occupation" json:"occupation,omitempty"`
+	SwipeCount        int           `bson:"swipe_count" json:"swipe_count,omitempty"`
+	Attractiveness    int           `bson:"attractiveness" json:"attractiveness,omitempty"`
+	Bio               string        `bson:"bio" json:"bio,omitempty"`
+	SwipingRate       float64       `json:"swiping_rate,omitempty"`
+	DailySwipeBudget  int           `json:"daily_swipe_budget,omitempty"`
+	TokenVersion      int           `bson:"token_version" json:"-"`
+	PasswordChangedAt time.Time     `bson:"password_changed_at,omitempty" json:"-"`
+	Preferences       *Preferences  `bson:"preferences,omitempty" json:"-"`
+	CreatedAt         time.Time     `bson:"created_at,omitempty" json:"-"`
+	LastActiveAt      time.Time     `bson:"last_active_at,omitempty" json:"-"`
+	Timezone          string        `bson:"timezone,omitempty" json:"timezone,omitempty"`
+	// SuperLikedYou is set on discovered profiles that super-liked the viewer.
+	SuperLikedYou bool        `bson:"super_liked_you,omitempty" json:"super_liked_you,omitempty"`
+	SwipeUsage    *SwipeUsage `bson:"swipe_usage,omitempty" json:"-"`
+	// Role is empty for plain users.
+	Role Role `bson:"role,omitempty" json:"role,omitempty"`
+	// Status is empty for accounts that were never deactivated, which are active.
+	Status             Status             `bson:"status,omitempty" json:"status,omitempty"`
+	DeactivationReason DeactivationReason `bson:"deactivation_reason,omitempty" json:"-"`
+	SuspendedUntil     time.Time          `bson:"suspended_until,omitempty" json:"suspended_until,omitempty"`
+	WarnedAt           time.Time          `bson:"warned_at,omitempty" json:"warned_at,omitempty"`
+
+	// Ranking explains the discovery ranking of the profile; it is only returned with explain=true.
+	Ranking *RankingExplanation `bson:"-" json:"ranking,omitempty"`
+}
+
+// IsSuspended reports whether a moderator suspended the user until after the given time.
+func (a User) IsSuspended(now time.Time) bool {
+	return a.SuspendedUntil.After(now)
+}
+
+// IsDeactivated reports whether the account is deactivated, by the user or by a moderator.
+func (a User) IsDeactivated() bool {
+	return a.Status == Deactivated
+}
+
+// IsBanned reports whether a moderator deactivated the user.
+func (a User) IsBanned() bool {
+	return a.Status == Deactivated && a.DeactivationReason == ModerationDeactivation
+}
+
+// RankingExplanation breaks a discovery ranking score down into the score of each named
+// signal (proximity, attractiveness, ...), each normalised to 0-2.5.
+type RankingExplanation struct {
+	Score      float64            `json:"score"`
+	Components map[string]float64 `json:"components"`
 }
 
+// Weights maps named signals to their weights.
+type Weights map[string]float64
+
 func (a User) Validate() error {
 	return validation.ValidateStruct(&a,
 		validation.Field(&a.Name, validation.Required),
@@ -105,21 +260,20 @@ type RegistrationPayload struct {
 
 func (r RegistrationPayload) Validate() error {
 	return validation.ValidateStruct(&r,
-		validation.Field(&r.Name, validation.Required),
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 255)),
 		validation.Field(&r.Email, validation.Required, is.Email),
-		validation.Field(&r.Password, validation.Required),
-		validation.Field(&r.Gender, validation.Required),
-		validation.Field(&r.DateOfBirth, validation.Required),
+		validation.Field(&r.Password, validation.Required, validation.Length(8, 72)),
+		validation.Field(&r.Gender, validation.Required, inStrings(validGenders...)),
+		validation.Field(&r.DateOfBirth, validation.Required, validation.Date(constants.DateOfBirthLayout), validation.By(minimumAge)),
 	)
 }
 
 type RegistrationResponse struct {
-	ID       string `json:"id,omitempty"`
-	Name     string `json:"name,omitempty"`
-	Email    string `json:"email,omitempty"`
-	Password string `json:"password,omitempty"`
-	Age      int    `json:"age,omitempty"`
-	Gender   Gender `json:"gender,omitempty"`
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Email  string `json:"email,omitempty"`
+	Age    int    `json:"age,omitempty"`
+	Gender Gender `json:"gender,omitempty"`
 }
 
 type LoginPayload struct {
@@ -136,28 +290,101 @@ func (l LoginPayload) Validate() error {
 
 type LoginResponse struct {
 	Token string `json:"token,omitempty"`
+	// Deactivated is set when the user deactivated their account, which they can reactivate
+	// with the token.
+	Deactivated bool `json:"deactivated,omitempty"`
 }
 
+// UpdateUserPayload is a partial profile update: fields left out of the request body stay nil
+// and are not touched.
 type UpdateUserPayload struct {
-	Location         []float64     `json:"location"`
-	Pets             string        `json:"pets"`
-	Sexuality        Sexuality     `json:"sexuality"`
-	Religion         Religion      `json:"religion"`
-	Drinking         DrinkingHabit `json:"drinking"`
-	Smoking          SmokingHabit  `json:"smoking"`
-	Drugs            DrugHabit     `json:"drugs"`
-	DatingIntentions Intentions    `json:"dating_intentions"`
-	Kids             string        `json:"kids"`
-	Occupation       string        `json:"occupation"`
-	Bio              string        `json:"bio"`
+	Location         []float64      `json:"location,omitempty"`
+	Pets             *string        `json:"pets,omitempty"`
+	Sexuality        *Sexuality     `json:"sexuality,omitempty"`
+	Religion         *Religion      `json:"religion,omitempty"`
+	Drinking         *DrinkingHabit `json:"drinking,omitempty"`
+	Smoking          *SmokingHabit  `json:"smoking,omitempty"`
+	Drugs            *DrugHabit     `json:"drugs,omitempty"`
+	DatingIntentions *Intentions    `json:"dating_intentions,omitempty"`
+	Kids             *string        `json:"kids,omitempty"`
+	Occupation       *string        `json:"occupation,omitempty"`
+	Bio              *string        `json:"bio,omitempty"`
+	Timezone         *string        `json:"timezone,omitempty"`
 }
 
 func (u UpdateUserPayload) Validate() error {
-	return validation.ValidateStruct(
-		&u,
+	return validation.ValidateStruct(&u,
+		validation.Field(&u.Location, validation.By(geoJSONPoint)),
+		validation.Field(&u.Pets, inStrings(validPets...)),
+		validation.Field(&u.Sexuality, inStrings(validSexualities...)),
+		validation.Field(&u.Religion, inStrings(validReligions...)),
+		validation.Field(&u.Drinking, inStrings(validDrinkingHabits...)),
+		validation.Field(&u.Smoking, inStrings(validSmokingHabits...)),
+		validation.Field(&u.Drugs, inStrings(validDrugHabits...)),
+		validation.Field(&u.DatingIntentions, inStrings(validIntentions...)),
+		validation.Field(&u.Kids, validation.By(kidsCount)),
+		validation.Field(&u.Occupation, validation.Length(0, 255)),
+		validation.Field(&u.Bio, validation.Length(0, 500)),
+		validation.Field(&u.Timezone, validation.By(timeZone)),
 	)
 }
 
+// IsEmpty reports whether the payload carries no field to update.
+func (u UpdateUserPayload) IsEmpty() bool {
+	return u.Location == nil && u.Pets == nil && u.Sexuality == nil && u.Religion == nil &&
+		u.Drinking == nil && u.Smoking == nil && u.Drugs == nil && u.DatingIntentions == nil &&
+		u.Kids == nil && u.Occupation == nil && u.Bio == nil && u.Timezone == nil
+}
+
+// ToUserUpdate converts the payload to a UserUpdate. It assumes Validate has passed.
+func (u UpdateUserPayload) ToUserUpdate() UserUpdate {
+	update := UserUpdate{
+		Location:   u.Location,
+		Pets:       u.Pets,
+		Sexuality:  u.Sexuality,
+		Religion:   u.Religion,
+		Drinking:   u.Drinking,
+		Smoking:    u.Smoking,
+		Drugs:      u.Drugs,
+		Occupation: u.Occupation,
+		Bio:        u.Bio,
+		Timezone:   u.Timezone,
+	}
+	if u.DatingIntentions != nil {
+		intentions := string(*u.DatingIntentions)
+		update.DatingIntentions = &intentions
+	}
+	if u.Kids != nil {
+		kids, _ := strconv.Atoi(strings.TrimSpace(*u.Kids))
+		update.Kids = &kids
+	}
+	return update
+}
+
+// UserUpdate holds the user fields to change in a partial update. Nil fields are left untouched.
+type UserUpdate struct {
+	Location         []float64      `bson:"location,omitempty"`
+	Pets             *string        `bson:"pets,omitempty"`
+	Sexuality        *Sexuality     `bson:"sexuality,omitempty"`
+	Religion         *Religion      `bson:"religion,omitempty"`
+	Drinking         *DrinkingHabit `bson:"drinking,omitempty"`
+	Smoking          *SmokingHabit  `bson:"smoking,omitempty"`
+	Drugs            *DrugHabit     `bson:"drugs,omitempty"`
+	DatingIntentions *string        `bson:"dating_intentions,omitempty"`
+	Kids             *int           `bson:"kids,omitempty"`
+	Occupation       *string        `bson:"occupation,omitempty"`
+	Bio              *string        `bson:"bio,omitempty"`
+	Timezone         *string        `bson:"timezone,omitempty"`
+	Preferences      *Preferences   `bson:"preferences,omitempty"`
+	LastActiveAt     *time.Time     `bson:"last_active_at,omitempty"`
+
+	// Moderation fields, only ever set by moderators.
+	Status             *Status             `bson:"status,omitempty"`
+	DeactivationReason *DeactivationReason `bson:"deactivation_reason,omitempty"`
+	SuspendedUntil     *time.Time          `bson:"suspended_until,omitempty"`
+	WarnedAt           *time.Time          `bson:"warned_at,omitempty"`
+}
+
 type UpdatePasswordPayload struct {
 	OldPassword string `json:"old_password,omitempty"`
 	NewPassword string `json:"new_password,omitempty"`
@@ -166,38 +393,136 @@ type UpdatePasswordPayload struct {
 func (u UpdatePasswordPayload) Validate() error {
 	return validation.ValidateStruct(&u,
 		validation.Field(&u.OldPassword, validation.Required),
-		validation.Field(&u.NewPassword, validation.Required),
+		validation.Field(&u.NewPassword, validation.Required, validation.Length(1, 72),
+			validation.NotIn(u.OldPassword).Error("must be different from the old password")),
 	)
 }
 
+// SwipeUsage counts the swipes of a user on one day of their local calendar: Day, until the local
+// midnight in ResetsAt. The usage only starts afresh once ResetsAt has passed, so changing time
+// zone never refills the quota early.
+type SwipeUsage struct {
+	Day        string    `bson:"day"`
+	ResetsAt   time.Time `bson:"resets_at"`
+	Likes      int       `bson:"likes"`
+	Passes     int       `bson:"passes"`
+	Rewinds    int       `bson:"rewinds"`
+	SuperLikes int       `bson:"super_likes"`
+}
+
+// SwipeCounter names one of the daily counters of SwipeUsage.
+type SwipeCounter string
+
+const (
+	LikesCounter      SwipeCounter = "likes"
+	PassesCounter     SwipeCounter = "passes"
+	RewindsCounter    SwipeCounter = "rewinds"
+	SuperLikesCounter SwipeCounter = "super_likes"
+)
+
+// SwipeLimits are the default daily swipe quotas. A user's DailySwipeBudget, when set,
+// replaces the daily like limit. A swipe can be undone for RewindWindow after it was made.
+type SwipeLimits struct {
+	DailyLikes      int
+	DailyPasses     int
+	DailySuperLikes int
+	DailyRewinds    int
+	RewindWindow    time.Duration
+}
+
+// SwipeQuota reports what is left of a user's daily swipe quotas.
+type SwipeQuota struct {
+	LikeLimit           int       `json:"like_limit"`
+	LikesRemaining      int       `json:"likes_remaining"`
+	PassLimit           int       `json:"pass_limit"`
+	PassesRemaining     int       `json:"passes_remaining"`
+	RewindLimit         int       `json:"rewind_limit"`
+	RewindsRemaining    int       `json:"rewinds_remaining"`
+	SuperLikeLimit      int       `json:"super_like_limit"`
+	SuperLikesRemaining int       `json:"super_likes_remaining"`
+	ResetsAt            time.Time `json:"resets_at"`
+}
+
+// PasswordPolicy describes the strength requirements for new passwords.
+type PasswordPolicy struct {
+	MinLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+}
+
+// Check returns an error describing the first requirement the password does not meet.
+func (p PasswordPolicy) Check(password string) error {
+	if len(password) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters long", p.MinLength)
+	}
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	switch {
+	case p.RequireUppercase && !hasUpper:
+		return errors.New("password must contain an uppercase letter")
+	case p.RequireLowercase && !hasLower:
+		return errors.New("password must contain a lowercase letter")
+	case p.RequireDigit && !hasDigit:
+		return errors.New("password must contain a digit")
+	case p.RequireSymbol && !hasSymbol:
+		return errors.New("password must contain a symbol")
+	}
+	return nil
+}
+
 type UserFilter struct {
-	MinAge            int    `json:"min_age"`
-	MaxAge            int    `json:"max_age"`
-	MinHeight         int    `json:"min_height"`
-	MaxHeight         int    `json:"max_height"`
-	MaxDistance       int    `json:"max_distance,omitempty"`
-	DesiredEthnicity  string `json:"desired_ethnicity,omitempty"`
-	DesiredPets       string `json:"desired_pets,omitempty"`
-	DesiredSexuality  string `json:"desired_sexuality,omitempty"`
-	DesiredDrinking   string `json:"desired_drinking,omitempty"`
-	DesiredSmoking    string `json:"desired_smoking,omitempty"`
-	DesiredDrugs      string `json:"desired_drugs,omitempty"`
-	DesiredIntentions string `json:"desired_intentions,omitempty"`
-	DesiredReligion   string `json:"desired_religion,omitempty"`
+	MinAge            int      `json:"min_age"`
+	MaxAge            int      `json:"max_age"`
+	MinHeight         int      `json:"min_height"`
+	MaxHeight         int      `json:"max_height"`
+	MaxDistance       int      `json:"max_distance,omitempty"`
+	DesiredEthnicity  []string `json:"desired_ethnicity,omitempty"`
+	DesiredPets       []string `json:"desired_pets,omitempty"`
+	DesiredSexuality  []string `json:"desired_sexuality,omitempty"`
+	DesiredDrinking   []string `json:"desired_drinking,omitempty"`
+	DesiredSmoking    []string `json:"desired_smoking,omitempty"`
+	DesiredDrugs      []string `json:"desired_drugs,omitempty"`
+	DesiredIntentions []string `json:"desired_intentions,omitempty"`
+	DesiredReligion   []string `json:"desired_religion,omitempty"`
+
+	// Preferences are the viewer's saved preferences, used when the request carries no
+	// explicit filter. Only their deal-breakers are enforced as filters.
+	Preferences *Preferences `json:"-"`
+}
+
+// IsEmpty reports whether no query filter was given.
+func (uf UserFilter) IsEmpty() bool {
+	return uf.MinAge == 0 && uf.MaxAge == 0 && uf.MinHeight == 0 && uf.MaxHeight == 0 && uf.MaxDistance == 0 &&
+		len(uf.DesiredEthnicity) == 0 && len(uf.DesiredPets) == 0 && len(uf.DesiredSexuality) == 0 &&
+		len(uf.DesiredDrinking) == 0 && len(uf.DesiredSmoking) == 0 && len(uf.DesiredDrugs) == 0 &&
+		len(uf.DesiredIntentions) == 0 && len(uf.DesiredReligion) == 0
 }
 
 func (uf UserFilter) Validate() error {
 	return validation.ValidateStruct(&uf,
 		validation.Field(&uf.MinHeight, validation.Min(0)),
 		validation.Field(&uf.MaxHeight, validation.Min(0), validation.By(func(value interface{}) error {
-			if uf.MinHeight > value.(int) {
+			if value.(int) > 0 && uf.MinHeight > value.(int) {
 				return errors.New("MaxHeight must be greater than or equal to MinHeight")
 			}
 			return nil
 		})),
 		validation.Field(&uf.MinAge, validation.Min(0)),
 		validation.Field(&uf.MaxAge, validation.Min(0), validation.By(func(value interface{}) error {
-			if uf.MinAge > value.(int) {
+			if value.(int) > 0 && uf.MinAge > value.(int) {
 				return errors.New("MaxAge must be greater than or equal to MinAge")
 			}
 			return nil
@@ -205,46 +530,437 @@ func (uf UserFilter) Validate() error {
 		//validation.Field(&uf.Latitude, validation.Min(-90), validation.Max(90)),
 		//validation.Field(&uf.Longitude, validation.Min(-180), validation.Max(180)),
 		validation.Field(&uf.MaxDistance, validation.Min(0)),
-		validation.Field(&uf.DesiredEthnicity, validation.Length(0, 255), validation.In(validEthnicities...)),
-		validation.Field(&uf.DesiredPets, validation.Length(0, 255), validation.In(validPets...)),
-		validation.Field(&uf.DesiredSexuality, validation.Length(0, 255), validation.In(validSexualities...)),
-		validation.Field(&uf.DesiredDrinking, validation.Length(0, 255), validation.In(validDrinkingHabits...)),
-		validation.Field(&uf.DesiredSmoking, validation.Length(0, 255), validation.In(validSmokingHabits...)),
-		validation.Field(&uf.DesiredDrugs, validation.Length(0, 255), validation.In(validDrugHabits...)),
-		validation.Field(&uf.DesiredIntentions, validation.Length(0, 255), validation.In(validIntentions...)),
-		validation.Field(&uf.DesiredReligion, validation.Length(0, 255), validation.In(validReligions...)),
+		validation.Field(&uf.DesiredEthnicity, validation.Each(validation.In(validEthnicities...))),
+		validation.Field(&uf.DesiredPets, validation.Each(validation.In(validPets...))),
+		validation.Field(&uf.DesiredSexuality, validation.Each(validation.In(validSexualities...))),
+		validation.Field(&uf.DesiredDrinking, validation.Each(validation.In(validDrinkingHabits...))),
+		validation.Field(&uf.DesiredSmoking, validation.Each(validation.In(validSmokingHabits...))),
+		validation.Field(&uf.DesiredDrugs, validation.Each(validation.In(validDrugHabits...))),
+		validation.Field(&uf.DesiredIntentions, validation.Each(validation.In(validIntentions...))),
+		validation.Field(&uf.DesiredReligion, validation.Each(validation.In(validReligions...))),
 	)
 }
 
+// Page requests one page of a cursor-paginated list. Cursor is the opaque next_cursor
+// returned with the previous page; an empty cursor requests the first page.
+type Page struct {
+	Limit  int    `json:"limit,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+func (p Page) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Limit, validation.Min(0), validation.Max(constants.MaxPageLimit)),
+		validation.Field(&p.Cursor, validation.Length(0, 1024)),
+	)
+}
+
+// LimitOrDefault returns the requested limit, or constants.DefaultPageLimit when none was given.
+func (p Page) LimitOrDefault() int {
+	if p.Limit <= 0 {
+		return constants.DefaultPageLimit
+	}
+	return p.Limit
+}
+
+// DiscoverCursor is the position of the last profile of a discover page. Profiles that super-liked
+// the viewer come first, then everyone else, each by ranking score, best first, and by ID among
+// equal scores, so recording new swipes between pages never shifts later pages. RankedAt is the
+// time the first page was ranked at; later pages are ranked at it too, so scores that decay with
+// time keep the order the session started with.
+type DiscoverCursor struct {
+	SuperLiked bool      `json:"s,omitempty"`
+	Score      float64   `json:"score"`
+	ID         string    `json:"id"`
+	RankedAt   time.Time `json:"at"`
+}
+
+// Before reports whether the position comes before the other in discover order.
+func (c DiscoverCursor) Before(other DiscoverCursor) bool {
+	if c.SuperLiked != other.SuperLiked {
+		return c.SuperLiked
+	}
+	if c.Score != other.Score {
+		return c.Score > other.Score
+	}
+	return c.ID < other.ID
+}
+
 type Swipe struct {
 	ID         string    `bson:"id,omitempty" json:"id,omitempty"`
 	UserID     string    `bson:"user_id,omitempty" json:"user_id,omitempty"`
 	ProspectID string    `bson:"prospect_id,omitempty" json:"prospect_id,omitempty"`
 	Interested bool      `bson:"interested" json:"interested"`
+	Kind       SwipeKind `bson:"kind,omitempty" json:"kind,omitempty"`
 	SwipeTime  time.Time `bson:"swipe_time" json:"swipe_time"`
+	// QuotaDay is the day of the swiper's usage the swipe was counted in.
+	QuotaDay string `bson:"quota_day,omitempty" json:"-"`
+}
+
+// SwipeKind returns the kind of the swipe. Swipes stored before swipe kinds existed are
+// plain likes or passes.
+func (s Swipe) SwipeKind() SwipeKind {
+	switch {
+	case s.Kind != "":
+		return s.Kind
+	case s.Interested:
+		return LikeSwipe
+	default:
+		return PassSwipe
+	}
+}
+
+// IsSuperLike reports whether the swipe is a super-like.
+func (s Swipe) IsSuperLike() bool {
+	return s.Kind == SuperLikeSwipe
+}
+
+type SwipeKind string
+
+const (
+	PassSwipe      SwipeKind = "pass"
+	LikeSwipe      SwipeKind = "like"
+	SuperLikeSwipe SwipeKind = "super_like"
+)
+
+// Interested reports whether the swipe kind is a like, super or not.
+func (k SwipeKind) Interested() bool {
+	return k == LikeSwipe || k == SuperLikeSwipe
 }
 
+// SwipePayload swipes on a prospect. Kind is one of pass, like or super_like; without it,
+// Interested picks between like and pass.
 type SwipePayload struct {
-	ProspectID string `json:"user_id,omitempty"`
-	Interested bool   `json:"interested"`
+	ProspectID string    `json:"user_id,omitempty"`
+	Kind       SwipeKind `json:"kind,omitempty"`
+	Interested bool      `json:"interested"`
 }
 
 func (sp SwipePayload) Validate() error {
 	return validation.ValidateStruct(&sp,
 		validation.Field(&sp.ProspectID, validation.Required, validation.Length(1, 255)),
-		validation.Field(&sp.Interested, validation.Required),
+		validation.Field(&sp.Kind, inStrings(validSwipeKinds...)),
 	)
 }
 
+// SwipeKind returns the kind of the swipe.
+func (sp SwipePayload) SwipeKind() SwipeKind {
+	switch {
+	case sp.Kind != "":
+		return sp.Kind
+	case sp.Interested:
+		return LikeSwipe
+	default:
+		return PassSwipe
+	}
+}
+
 type SwipeResponse struct {
 	Matched bool   `json:"matched"`
 	MatchID string `json:"match_id"`
+	// ProspectSuperLiked tells that the prospect's like, which made the match, was a super-like.
+	ProspectSuperLiked bool `json:"prospect_super_liked,omitempty"`
+}
+
+// Block stops two users from seeing, swiping on or matching with each other. It applies in
+// both directions, whoever blocked.
+type Block struct {
+	ID        string    `bson:"id,omitempty" json:"id,omitempty"`
+	BlockerID string    `bson:"blocker_id,omitempty" json:"blocker_id,omitempty"`
+	BlockedID string    `bson:"blocked_id,omitempty" json:"blocked_id,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// ReportReason is why a user reported another user.
+type ReportReason string
+
+const (
+	SpamReason                 ReportReason = "spam"
+	HarassmentReason           ReportReason = "harassment"
+	InappropriateContentReason ReportReason = "inappropriate_content"
+	FakeProfileReason          ReportReason = "fake_profile"
+	UnderageReason             ReportReason = "underage"
+	ScamReason                 ReportReason = "scam"
+	OtherReason                ReportReason = "other"
+)
+
+// ReportState is where a report is in the moderation queue. Reports are open until a moderator
+// claims them for review, and end up either actioned or dismissed.
+type ReportState string
+
+const (
+	OpenReport      ReportState = "open"
+	ReviewingReport ReportState = "reviewing"
+	ActionedReport  ReportState = "actioned"
+	DismissedReport ReportState = "dismissed"
+)
+
+// ModerationAction is what a moderator does to a reported user.
+type ModerationAction string
+
+const (
+	WarnAction       ModerationAction = "warn"
+	SuspendAction    ModerationAction = "suspend"
+	DeactivateAction ModerationAction = "deactivate"
+)
+
+// Report is a user's report of another user, kept for moderators to review.
+type Report struct {
+	ID         string           `bson:"id,omitempty" json:"id,omitempty"`
+	ReporterID string           `bson:"reporter_id,omitempty" json:"reporter_id,omitempty"`
+	ReportedID string           `bson:"reported_id,omitempty" json:"reported_id,omitempty"`
+	Reason     ReportReason     `bson:"reason,omitempty" json:"reason,omitempty"`
+	Details    string           `bson:"details,omitempty" json:"details,omitempty"`
+	State      ReportState      `bson:"state,omitempty" json:"state,omitempty"`
+	CreatedAt  time.Time        `bson:"created_at" json:"created_at"`
+	ClaimedBy  string           `bson:"claimed_by,omitempty" json:"claimed_by,omitempty"`
+	ClaimedAt  time.Time        `bson:"claimed_at,omitempty" json:"claimed_at,omitempty"`
+	Action     ModerationAction `bson:"action,omitempty" json:"action,omitempty"`
+	Note       string           `bson:"note,omitempty" json:"note,omitempty"`
+	ResolvedAt time.Time        `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+}
+
+// ReportFilter narrows down the moderation queue.
+type ReportFilter struct {
+	State *ReportState `json:"state,omitempty"`
+}
+
+func (rf ReportFilter) Validate() error {
+	return validation.ValidateStruct(&rf,
+		validation.Field(&rf.State, inStrings(validReportStates...)),
+	)
+}
+
+// ReportCursor is the position of the last report of a page. Reports are paged oldest first,
+// in (created_at, id) ascending order, so the queue is worked through in the order it was filed.
+type ReportCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// ReportResolution is how a moderator closes a report.
+type ReportResolution struct {
+	State       ReportState      `json:"state"`
+	Action      ModerationAction `json:"action,omitempty"`
+	SuspendDays int              `json:"suspend_days,omitempty"`
+	Note        string           `json:"note,omitempty"`
+}
+
+func (rr ReportResolution) Validate() error {
+	return validation.ValidateStruct(&rr,
+		validation.Field(&rr.State, validation.Required, inStrings("actioned", "dismissed")),
+		// An actioned report names its action; a dismissed one has none.
+		validation.Field(&rr.Action,
+			validation.When(rr.State == ActionedReport, validation.Required, inStrings(validModerationActions...)),
+			validation.When(rr.State == DismissedReport, validation.Empty),
+		),
+		validation.Field(&rr.SuspendDays,
+			validation.When(rr.Action == SuspendAction, validation.Required, validation.Min(1), validation.Max(365)).
+				Else(validation.Empty),
+		),
+		validation.Field(&rr.Note, validation.Length(0, 1000)),
+	)
+}
+
+// StatusPayload is an admin's change of a user's status.
+type StatusPayload struct {
+	Status Status `json:"status"`
+	Note   string `json:"note,omitempty"`
+}
+
+func (sp StatusPayload) Validate() error {
+	return validation.ValidateStruct(&sp,
+		validation.Field(&sp.Status, validation.Required, inStrings(validStatuses...)),
+		validation.Field(&sp.Note, validation.Length(0, 1000)),
+	)
+}
+
+// RolePayload is an admin's change of a user's role.
+type RolePayload struct {
+	Role Role   `json:"role"`
+	Note string `json:"note,omitempty"`
+}
+
+func (rp RolePayload) Validate() error {
+	return validation.ValidateStruct(&rp,
+		validation.Field(&rp.Role, validation.Required, inStrings(validRoles...)),
+		validation.Field(&rp.Note, validation.Length(0, 1000)),
+	)
+}
+
+// PasswordResetResponse holds the temporary password set by an admin. It is only ever shown once.
+type PasswordResetResponse struct {
+	TemporaryPassword string `json:"temporary_password"`
+}
+
+// AuditEntry records one moderation step: a claim, a resolution or an action on a user. Admin
+// actions on users outside of a report have no ReportID.
+type AuditEntry struct {
+	ID           string           `bson:"id,omitempty" json:"id,omitempty"`
+	ReportID     string           `bson:"report_id,omitempty" json:"report_id,omitempty"`
+	ModeratorID  string           `bson:"moderator_id,omitempty" json:"moderator_id,omitempty"`
+	TargetUserID string           `bson:"target_user_id,omitempty" json:"target_user_id,omitempty"`
+	Event        AuditEvent       `bson:"event,omitempty" json:"event,omitempty"`
+	FromState    ReportState      `bson:"from_state,omitempty" json:"from_state,omitempty"`
+	ToState      ReportState      `bson:"to_state,omitempty" json:"to_state,omitempty"`
+	Action       ModerationAction `bson:"action,omitempty" json:"action,omitempty"`
+	// Change is the new status or role of a status or role change.
+	Change    string    `bson:"change,omitempty" json:"change,omitempty"`
+	Note      string    `bson:"note,omitempty" json:"note,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// AuditEvent names a moderation step.
+type AuditEvent string
+
+const (
+	ReportClaimedEvent  AuditEvent = "report_claimed"
+	ReportResolvedEvent AuditEvent = "report_resolved"
+	UserModeratedEvent  AuditEvent = "user_moderated"
+	StatusChangedEvent  AuditEvent = "status_changed"
+	RoleChangedEvent    AuditEvent = "role_changed"
+	PasswordResetEvent  AuditEvent = "password_reset"
+)
+
+type ReportPayload struct {
+	Reason  ReportReason `json:"reason"`
+	Details string       `json:"details,omitempty"`
+}
+
+func (rp ReportPayload) Validate() error {
+	return validation.ValidateStruct(&rp,
+		validation.Field(&rp.Reason, validation.Required, inStrings(validReportReasons...)),
+		// "other" says nothing on its own, so it needs the details.
+		validation.Field(&rp.Details, validation.When(rp.Reason == OtherReason, validation.Required), validation.Length(0, 1000)),
+	)
+}
+
+// ReceivedLike is a like, or super-like, that a user received and has not answered yet.
+type ReceivedLike struct {
+	SwipeID string    `bson:"swipe_id" json:"swipe_id"`
+	Kind    SwipeKind `bson:"kind" json:"kind"`
+	LikedAt time.Time `bson:"liked_at" json:"liked_at"`
+	User    *User     `bson:"user" json:"user"`
+}
+
+// ReceivedLikeCursor is the position of the last like of a page. Likes are paged newest first,
+// in (swipe_time, id) descending order.
+type ReceivedLikeCursor struct {
+	LikedAt time.Time `json:"t"`
+	ID      string    `json:"id"`
+}
+
+// SwipeEvent is the data of the swipe events published on the event store.
+type SwipeEvent struct {
+	SwipeID    string    `json:"swipe_id"`
+	UserID     string    `json:"user_id"`
+	ProspectID string    `json:"prospect_id"`
+	At         time.Time `json:"at"`
 }
 
 type Match struct {
 	ID       string   `bson:"_id,omitempty" json:"id,omitempty"`
 	Profiles []string `bson:"profiles,omitempty" json:"profiles,omitempty"`
 	Matched  bool     `bson:"matched" json:"matched"`
+	// PairKey identifies the pair of profiles regardless of who swiped first.
+	PairKey        string    `bson:"pair_key,omitempty" json:"-"`
+	MatchedAt      time.Time `bson:"matched_at,omitempty" json:"matched_at,omitempty"`
+	LastActivityAt time.Time `bson:"last_activity_at,omitempty" json:"last_activity_at,omitempty"`
+	UnmatchedAt    time.Time `bson:"unmatched_at,omitempty" json:"unmatched_at,omitempty"`
+	UnmatchedBy    string    `bson:"unmatched_by,omitempty" json:"unmatched_by,omitempty"`
+	// ExtendedUntil is the expiry the match was pushed back to by its participants, and ExtendedBy
+	// the participants who used their one extension.
+	ExtendedUntil time.Time `bson:"extended_until,omitempty" json:"extended_until,omitempty"`
+	ExtendedBy    []string  `bson:"extended_by,omitempty" json:"extended_by,omitempty"`
+	ExpiredAt     time.Time `bson:"expired_at,omitempty" json:"expired_at,omitempty"`
+	// ExpiryRemindedFor is the expiry the participants were last reminded of.
+	ExpiryRemindedFor time.Time `bson:"expiry_reminded_for,omitempty" json:"-"`
+}
+
+// ExpiresAt returns when the match expires if it stays quiet: expireAfter after its last activity,
+// or when its extension runs out, whichever is later.
+func (m Match) ExpiresAt(expireAfter time.Duration) time.Time {
+	expiresAt := m.LastActivityAt.Add(expireAfter)
+	if m.ExtendedUntil.After(expiresAt) {
+		return m.ExtendedUntil
+	}
+	return expiresAt
+}
+
+// HasActivity reports whether the match saw anything since it was made: a message or an
+// extension.
+func (m Match) HasActivity() bool {
+	return m.LastActivityAt.After(m.MatchedAt) || len(m.ExtendedBy) > 0
+}
+
+// HasProfile reports whether the user takes part in the match.
+func (m Match) HasProfile(userID string) bool {
+	for _, profile := range m.Profiles {
+		if profile == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// OtherProfile returns the other participant of the match than the given user.
+func (m Match) OtherProfile(userID string) string {
+	for _, profile := range m.Profiles {
+		if profile != userID {
+			return profile
+		}
+	}
+	return ""
+}
+
+// MatchEvent is the data of the match events published on the event store.
+type MatchEvent struct {
+	MatchID  string    `json:"match_id"`
+	Profiles []string  `json:"profiles"`
+	UserID   string    `json:"user_id,omitempty"`
+	At       time.Time `json:"at"`
+	// ExpiresAt is set on the expiry events only.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// MatchExpiryPolicy expires matches that stay quiet for too long.
+type MatchExpiryPolicy struct {
+	// ExpireAfter is how long a match lasts without a message. Zero turns expiry off.
+	ExpireAfter time.Duration
+	// Extension is how much longer a match lasts when one of its participants extends it, which
+	// each participant can do once.
+	Extension time.Duration
+	// WarnBefore is how long before a match expires its participants are reminded. Zero turns
+	// reminders off.
+	WarnBefore time.Duration
+	// SweepInterval is how often matches are checked for expiry.
+	SweepInterval time.Duration
+}
+
+// Enabled reports whether matches expire at all.
+func (p MatchExpiryPolicy) Enabled() bool {
+	return p.ExpireAfter > 0
+}
+
+// Expired reports whether the match is past its expiry at the given time, whether or not the
+// sweeper has ended it yet. A match past its expiry is as good as expired: it can no longer be
+// extended, and a message must not bring it back.
+func (p MatchExpiryPolicy) Expired(match Match, at time.Time) bool {
+	return p.Enabled() && !match.ExpiresAt(p.ExpireAfter).After(at)
+}
+
+// MatchExpiry tells when a match expires and who extended it.
+type MatchExpiry struct {
+	MatchID    string    `json:"match_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	ExtendedBy []string  `json:"extended_by"`
+}
+
+// MatchPairKey returns the key of a pair of profiles, the same in either order.
+func MatchPairKey(profiles []string) string {
+	sorted := append([]string(nil), profiles...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ":")
 }
 
 type SwipeFilter struct {
@@ -255,13 +971,185 @@ type SwipeFilter struct {
 	MaxSwipeDate time.Time `bson:"max_swipe_date,omitempty" json:"max_swipe_date,omitempty"`
 }
 
+// Validate checks that the swipe date range, when both ends are given, is not reversed.
+func (sf SwipeFilter) Validate() error {
+	return validation.ValidateStruct(&sf,
+		validation.Field(&sf.MaxSwipeDate, validation.By(func(value interface{}) error {
+			maxDate := value.(time.Time)
+			if !maxDate.IsZero() && maxDate.Before(sf.MinSwipeDate) {
+				return errors.New("to must not be before from")
+			}
+			return nil
+		})),
+	)
+}
+
 type MatchFilter struct {
 	UserID string `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	// ExpireAfter, when set, has each match report when it expires.
+	ExpireAfter time.Duration `bson:"-" json:"-"`
+}
+
+// MatchedUser is one match as its participant sees it: the other party and the match details.
+type MatchedUser struct {
+	MatchID        string    `bson:"match_id" json:"match_id"`
+	MatchedAt      time.Time `bson:"matched_at" json:"matched_at"`
+	LastActivityAt time.Time `bson:"last_activity_at" json:"last_activity_at"`
+	// UnreadCount is the number of messages from the other party the user has not read.
+	UnreadCount int `bson:"unread_count" json:"unread_count"`
+	// ExpiresAt is when the match expires if it stays quiet, when matches expire.
+	ExpiresAt *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	User      *User      `bson:"user" json:"user"`
+}
+
+// SwipeCursor is the position of the last swipe of a page. Swipes are paged newest first,
+// in (swipe_time, id) descending order.
+type SwipeCursor struct {
+	SwipeTime time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// MatchCursor is the position of the last match of a page. Matches are paged newest first,
+// in (matched_at, id) descending order.
+type MatchCursor struct {
+	MatchedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// Message is a chat message sent by one participant of a match to the other.
+type Message struct {
+	ID       string    `bson:"id" json:"id"`
+	MatchID  string    `bson:"match_id" json:"match_id"`
+	SenderID string    `bson:"sender_id" json:"sender_id"`
+	Body     string    `bson:"body" json:"body"`
+	SentAt   time.Time `bson:"sent_at" json:"sent_at"`
+	// DeliveredAt is set when the recipient first fetches the message, ReadAt when they read it.
+	DeliveredAt time.Time `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	ReadAt      time.Time `bson:"read_at,omitempty" json:"read_at,omitempty"`
+}
+
+// MessagePayload is a message to send in a match.
+type MessagePayload struct {
+	Body string `json:"body"`
+}
+
+func (mp MessagePayload) Validate() error {
+	return validation.ValidateStruct(&mp,
+		validation.Field(&mp.Body, validation.Required, validation.Length(1, 2000)),
+	)
+}
+
+// ReadPayload marks the messages of a match as read, up to and including the given message, or
+// all of them when no message is given.
+type ReadPayload struct {
+	MessageID string `json:"message_id,omitempty"`
+}
+
+// ReadReceipt tells that a participant of a match read the other participant's messages up to a
+// point. It is both the response to marking messages read and the data of message.read events.
+type ReadReceipt struct {
+	MatchID  string    `json:"match_id"`
+	ReaderID string    `json:"reader_id"`
+	SenderID string    `json:"sender_id"`
+	UpTo     time.Time `json:"up_to"`
+	At       time.Time `json:"at"`
+	Count    int64     `json:"count"`
+}
+
+// TypingPayload starts or stops the typing indicator of a match.
+type TypingPayload struct {
+	Typing *bool `json:"typing"`
+}
+
+func (tp TypingPayload) Validate() error {
+	return validation.ValidateStruct(&tp,
+		validation.Field(&tp.Typing, validation.NotNil),
+	)
+}
+
+// TypingEvent is the data of the typing events published on the event store.
+type TypingEvent struct {
+	MatchID     string    `json:"match_id"`
+	UserID      string    `json:"user_id"`
+	RecipientID string    `json:"recipient_id"`
+	At          time.Time `json:"at"`
 }
 
-type MatchedUserInfo struct {
-	CurrentUser  *User   `bson:"current_user,omitempty" json:"user,omitempty"`
-	MatchedUsers []*User `bson:"matched_users,omitempty" json:"matched,omitempty"`
+// MessageCursor is the position of the last message of a page. Messages are paged newest first,
+// in (sent_at, id) descending order.
+type MessageCursor struct {
+	SentAt time.Time `json:"t"`
+	ID     string    `json:"id"`
+}
+
+// MessageEvent is the data of the message events published on the event store.
+type MessageEvent struct {
+	MessageID   string    `json:"message_id"`
+	MatchID     string    `json:"match_id"`
+	SenderID    string    `json:"sender_id"`
+	RecipientID string    `json:"recipient_id"`
+	Body        string    `json:"body"`
+	At          time.Time `json:"at"`
+}
+
+// Notification is a notification in a user's in-app inbox. Type is the topic of the event it was
+// made from, and Data holds the IDs a client needs to open what it is about, such as the match_id.
+type Notification struct {
+	ID        string            `bson:"id" json:"id"`
+	UserID    string            `bson:"user_id" json:"user_id"`
+	Type      string            `bson:"type" json:"type"`
+	Title     string            `bson:"title" json:"title"`
+	Body      string            `bson:"body" json:"body"`
+	Data      map[string]string `bson:"data,omitempty" json:"data,omitempty"`
+	CreatedAt time.Time         `bson:"created_at" json:"created_at"`
+	ReadAt    time.Time         `bson:"read_at,omitempty" json:"read_at,omitempty"`
+}
+
+// NotificationCursor is the position of the last notification of a page. Notifications are paged
+// newest first, in (created_at, id) descending order.
+type NotificationCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+// ReadNotificationsPayload marks the given notifications as read, or all of them when none are given.
+type ReadNotificationsPayload struct {
+	IDs []string `json:"ids,omitempty"`
+}
+
+func (rp ReadNotificationsPayload) Validate() error {
+	return validation.ValidateStruct(&rp,
+		validation.Field(&rp.IDs, validation.Length(0, 100), validation.Each(validation.Required)),
+	)
+}
+
+// NotificationsRead tells how many notifications were marked read.
+type NotificationsRead struct {
+	Count int64     `json:"count"`
+	At    time.Time `json:"at"`
+}
+
+// NotifierSettings configures the channels notifications are delivered through, besides the
+// in-app inbox.
+type NotifierSettings struct {
+	// Channels are the channels to deliver through: push, email, webhook, log or file.
+	Channels []string
+
+	// PushURL is the push provider endpoint, which maps user IDs to their devices.
+	PushURL    string
+	PushAPIKey string
+
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+	EmailFrom    string
+
+	// WebhookSecret signs webhook deliveries.
+	WebhookURL    string
+	WebhookSecret string
+
+	// FilePath is the file notifications are appended to, one JSON object per line.
+	FilePath string
 }
 
 type Ethnicity string
@@ -350,43 +1238,78 @@ const (
 )
 
 type AgeRange struct {
-	Min         int  `json:"min"`
-	Max         int  `json:"max"`
-	DealBreaker bool `json:"deal_breaker"`
+	Min         int  `bson:"min" json:"min"`
+	Max         int  `bson:"max" json:"max"`
+	DealBreaker bool `bson:"deal_breaker" json:"deal_breaker"`
 }
 
 type HeightRange struct {
-	Min         int  `json:"min"`
-	Max         int  `json:"max"`
-	DealBreaker bool `json:"deal_breaker"`
+	Min         int  `bson:"min" json:"min"`
+	Max         int  `bson:"max" json:"max"`
+	DealBreaker bool `bson:"deal_breaker" json:"deal_breaker"`
 }
 
 type SmokingPreference struct {
-	DealBreaker bool         `json:"deal_breaker"`
-	Status      SmokingHabit `json:"status"`
+	DealBreaker bool         `bson:"deal_breaker" json:"deal_breaker"`
+	Status      SmokingHabit `bson:"status" json:"status"`
 }
 
 type DrinkingPreference struct {
-	DealBreaker bool          `json:"deal_breaker"`
-	Status      DrinkingHabit `json:"status"`
+	DealBreaker bool          `bson:"deal_breaker" json:"deal_breaker"`
+	Status      DrinkingHabit `bson:"status" json:"status"`
 }
 
 type DrugPreference struct {
-	DealBreaker bool      `json:"deal_breaker"`
-	Status      DrugHabit `json:"status"`
+	DealBreaker bool      `bson:"deal_breaker" json:"deal_breaker"`
+	Status      DrugHabit `bson:"status" json:"status"`
 }
 
 type Preferences struct {
-	InterestedIn Gender             `json:"interested_in"` // men, women, non-binary, everyone
-	MaxDistance  int                `json:"max_distance"`
-	AgeRange     AgeRange           `json:"age_range"`
-	Ethnicity    Ethnicity          `json:"ethnicity"`
-	Religion     Religion           `json:"religion"`
-	Height       HeightRange        `json:"height"`
-	Children     string             `json:"children"`     // Doesn't have children, has children, open to all
-	Drinking     DrinkingPreference `json:"drinking"`     // Never, sometimes, often, open to all
-	FamilyPlans  string             `json:"family_plans"` // Doesn't want children, wants children, open to all, not sure yet, might want children
-	Drugs        DrugPreference     `json:"drugs"`        // Never, sometimes, often, open to all
-	Smoking      SmokingPreference  `json:"smoking"`      // Never, sometimes, often, open to all
-	Education    EducationLevel     `json:"education"`    // High School, Some College, Associates Degree, Bachelors Degree, Masters Degree, PhD/Post Doctoral, open to all
+	InterestedIn Gender             `bson:"interested_in" json:"interested_in"` // men, women, non-binary, everyone
+	MaxDistance  int                `bson:"max_distance" json:"max_distance"`
+	AgeRange     AgeRange           `bson:"age_range" json:"age_range"`
+	Ethnicity    Ethnicity          `bson:"ethnicity" json:"ethnicity"`
+	Religion     Religion           `bson:"religion" json:"religion"`
+	Height       HeightRange        `bson:"height" json:"height"`
+	Children     string             `bson:"children" json:"children"`         // Doesn't have children, has children, open to all
+	Drinking     DrinkingPreference `bson:"drinking" json:"drinking"`         // Never, sometimes, often, open to all
+	FamilyPlans  string             `bson:"family_plans" json:"family_plans"` // Doesn't want children, wants children, open to all, not sure yet, might want children
+	Drugs        DrugPreference     `bson:"drugs" json:"drugs"`               // Never, sometimes, often, open to all
+	Smoking      SmokingPreference  `bson:"smoking" json:"smoking"`           // Never, sometimes, often, open to all
+	Education    EducationLevel     `bson:"education" json:"education"`       // High School, Some College, Associates Degree, Bachelors Degree, Masters Degree, PhD/Post Doctoral, open to all
+}
+
+func (p Preferences) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.InterestedIn, inStrings(validGenders...)),
+		validation.Field(&p.MaxDistance, validation.Min(0)),
+		validation.Field(&p.AgeRange, validation.By(func(value interface{}) error {
+			return validateRange(p.AgeRange.Min, p.AgeRange.Max)
+		})),
+		validation.Field(&p.Ethnicity, inStrings(validEthnicities...)),
+		validation.Field(&p.Religion, inStrings(validReligions...)),
+		validation.Field(&p.Height, validation.By(func(value interface{}) error {
+			return validateRange(p.Height.Min, p.Height.Max)
+		})),
+		validation.Field(&p.Drinking, validation.By(func(value interface{}) error {
+			return inStrings(validDrinkingHabits...).Validate(p.Drinking.Status)
+		})),
+		validation.Field(&p.Drugs, validation.By(func(value interface{}) error {
+			return inStrings(validDrugHabits...).Validate(p.Drugs.Status)
+		})),
+		validation.Field(&p.Smoking, validation.By(func(value interface{}) error {
+			return inStrings(validSmokingHabits...).Validate(p.Smoking.Status)
+		})),
+	)
+}
+
+// validateRange checks a min/max pair where zero means "no bound".
+func validateRange(min, max int) error {
+	if min < 0 || max < 0 {
+		return errors.New("min and max must not be negative")
+	}
+	if max > 0 && min > max {
+		return errors.New("max must be greater than or equal to min")
+	}
+	return nil
 }
@@ -0,0 +1,62 @@
+package notifiers
+
+import (
+	"api/models"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// EmailNotifier emails notifications to the address of the user's account over SMTP.
+type EmailNotifier struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewEmailNotifier(addr, username, password, from string) (*EmailNotifier, error) {
+	if addr == "" || from == "" {
+		return nil, errors.New("email notifications need SMTP_ADDR and EMAIL_FROM")
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP_ADDR: %w", err)
+	}
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &EmailNotifier{addr: addr, auth: auth, from: from}, nil
+}
+
+func (e *EmailNotifier) Name() string {
+	return "email"
+}
+
+// Notify sends the email. net/smtp takes no context, so a slow server is not cut short by it.
+func (e *EmailNotifier) Notify(ctx context.Context, user models.User, notification models.Notification) error {
+	if user.Email == "" {
+		return nil
+	}
+	return smtp.SendMail(e.addr, e.auth, e.from, []string{user.Email}, buildEmail(e.from, user.Email, notification))
+}
+
+// buildEmail builds a plain text email of a notification. Titles are made from user input such as
+// names, so line breaks are dropped from the subject to keep it from adding headers.
+func buildEmail(from, to string, notification models.Notification) []byte {
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(notification.Title)
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(notification.Body)
+	msg.WriteString("\r\n")
+	return []byte(msg.String())
+}
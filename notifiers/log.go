@@ -0,0 +1,71 @@
+package notifiers
+
+import (
+	"api/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"os"
+	"sync"
+)
+
+// LogNotifier logs notifications instead of delivering them, for development.
+type LogNotifier struct {
+	logger *logrus.Logger
+}
+
+func NewLogNotifier(logger *logrus.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (l *LogNotifier) Name() string {
+	return "log"
+}
+
+func (l *LogNotifier) Notify(ctx context.Context, user models.User, notification models.Notification) error {
+	l.logger.WithFields(logrus.Fields{
+		"user":         user.ID,
+		"notification": notification.ID,
+		"type":         notification.Type,
+		"title":        notification.Title,
+		"body":         notification.Body,
+	}).Info("notification")
+	return nil
+}
+
+// FileNotifier appends notifications to a file, one JSON object per line, for development and tests.
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) (*FileNotifier, error) {
+	if path == "" {
+		return nil, errors.New("file notifications need NOTIFICATIONS_FILE")
+	}
+	return &FileNotifier{path: path}, nil
+}
+
+func (f *FileNotifier) Name() string {
+	return "file"
+}
+
+func (f *FileNotifier) Notify(ctx context.Context, user models.User, notification models.Notification) error {
+	line, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,82 @@
+package notifiers
+
+import (
+	"api/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+// Notifier delivers notifications to users through one channel, such as push or email.
+type Notifier interface {
+	// Name names the channel, for logs.
+	Name() string
+	Notify(ctx context.Context, user models.User, notification models.Notification) error
+}
+
+// New returns a notifier for each configured channel, and fails when a channel is unknown or
+// misses its settings.
+func New(settings models.NotifierSettings, logger *logrus.Logger) ([]Notifier, error) {
+	var notifiers []Notifier
+	for _, channel := range settings.Channels {
+		var notifier Notifier
+		var err error
+		switch channel {
+		case "push":
+			notifier, err = NewPushNotifier(settings.PushURL, settings.PushAPIKey)
+		case "email":
+			notifier, err = NewEmailNotifier(settings.SMTPAddr, settings.SMTPUsername, settings.SMTPPassword, settings.EmailFrom)
+		case "webhook":
+			notifier, err = NewWebhookNotifier(settings.WebhookURL, settings.WebhookSecret)
+		case "log":
+			notifier = NewLogNotifier(logger)
+		case "file":
+			notifier, err = NewFileNotifier(settings.FilePath)
+		default:
+			err = fmt.Errorf("unknown notification channel %q", channel)
+		}
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	return notifiers, nil
+}
+
+// httpClient is shared by the notifiers that deliver over HTTP. Deliveries are bounded by their
+// context too; the timeout only guards against a context without a deadline.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// postJSON posts the JSON of body to url, and fails unless the response is a success.
+func postJSON(ctx context.Context, url string, body interface{}, headers map[string]string) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return post(ctx, url, payload, headers)
+}
+
+func post(ctx context.Context, url string, payload []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded %s", url, resp.Status)
+	}
+	return nil
+}
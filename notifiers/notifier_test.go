@@ -0,0 +1,118 @@
+package notifiers
+
+import (
+	"api/models"
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testNotification = models.Notification{
+	ID:     "n1",
+	UserID: "alice",
+	Type:   "match.created",
+	Title:  "It's a match!",
+	Body:   "You and Bilal liked each other. Say salaam!",
+	Data:   map[string]string{"match_id": "m1"},
+}
+
+func TestNew(t *testing.T) {
+	notifiers, err := New(models.NotifierSettings{Channels: []string{"log", "file"}, FilePath: "notifications.jsonl"}, logrus.New())
+	assert.NoError(t, err)
+	assert.Len(t, notifiers, 2)
+
+	_, err = New(models.NotifierSettings{Channels: []string{"pigeon"}}, logrus.New())
+	assert.ErrorContains(t, err, "unknown notification channel")
+	// A channel without its settings is refused at startup rather than failing every delivery.
+	_, err = New(models.NotifierSettings{Channels: []string{"push"}}, logrus.New())
+	assert.Error(t, err)
+}
+
+func TestPushNotifier(t *testing.T) {
+	var received pushMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	notifier, err := NewPushNotifier(server.URL, "key")
+	assert.NoError(t, err)
+	assert.NoError(t, notifier.Notify(context.Background(), models.User{ID: "alice"}, testNotification))
+	assert.Equal(t, pushMessage{
+		UserID: "alice",
+		Title:  testNotification.Title,
+		Body:   testNotification.Body,
+		Data:   map[string]string{"notification_id": "n1", "type": "match.created", "match_id": "m1"},
+	}, received)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(body)
+		assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), r.Header.Get(WebhookSignatureHeader))
+
+		var notification models.Notification
+		assert.NoError(t, json.Unmarshal(body, &notification))
+		assert.Equal(t, testNotification, notification)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	notifier, err := NewWebhookNotifier(server.URL, "secret")
+	assert.NoError(t, err)
+	assert.NoError(t, notifier.Notify(context.Background(), models.User{ID: "alice"}, testNotification))
+
+	status = http.StatusInternalServerError
+	assert.Error(t, notifier.Notify(context.Background(), models.User{ID: "alice"}, testNotification))
+}
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	notifier, err := NewFileNotifier(path)
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, notifier.Notify(context.Background(), models.User{ID: "alice"}, testNotification))
+	}
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+	lines := 0
+	for scanner := bufio.NewScanner(file); scanner.Scan(); lines++ {
+		var notification models.Notification
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &notification))
+		assert.Equal(t, testNotification, notification)
+	}
+	assert.Equal(t, 2, lines)
+}
+
+func TestBuildEmail(t *testing.T) {
+	notification := testNotification
+	notification.Title = "Bilal\r\nBcc: everyone@example.com super-liked you!"
+
+	email := string(buildEmail("hello@muzz.com", "alice@example.com", notification))
+
+	headers, body, found := strings.Cut(email, "\r\n\r\n")
+	assert.True(t, found)
+	assert.NotContains(t, headers, "\r\nBcc:")
+	assert.Contains(t, headers, "To: alice@example.com\r\n")
+	assert.Equal(t, testNotification.Body+"\r\n", body)
+}
@@ -0,0 +1,46 @@
+package notifiers
+
+import (
+	"api/models"
+	"context"
+	"errors"
+)
+
+// PushNotifier sends push notifications through a push provider that keeps the devices of each
+// user, addressed by user ID.
+type PushNotifier struct {
+	url    string
+	apiKey string
+}
+
+func NewPushNotifier(url, apiKey string) (*PushNotifier, error) {
+	if url == "" || apiKey == "" {
+		return nil, errors.New("push notifications need PUSH_URL and PUSH_API_KEY")
+	}
+	return &PushNotifier{url: url, apiKey: apiKey}, nil
+}
+
+func (p *PushNotifier) Name() string {
+	return "push"
+}
+
+// pushMessage is the request body of the push provider.
+type pushMessage struct {
+	UserID string            `json:"user_id"`
+	Title  string            `json:"title"`
+	Body   string            `json:"body"`
+	Data   map[string]string `json:"data,omitempty"`
+}
+
+func (p *PushNotifier) Notify(ctx context.Context, user models.User, notification models.Notification) error {
+	data := map[string]string{"notification_id": notification.ID, "type": notification.Type}
+	for key, value := range notification.Data {
+		data[key] = value
+	}
+	return postJSON(ctx, p.url, pushMessage{
+		UserID: user.ID,
+		Title:  notification.Title,
+		Body:   notification.Body,
+		Data:   data,
+	}, map[string]string{"Authorization": "Bearer " + p.apiKey})
+}
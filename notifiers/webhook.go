@@ -0,0 +1,46 @@
+package notifiers
+
+import (
+	"api/models"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+)
+
+// WebhookSignatureHeader carries the hex HMAC-SHA256 of a webhook delivery's body, keyed with the
+// webhook secret, so the receiver can check the delivery came from us.
+const WebhookSignatureHeader = "X-Muzz-Signature"
+
+// WebhookNotifier posts every notification as JSON to a URL.
+type WebhookNotifier struct {
+	url    string
+	secret []byte
+}
+
+func NewWebhookNotifier(url, secret string) (*WebhookNotifier, error) {
+	if url == "" || secret == "" {
+		return nil, errors.New("webhook notifications need WEBHOOK_URL and WEBHOOK_SECRET")
+	}
+	return &WebhookNotifier{url: url, secret: []byte(secret)}, nil
+}
+
+func (w *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (w *WebhookNotifier) Notify(ctx context.Context, user models.User, notification models.Notification) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	return post(ctx, w.url, payload, map[string]string{WebhookSignatureHeader: "sha256=" + w.sign(payload)})
+}
+
+func (w *WebhookNotifier) sign(payload []byte) string {
+	mac := hmac.New(sha256.New, w.secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,54 @@
+package mongodb
+
+import (
+	"api/constants"
+	"api/models"
+	"api/repository"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type auditRepository struct {
+	mongo      *MongoStore
+	collection string
+}
+
+// CreateAuditEntry appends an entry to the moderation audit trail. Entries are never updated
+// or deleted.
+func (a auditRepository) CreateAuditEntry(ctx context.Context, payload *models.AuditEntry) error {
+	_, err := a.mongo.coll(a.collection).InsertOne(ctx, payload)
+	return err
+}
+
+// ListAuditEntries returns the audit trail of a report, oldest first.
+func (a auditRepository) ListAuditEntries(ctx context.Context, reportID string) ([]*models.AuditEntry, error) {
+	return a.list(ctx, bson.M{"report_id": reportID})
+}
+
+// ListUserAuditEntries returns every moderation step taken on a user, oldest first.
+func (a auditRepository) ListUserAuditEntries(ctx context.Context, userID string) ([]*models.AuditEntry, error) {
+	return a.list(ctx, bson.M{"target_user_id": userID})
+}
+
+func (a auditRepository) list(ctx context.Context, filter bson.M) ([]*models.AuditEntry, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "id", Value: 1}})
+	cursor, err := a.mongo.coll(a.collection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	result := []*models.AuditEntry{}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func NewAuditRepo(store *MongoStore) repository.AuditRepository {
+	return &auditRepository{
+		mongo:      store,
+		collection: constants.AuditCollection,
+	}
+}
@@ -0,0 +1,46 @@
+package mongodb
+
+import (
+	"api/constants"
+	"api/models"
+	"api/repository"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type blockRepository struct {
+	mongo      *MongoStore
+	collection string
+}
+
+// CreateBlock stores a block, or returns the existing block of the same blocker and blocked user.
+// It upserts, so blocking a user twice keeps the first block.
+func (b blockRepository) CreateBlock(ctx context.Context, payload *models.Block) (*models.Block, error) {
+	filter := bson.M{"blocker_id": payload.BlockerID, "blocked_id": payload.BlockedID}
+
+	var block models.Block
+	if err := findOrInsert(ctx, b.mongo.coll(b.collection), filter, payload, &block); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// IsBlocked reports whether either of the two users blocked the other.
+func (b blockRepository) IsBlocked(ctx context.Context, userID, otherUserID string) (bool, error) {
+	count, err := b.mongo.coll(b.collection).CountDocuments(ctx, bson.M{"$or": []bson.M{
+		{"blocker_id": userID, "blocked_id": otherUserID},
+		{"blocker_id": otherUserID, "blocked_id": userID},
+	}}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func NewBlockRepo(store *MongoStore) repository.BlockRepository {
+	return &blockRepository{
+		mongo:      store,
+		collection: constants.BlockCollection,
+	}
+}
@@ -33,26 +33,59 @@ func NewDiscoverQueryBuilder(user models.User, filter models.UserFilter) *Discov
 	return &DiscoverQueryBuilder{stages: []bson.M{geoNearStage}}
 }
 
-// LookupSwipes adds a stage to the pipeline to look up swipe data for each user.
-func (qb *DiscoverQueryBuilder) LookupSwipes() *DiscoverQueryBuilder {
+// ExcludeUnavailable leaves out deactivated users and users suspended at the given time. It
+// narrows the geospatial search itself, so they are never read.
+func (qb *DiscoverQueryBuilder) ExcludeUnavailable(now time.Time) *DiscoverQueryBuilder {
+	query := qb.query()
+	query["status"] = bson.M{"$ne": models.Deactivated}
+	query["suspended_until"] = bson.M{"$not": bson.M{"$gt": now}}
+	return qb
+}
+
+// query returns the query of the geospatial search, which users must meet to be read at all.
+func (qb *DiscoverQueryBuilder) query() bson.M {
+	geoNear := qb.stages[0]["$geoNear"].(bson.M)
+	query, ok := geoNear["query"].(bson.M)
+	if !ok {
+		query = bson.M{}
+		geoNear["query"] = query
+	}
+	return query
+}
+
+// where adds a condition on the stored user to the geospatial search, so the users who fail it
+// are dropped before their swipes, matches and blocks are looked up. Conditions are kept apart,
+// so two of them can constrain the same field.
+func (qb *DiscoverQueryBuilder) where(condition bson.M) *DiscoverQueryBuilder {
+	query := qb.query()
+	conditions, _ := query["$and"].([]bson.M)
+	query["$and"] = append(conditions, condition)
+	return qb
+}
+
+// LookupSwipes adds a stage to the pipeline to look up the viewer's swipes on each user.
+func (qb *DiscoverQueryBuilder) LookupSwipes(viewerID string) *DiscoverQueryBuilder {
 	lookupStage := bson.M{
 		"$lookup": bson.M{
-			"from":     constants.SwipeCollection,
-			"let":      bson.M{"targetUserId": "$id"},
-			"pipeline": []bson.M{{"$match": bson.M{"$expr": bson.M{"$eq": []interface{}{"$prospect_id", "$$targetUserId"}}}}},
-			"as":       "prospects",
+			"from": constants.SwipeCollection,
+			"let":  bson.M{"targetUserId": "$id"},
+			"pipeline": []bson.M{{"$match": bson.M{"$expr": bson.M{"$and": []bson.M{
+				{"$eq": []interface{}{"$user_id", viewerID}},
+				{"$eq": []interface{}{"$prospect_id", "$$targetUserId"}},
+			}}}}},
+			"as": "prospects",
 		},
 	}
 	qb.stages = append(qb.stages, lookupStage)
 	return qb
 }
 
-// MatchSwipesEmpty filters out users who have already been swiped on.
-func (qb *DiscoverQueryBuilder) MatchSwipesEmpty() *DiscoverQueryBuilder {
+// MatchSwipesEmpty filters out the viewer and the users the viewer has already swiped on.
+func (qb *DiscoverQueryBuilder) MatchSwipesEmpty(viewerID string) *DiscoverQueryBuilder {
 	matchStage := bson.M{
 		"$match": bson.M{
 			"$and": []bson.M{
-				{"id": bson.M{"$ne": "user.ID"}},
+				{"id": bson.M{"$ne": viewerID}},
 				{"prospects": bson.M{"$eq": []interface{}{}}},
 			},
 		},
@@ -61,70 +94,264 @@ func (qb *DiscoverQueryBuilder) MatchSwipesEmpty() *DiscoverQueryBuilder {
 	return qb
 }
 
-// LookupSwipesCount adds a stage to count the number of swipes for each user.
-func (qb *DiscoverQueryBuilder) LookupSwipesCount() *DiscoverQueryBuilder {
-	swipesLookupStage := bson.M{
+// LookupMatches adds a stage to the pipeline to look up the matches between the viewer and each
+// user, current or unmatched.
+func (qb *DiscoverQueryBuilder) LookupMatches(viewerID string) *DiscoverQueryBuilder {
+	lookupStage := bson.M{
 		"$lookup": bson.M{
-			"from": "swipes",
+			"from": constants.MatchCollection,
 			"let":  bson.M{"targetUserId": "$id"},
 			"pipeline": []bson.M{
-				{
-					"$match": bson.M{
-						"$expr": bson.M{
-							"$and": []bson.M{
-								{"$eq": []interface{}{"$user_id", "$$targetUserId"}},
-								{"$eq": []interface{}{"$prospect_id", "$$targetUserId"}},
-							},
-						},
-					},
-				},
+				{"$match": bson.M{"profiles": viewerID}},
+				{"$match": bson.M{"$expr": bson.M{"$in": []interface{}{"$$targetUserId", "$profiles"}}}},
 			},
-			"as": constants.SwipeCollection,
+			"as": "shared_matches",
 		},
 	}
-	qb.stages = append(qb.stages, swipesLookupStage, bson.M{"$match": bson.M{"swipes": bson.M{"$eq": []interface{}{}}}}, bson.M{"$match": bson.M{"id": bson.M{"$ne": "user.ID"}}})
+	qb.stages = append(qb.stages, lookupStage)
 	return qb
 }
 
-func (qb *DiscoverQueryBuilder) Projection() *DiscoverQueryBuilder {
-	projectionStage := bson.M{
-		"$project": bson.M{
-			"id":                1,
-			"name":              1,
-			"gender":            1,
-			"age":               bson.M{"$toInt": bson.M{"$divide": []interface{}{bson.M{"$subtract": []interface{}{time.Now(), bson.M{"$toDate": "$date_of_birth"}}}, 31556952000}}},
-			"date_of_birth":     1,
-			"location":          1,
-			"height":            1,
-			"ethnicity":         1,
-			"pets":              1,
-			"sexuality":         1,
-			"religion":          1,
-			"drinking":          1,
-			"smoking":           1,
-			"drugs":             1,
-			"dating_intentions": 1,
-			"kids":              1,
-			"occupation":        1,
-			"swipe_count":       1,
-			"attractiveness":    1,
-			"bio":               1,
-			"distance":          bson.M{"$divide": []interface{}{"$distance", 1000}},
+// MatchMatchesEmpty filters out the users the viewer has matched with, including unmatched
+// pairs, which must never see each other again.
+func (qb *DiscoverQueryBuilder) MatchMatchesEmpty() *DiscoverQueryBuilder {
+	qb.stages = append(qb.stages, bson.M{"$match": bson.M{"shared_matches": bson.M{"$eq": []interface{}{}}}})
+	return qb
+}
+
+// LookupBlocks adds a stage to the pipeline to look up the blocks between the viewer and each
+// user, whoever blocked.
+func (qb *DiscoverQueryBuilder) LookupBlocks(viewerID string) *DiscoverQueryBuilder {
+	qb.stages = append(qb.stages, lookupBlocksStage(viewerID, "$id"))
+	return qb
+}
+
+// MatchBlocksEmpty filters out the users the viewer blocked or was blocked by.
+func (qb *DiscoverQueryBuilder) MatchBlocksEmpty() *DiscoverQueryBuilder {
+	qb.stages = append(qb.stages, bson.M{"$match": bson.M{"blocks": bson.M{"$eq": []interface{}{}}}})
+	return qb
+}
+
+// lookupBlocksStage looks up, as "blocks", the blocks in either direction between the user and
+// the user whose ID is at the given field path.
+func lookupBlocksStage(userID, otherUserIDField string) bson.M {
+	return bson.M{
+		"$lookup": bson.M{
+			"from": constants.BlockCollection,
+			"let":  bson.M{"otherUserId": otherUserIDField},
+			"pipeline": []bson.M{{"$match": bson.M{"$expr": bson.M{"$or": []bson.M{
+				{"$and": []bson.M{
+					{"$eq": []interface{}{"$blocker_id", userID}},
+					{"$eq": []interface{}{"$blocked_id", "$$otherUserId"}},
+				}},
+				{"$and": []bson.M{
+					{"$eq": []interface{}{"$blocker_id", "$$otherUserId"}},
+					{"$eq": []interface{}{"$blocked_id", userID}},
+				}},
+			}}}}},
+			"as": "blocks",
 		},
 	}
-	qb.stages = append(qb.stages, projectionStage)
+}
+
+// lookupDeactivatedStage looks up, as "deactivated", the user whose ID is the given expression
+// if they deactivated their account. It only reads the user's ID, so rows of deactivated users can
+// be dropped before a page is cut and the remaining users are read in full.
+func lookupDeactivatedStage(otherUserID interface{}) bson.M {
+	return bson.M{
+		"$lookup": bson.M{
+			"from": constants.UserCollection,
+			"let":  bson.M{"otherUserId": otherUserID},
+			"pipeline": []bson.M{
+				{"$match": bson.M{"status": models.Deactivated, "$expr": bson.M{"$eq": []interface{}{"$id", "$$otherUserId"}}}},
+				{"$project": bson.M{"_id": 1}},
+			},
+			"as": "deactivated",
+		},
+	}
+}
+
+// publicProfileFields are the fields of a user anyone they are shown to can see. Contact details,
+// the exact location and date of birth, and the account and moderation state stay private.
+var publicProfileFields = []string{
+	"id", "name", "gender", "height", "ethnicity", "pets", "sexuality", "religion", "drinking", "smoking",
+	"drugs", "dating_intentions", "kids", "occupation", "bio",
+}
+
+// publicProfile shapes the user document at the given field path, e.g. "$user", as its public
+// profile, with the age instead of the date of birth.
+func publicProfile(userField string) bson.M {
+	profile := bson.M{"age": ageExpr(userField + ".date_of_birth")}
+	for _, field := range publicProfileFields {
+		profile[field] = userField + "." + field
+	}
+	return profile
+}
+
+// averageYear is the length of the years ages are counted in.
+const averageYear = 31556952 * time.Second
+
+// ageExpr computes the age in years from the date of birth at the given field path.
+func ageExpr(dateOfBirthField string) bson.M {
+	return bson.M{"$toInt": bson.M{"$divide": []interface{}{bson.M{"$subtract": []interface{}{time.Now(), bson.M{"$toDate": dateOfBirthField}}}, averageYear.Milliseconds()}}}
+}
+
+// dateOfBirthCondition turns an age range, as ageExpr counts ages at the given time, into a range
+// of stored dates of birth, which sort as they compare. A zero bound is left open.
+func dateOfBirthCondition(minAge, maxAge int, now time.Time) bson.M {
+	condition := bson.M{}
+	if minAge > 0 {
+		condition["$lte"] = now.Add(-time.Duration(minAge) * averageYear).Format(constants.DateOfBirthLayout)
+	}
+	if maxAge > 0 {
+		condition["$gt"] = now.Add(-time.Duration(maxAge+1) * averageYear).Format(constants.DateOfBirthLayout)
+	}
+	if len(condition) == 0 {
+		return nil
+	}
+	return condition
+}
+
+// LookupSuperLikes adds a stage to the pipeline to look up the super-likes each user gave the viewer.
+func (qb *DiscoverQueryBuilder) LookupSuperLikes(viewerID string) *DiscoverQueryBuilder {
+	lookupStage := bson.M{
+		"$lookup": bson.M{
+			"from": constants.SwipeCollection,
+			"let":  bson.M{"targetUserId": "$id"},
+			"pipeline": []bson.M{{"$match": bson.M{"$expr": bson.M{"$and": []bson.M{
+				{"$eq": []interface{}{"$user_id", "$$targetUserId"}},
+				{"$eq": []interface{}{"$prospect_id", viewerID}},
+				{"$eq": []interface{}{"$kind", models.SuperLikeSwipe}},
+			}}}}},
+			"as": "super_likes",
+		},
+	}
+	qb.stages = append(qb.stages, lookupStage)
+	return qb
+}
+
+// rankingSignalFields are the fields outside the public profile that discovered users are ranked
+// by. They are cleared before the profiles are shown.
+var rankingSignalFields = []string{"location", "swipe_count", "attractiveness", "created_at", "last_active_at"}
+
+// Projection shapes each user as their public profile, as matches and likes show it, with the
+// distance to the viewer in km, whether they super-liked the viewer, and the ranking signals.
+func (qb *DiscoverQueryBuilder) Projection() *DiscoverQueryBuilder {
+	fields := publicProfile("$$ROOT")
+	for _, field := range rankingSignalFields {
+		fields[field] = 1
+	}
+	fields["super_liked_you"] = bson.M{"$gt": []interface{}{bson.M{"$size": bson.M{"$ifNull": []interface{}{"$super_likes", []interface{}{}}}}, 0}}
+	fields["distance"] = bson.M{"$divide": []interface{}{"$distance", 1000}}
+	qb.stages = append(qb.stages, bson.M{"$project": fields})
+	return qb
+}
+
+// AgeFilter keeps users of the given age range at the given time. A zero bound is left open.
+func (qb *DiscoverQueryBuilder) AgeFilter(minAge, maxAge int, now time.Time) *DiscoverQueryBuilder {
+	if dateOfBirthRange := dateOfBirthCondition(minAge, maxAge, now); dateOfBirthRange != nil {
+		qb.where(bson.M{"date_of_birth": dateOfBirthRange})
+	}
 	return qb
 }
 
-// AgeFilter adds a filtering stage based on age range.
-func (qb *DiscoverQueryBuilder) AgeFilter(minAge, maxAge int) *DiscoverQueryBuilder {
-	if minAge > 0 || maxAge > 0 {
-		ageFilterStage := bson.M{"$match": bson.M{"age": bson.M{"$gte": minAge, "$lte": maxAge}}}
-		qb.stages = append(qb.stages, ageFilterStage)
+// HeightFilter keeps users of the given height range. A zero bound is left open.
+func (qb *DiscoverQueryBuilder) HeightFilter(minHeight, maxHeight int) *DiscoverQueryBuilder {
+	if heightRange := rangeCondition(minHeight, maxHeight); heightRange != nil {
+		qb.where(bson.M{"height": heightRange})
 	}
 	return qb
 }
 
+// AttributesFilter keeps users whose attribute matches any of the desired values, e.g. religion
+// in [muslim, other]. Attributes without desired values are skipped.
+func (qb *DiscoverQueryBuilder) AttributesFilter(filter models.UserFilter) *DiscoverQueryBuilder {
+	attributes := []struct {
+		field  string
+		values []string
+	}{
+		{"ethnicity", filter.DesiredEthnicity},
+		{"pets", filter.DesiredPets},
+		{"sexuality", filter.DesiredSexuality},
+		{"drinking", filter.DesiredDrinking},
+		{"smoking", filter.DesiredSmoking},
+		{"drugs", filter.DesiredDrugs},
+		{"dating_intentions", filter.DesiredIntentions},
+		{"religion", filter.DesiredReligion},
+	}
+
+	match := bson.M{}
+	for _, attribute := range attributes {
+		if len(attribute.values) > 0 {
+			match[attribute.field] = bson.M{"$in": attribute.values}
+		}
+	}
+	if len(match) > 0 {
+		qb.where(match)
+	}
+	return qb
+}
+
+// DealBreakers adds hard filters for the viewer's deal-breaker preferences, with ages counted at
+// the given time. Preferences that are not deal-breakers only affect ranking and are ignored here.
+func (qb *DiscoverQueryBuilder) DealBreakers(preferences *models.Preferences, now time.Time) *DiscoverQueryBuilder {
+	if preferences == nil {
+		return qb
+	}
+	match := bson.M{}
+	if preferences.InterestedIn != "" {
+		match["gender"] = preferences.InterestedIn
+	}
+	if preferences.AgeRange.DealBreaker {
+		if dateOfBirthRange := dateOfBirthCondition(preferences.AgeRange.Min, preferences.AgeRange.Max, now); dateOfBirthRange != nil {
+			match["date_of_birth"] = dateOfBirthRange
+		}
+	}
+	if preferences.Height.DealBreaker {
+		if heightRange := rangeCondition(preferences.Height.Min, preferences.Height.Max); heightRange != nil {
+			match["height"] = heightRange
+		}
+	}
+	if preferences.Drinking.DealBreaker && preferences.Drinking.Status != "" {
+		match["drinking"] = preferences.Drinking.Status
+	}
+	if preferences.Smoking.DealBreaker && preferences.Smoking.Status != "" {
+		match["smoking"] = preferences.Smoking.Status
+	}
+	if preferences.Drugs.DealBreaker && preferences.Drugs.Status != "" {
+		match["drugs"] = preferences.Drugs.Status
+	}
+	if len(match) > 0 {
+		qb.where(match)
+	}
+	return qb
+}
+
+// rangeCondition builds a $gte/$lte condition where a zero bound means "unbounded".
+func rangeCondition(min, max int) bson.M {
+	condition := bson.M{}
+	if min > 0 {
+		condition["$gte"] = min
+	}
+	if max > 0 {
+		condition["$lte"] = max
+	}
+	if len(condition) == 0 {
+		return nil
+	}
+	return condition
+}
+
+// Nearest sorts users by (super_liked_you, distance, id), super-likers of the viewer first, and
+// keeps the given number of them. It must run after Projection, which flags the super-likers.
+func (qb *DiscoverQueryBuilder) Nearest(limit int) *DiscoverQueryBuilder {
+	qb.stages = append(qb.stages,
+		bson.M{"$sort": bson.D{{Key: "super_liked_you", Value: -1}, {Key: "distance", Value: 1}, {Key: "id", Value: 1}}},
+		bson.M{"$limit": limit},
+	)
+	return qb
+}
+
 func (qb *DiscoverQueryBuilder) Build() []bson.M {
 	return qb.stages
 }
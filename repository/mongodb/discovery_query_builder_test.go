@@ -0,0 +1,307 @@
+package mongodb
+
+import (
+	"api/constants"
+	"api/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+	"time"
+)
+
+// stageIndex returns the index of the first stage with the given operator.
+func stageIndex(t *testing.T, pipeline []bson.M, operator string) int {
+	for i, stage := range pipeline {
+		if _, ok := stage[operator]; ok {
+			return i
+		}
+	}
+	t.Fatalf("pipeline has no %s stage", operator)
+	return -1
+}
+
+// discoverNow is the time the discover pipelines of the tests are built at.
+var discoverNow = time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+
+// filterConditions returns the filters the geospatial search is narrowed by.
+func filterConditions(pipeline []bson.M) []bson.M {
+	conditions, _ := pipeline[0]["$geoNear"].(bson.M)["query"].(bson.M)["$and"].([]bson.M)
+	if conditions == nil {
+		return []bson.M{}
+	}
+	return conditions
+}
+
+func TestBuildDiscoverPipeline_Filters(t *testing.T) {
+	viewer := models.User{ID: "viewer", Location: []float64{-0.12, 51.5}}
+
+	testCases := []struct {
+		name     string
+		filter   models.UserFilter
+		expected []bson.M
+	}{
+		{
+			name:     "no filter",
+			filter:   models.UserFilter{},
+			expected: []bson.M{},
+		},
+		{
+			name:   "age range",
+			filter: models.UserFilter{MinAge: 24, MaxAge: 30},
+			expected: []bson.M{
+				{"date_of_birth": bson.M{"$lte": "2002-06-15", "$gt": "1995-06-15"}},
+			},
+		},
+		{
+			name:   "minimum age only",
+			filter: models.UserFilter{MinAge: 24},
+			expected: []bson.M{
+				{"date_of_birth": bson.M{"$lte": "2002-06-15"}},
+			},
+		},
+		{
+			name:   "height range",
+			filter: models.UserFilter{MinHeight: 160, MaxHeight: 185},
+			expected: []bson.M{
+				{"height": bson.M{"$gte": 160, "$lte": 185}},
+			},
+		},
+		{
+			name:   "maximum height only",
+			filter: models.UserFilter{MaxHeight: 185},
+			expected: []bson.M{
+				{"height": bson.M{"$lte": 185}},
+			},
+		},
+		{
+			name:   "single desired religion",
+			filter: models.UserFilter{DesiredReligion: []string{"muslim"}},
+			expected: []bson.M{
+				{"religion": bson.M{"$in": []string{"muslim"}}},
+			},
+		},
+		{
+			name:   "multiple desired religions",
+			filter: models.UserFilter{DesiredReligion: []string{"muslim", "other"}},
+			expected: []bson.M{
+				{"religion": bson.M{"$in": []string{"muslim", "other"}}},
+			},
+		},
+		{
+			name: "every desired attribute",
+			filter: models.UserFilter{
+				DesiredEthnicity:  []string{"asian", "black"},
+				DesiredPets:       []string{"dog"},
+				DesiredSexuality:  []string{"straight"},
+				DesiredDrinking:   []string{"no"},
+				DesiredSmoking:    []string{"no", "none"},
+				DesiredDrugs:      []string{"no"},
+				DesiredIntentions: []string{"life partner"},
+				DesiredReligion:   []string{"muslim"},
+			},
+			expected: []bson.M{
+				{
+					"ethnicity":         bson.M{"$in": []string{"asian", "black"}},
+					"pets":              bson.M{"$in": []string{"dog"}},
+					"sexuality":         bson.M{"$in": []string{"straight"}},
+					"drinking":          bson.M{"$in": []string{"no"}},
+					"smoking":           bson.M{"$in": []string{"no", "none"}},
+					"drugs":             bson.M{"$in": []string{"no"}},
+					"dating_intentions": bson.M{"$in": []string{"life partner"}},
+					"religion":          bson.M{"$in": []string{"muslim"}},
+				},
+			},
+		},
+		{
+			name: "age, height and attributes combined",
+			filter: models.UserFilter{
+				MinAge:          24,
+				MaxAge:          30,
+				MinHeight:       160,
+				DesiredPets:     []string{"cat", "none"},
+				DesiredReligion: []string{"muslim", "other"},
+			},
+			expected: []bson.M{
+				{"date_of_birth": bson.M{"$lte": "2002-06-15", "$gt": "1995-06-15"}},
+				{"height": bson.M{"$gte": 160}},
+				{
+					"pets":     bson.M{"$in": []string{"cat", "none"}},
+					"religion": bson.M{"$in": []string{"muslim", "other"}},
+				},
+			},
+		},
+		{
+			name: "deal-breaker preferences",
+			filter: models.UserFilter{Preferences: &models.Preferences{
+				InterestedIn: models.Female,
+				AgeRange:     models.AgeRange{Min: 25, Max: 35, DealBreaker: true},
+				Height:       models.HeightRange{Min: 170, DealBreaker: false},
+				Drinking:     models.DrinkingPreference{Status: models.NoDrinkingHabit, DealBreaker: true},
+				Smoking:      models.SmokingPreference{Status: models.NoSmokingHabit, DealBreaker: false},
+			}},
+			expected: []bson.M{
+				{
+					"gender":        models.Female,
+					"date_of_birth": bson.M{"$lte": "2001-06-15", "$gt": "1990-06-15"},
+					"drinking":      models.NoDrinkingHabit,
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pipeline := buildDiscoverPipeline(tc.filter, viewer, 20, discoverNow)
+			assert.Equal(t, tc.expected, filterConditions(pipeline))
+		})
+	}
+}
+
+func TestBuildDiscoverPipeline_MaxDistance(t *testing.T) {
+	viewer := models.User{ID: "viewer", Location: []float64{-0.12, 51.5}}
+
+	pipeline := buildDiscoverPipeline(models.UserFilter{MaxDistance: 10}, viewer, 20, discoverNow)
+	geoNear := pipeline[0]["$geoNear"].(bson.M)
+	assert.Equal(t, 10000, geoNear["maxDistance"])
+	assert.Equal(t, viewer.Location, geoNear["near"].(bson.M)["coordinates"])
+
+	pipeline = buildDiscoverPipeline(models.UserFilter{}, viewer, 20, discoverNow)
+	assert.NotContains(t, pipeline[0]["$geoNear"].(bson.M), "maxDistance")
+}
+
+func TestBuildDiscoverPipeline_ExcludesViewerAndTheirSwipes(t *testing.T) {
+	viewer := models.User{ID: "viewer", Location: []float64{-0.12, 51.5}}
+
+	pipeline := buildDiscoverPipeline(models.UserFilter{}, viewer, 20, discoverNow)
+
+	lookup := pipeline[stageIndex(t, pipeline, "$lookup")]["$lookup"].(bson.M)
+	swipeMatch := lookup["pipeline"].([]bson.M)[0]["$match"].(bson.M)["$expr"].(bson.M)["$and"].([]bson.M)
+	assert.Contains(t, swipeMatch, bson.M{"$eq": []interface{}{"$user_id", "viewer"}})
+
+	match := pipeline[stageIndex(t, pipeline, "$match")]["$match"].(bson.M)["$and"].([]bson.M)
+	assert.Contains(t, match, bson.M{"id": bson.M{"$ne": "viewer"}})
+}
+
+func TestBuildDiscoverPipeline_Nearest(t *testing.T) {
+	viewer := models.User{ID: "viewer", Location: []float64{-0.12, 51.5}}
+
+	pipeline := buildDiscoverPipeline(models.UserFilter{}, viewer, 500, discoverNow)
+
+	// Super-likers make it into the candidates wherever they are, then the nearest users do.
+	assert.Equal(t, []bson.M{
+		{"$sort": bson.D{{Key: "super_liked_you", Value: -1}, {Key: "distance", Value: 1}, {Key: "id", Value: 1}}},
+		{"$limit": 500},
+	}, pipeline[stageIndex(t, pipeline, "$project")+1:])
+}
+
+func TestBuildDiscoverPipeline_ExcludesMatchedAndUnmatchedPairs(t *testing.T) {
+	viewer := models.User{ID: "viewer", Location: []float64{-0.12, 51.5}}
+
+	pipeline := buildDiscoverPipeline(models.UserFilter{}, viewer, 20, discoverNow)
+
+	var matchLookup bson.M
+	for _, stage := range pipeline[:stageIndex(t, pipeline, "$project")] {
+		if lookup, ok := stage["$lookup"].(bson.M); ok && lookup["from"] == "matches" {
+			matchLookup = lookup
+		}
+	}
+	if assert.NotNil(t, matchLookup, "pipeline has no matches $lookup") {
+		// Matches are looked up whatever their state, so unmatched pairs stay hidden too.
+		assert.Equal(t, bson.M{"$match": bson.M{"profiles": "viewer"}}, matchLookup["pipeline"].([]bson.M)[0])
+	}
+	assert.Contains(t, pipeline, bson.M{"$match": bson.M{"shared_matches": bson.M{"$eq": []interface{}{}}}})
+}
+
+func TestBuildDiscoverPipeline_FlagsSuperLikers(t *testing.T) {
+	viewer := models.User{ID: "viewer", Location: []float64{-0.12, 51.5}}
+
+	pipeline := buildDiscoverPipeline(models.UserFilter{}, viewer, 20, discoverNow)
+
+	var superLikes bson.M
+	for _, stage := range pipeline[:stageIndex(t, pipeline, "$project")] {
+		if lookup, ok := stage["$lookup"].(bson.M); ok && lookup["as"] == "super_likes" {
+			superLikes = lookup
+		}
+	}
+	if assert.NotNil(t, superLikes, "pipeline has no super-likes $lookup") {
+		conditions := superLikes["pipeline"].([]bson.M)[0]["$match"].(bson.M)["$expr"].(bson.M)["$and"].([]bson.M)
+		assert.Contains(t, conditions, bson.M{"$eq": []interface{}{"$prospect_id", "viewer"}})
+		assert.Contains(t, conditions, bson.M{"$eq": []interface{}{"$kind", models.SuperLikeSwipe}})
+	}
+	projection := pipeline[stageIndex(t, pipeline, "$project")]["$project"].(bson.M)
+	assert.Contains(t, projection, "super_liked_you")
+}
+
+func TestBuildDiscoverPipeline_OnlyThePublicProfile(t *testing.T) {
+	viewer := models.User{ID: "viewer", Location: []float64{-0.12, 51.5}}
+
+	pipeline := buildDiscoverPipeline(models.UserFilter{}, viewer, 20, discoverNow)
+	projection := pipeline[stageIndex(t, pipeline, "$project")]["$project"].(bson.M)
+
+	assert.Equal(t, "$$ROOT.name", projection["name"])
+	assert.Contains(t, projection, "age")
+	assert.Contains(t, projection, "distance")
+	for _, private := range []string{"email", "password", "date_of_birth", "timezone", "preferences",
+		"swipe_usage", "role", "status", "deactivation_reason", "suspended_until", "warned_at"} {
+		assert.NotContains(t, projection, private)
+	}
+}
+
+func TestBuildDiscoverPipeline_ExcludesBlocksInBothDirections(t *testing.T) {
+	viewer := models.User{ID: "viewer", Location: []float64{-0.12, 51.5}}
+
+	pipeline := buildDiscoverPipeline(models.UserFilter{}, viewer, 20, discoverNow)
+
+	var blocks bson.M
+	for _, stage := range pipeline[:stageIndex(t, pipeline, "$project")] {
+		if lookup, ok := stage["$lookup"].(bson.M); ok && lookup["from"] == "blocks" {
+			blocks = lookup
+		}
+	}
+	if assert.NotNil(t, blocks, "pipeline has no blocks $lookup") {
+		directions := blocks["pipeline"].([]bson.M)[0]["$match"].(bson.M)["$expr"].(bson.M)["$or"].([]bson.M)
+		assert.Equal(t, []bson.M{
+			{"$and": []bson.M{
+				{"$eq": []interface{}{"$blocker_id", "viewer"}},
+				{"$eq": []interface{}{"$blocked_id", "$$otherUserId"}},
+			}},
+			{"$and": []bson.M{
+				{"$eq": []interface{}{"$blocker_id", "$$otherUserId"}},
+				{"$eq": []interface{}{"$blocked_id", "viewer"}},
+			}},
+		}, directions)
+	}
+	assert.Contains(t, pipeline, bson.M{"$match": bson.M{"blocks": bson.M{"$eq": []interface{}{}}}})
+}
+
+func TestBuildDiscoverPipeline_ExcludesUnavailableUsers(t *testing.T) {
+	viewer := models.User{ID: "viewer", Location: []float64{-0.12, 51.5}}
+
+	pipeline := buildDiscoverPipeline(models.UserFilter{}, viewer, 20, discoverNow)
+
+	query := pipeline[0]["$geoNear"].(bson.M)["query"].(bson.M)
+	assert.Equal(t, bson.M{"$ne": models.Deactivated}, query["status"])
+	assert.Equal(t, bson.M{"$not": bson.M{"$gt": discoverNow}}, query["suspended_until"])
+}
+
+func TestDateOfBirthCondition_CountsAgesAsTheProfileDoes(t *testing.T) {
+	// age is the age ageExpr shows for a date of birth at discoverNow.
+	age := func(dateOfBirth string) int {
+		born, err := time.Parse(constants.DateOfBirthLayout, dateOfBirth)
+		assert.NoError(t, err)
+		return int(discoverNow.Sub(born) / averageYear)
+	}
+	dayAfter := func(dateOfBirth string) string {
+		born, _ := time.Parse(constants.DateOfBirthLayout, dateOfBirth)
+		return born.AddDate(0, 0, 1).Format(constants.DateOfBirthLayout)
+	}
+
+	condition := dateOfBirthCondition(24, 30, discoverNow)
+	youngest, oldest := condition["$lte"].(string), condition["$gt"].(string)
+	assert.Equal(t, 24, age(youngest))
+	assert.Equal(t, 23, age(dayAfter(youngest)))
+	assert.Equal(t, 31, age(oldest))
+	assert.Equal(t, 30, age(dayAfter(oldest)))
+
+	assert.Nil(t, dateOfBirthCondition(0, 0, discoverNow))
+}
@@ -8,6 +8,8 @@ import (
 	"errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
 )
 
 type matchRepository struct {
@@ -15,10 +17,10 @@ type matchRepository struct {
 	collection string
 }
 
-// GetMatchByProfiles returns a match by the given profiles.
+// GetMatchByProfiles returns a match by the given profiles, in any order.
 func (m matchRepository) GetMatchByProfiles(ctx context.Context, profiles []string) (*models.Match, error) {
 	var match models.Match
-	err := m.mongo.coll(m.collection).FindOne(ctx, bson.M{"profiles": profiles}).Decode(&match)
+	err := m.mongo.coll(m.collection).FindOne(ctx, bson.M{"pair_key": models.MatchPairKey(profiles)}).Decode(&match)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, nil
@@ -28,22 +30,16 @@ func (m matchRepository) GetMatchByProfiles(ctx context.Context, profiles []stri
 	return &match, nil
 }
 
-// CreateMatch creates a new match in the database.
+// CreateMatch creates a new match in the database, or returns the existing match of the same pair
+// of profiles. It upserts on the pair key, so concurrent calls for one pair store a single match.
 func (m matchRepository) CreateMatch(ctx context.Context, payload *models.Match) (*models.Match, error) {
-	existingMatch, err := m.GetMatchByProfiles(ctx, payload.Profiles)
-	if err != nil {
-		return nil, err
-	}
-
-	if existingMatch != nil {
-		return nil, repository.ErrDuplicateFound
-	}
+	payload.PairKey = models.MatchPairKey(payload.Profiles)
 
-	_, err = m.mongo.coll(m.collection).InsertOne(ctx, payload)
-	if err != nil {
+	var match models.Match
+	if err := findOrInsert(ctx, m.mongo.coll(m.collection), bson.M{"pair_key": payload.PairKey}, payload, &match); err != nil {
 		return nil, err
 	}
-	return payload, nil
+	return &match, nil
 }
 
 // GetMatchById returns a match by their ID.
@@ -59,37 +55,46 @@ func (m matchRepository) GetMatchById(ctx context.Context, id string) (*models.M
 	return &match, nil
 }
 
-// GetMatchesFiltered returns a match by the given filter.
-func (m matchRepository) GetMatchesFiltered(ctx context.Context, filter models.MatchFilter) (*models.MatchedUserInfo, error) {
-	qb := NewMatchedUserInfoQueryBuilder(filter).
-		MatchProfiles([]string{filter.UserID}).
-		UnwindProfiles().
-		LookupUsers().
-		UnwindUsers().
-		GroupResults()
-
-	cursor, err := m.mongo.coll(m.collection).Aggregate(ctx, qb.Build())
+// GetMatchesFiltered returns one page of the matches of filter.UserID, newest first, each with
+// the other party of the match.
+func (m matchRepository) GetMatchesFiltered(ctx context.Context, filter models.MatchFilter, after *models.MatchCursor, limit int) ([]*models.MatchedUser, error) {
+	cursor, err := m.mongo.coll(m.collection).Aggregate(ctx, buildMatchesPipeline(filter, after, limit))
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	result := &models.MatchedUserInfo{}
-	if cursor.Next(ctx) {
-		if err := cursor.Decode(result); err != nil {
-			return nil, err
-		}
+	var result []*models.MatchedUser
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, err
 	}
-
 	return result, nil
 }
 
+// buildMatchesPipeline builds the aggregation pipeline behind GetMatchesFiltered. Users and unread
+// counts are looked up after the page is cut, so only those of the page are read.
+func buildMatchesPipeline(filter models.MatchFilter, after *models.MatchCursor, limit int) []bson.M {
+	return NewMatchedUserInfoQueryBuilder(filter).
+		MatchProfiles().
+		ExcludeDeactivated().
+		After(after).
+		Paginate(limit).
+		LookupUsers().
+		UnwindUsers().
+		LookupUnreadCount().
+		Projection().
+		Build()
+}
+
 // UpdateMatch updates a match in the database.
 func (m matchRepository) UpdateMatch(ctx context.Context, payload *models.Match) (*models.Match, error) {
-	update := bson.M{"$set": payload}
+	// Matches are stored under _id, which cannot be part of the $set.
+	fields := *payload
+	fields.ID = ""
+	update := bson.M{"$set": fields}
 	result, err := m.mongo.coll(m.collection).UpdateOne(
 		ctx,
-		bson.M{"id": payload.ID},
+		bson.M{"_id": payload.ID},
 		update,
 	)
 	if err != nil {
@@ -102,11 +107,160 @@ func (m matchRepository) UpdateMatch(ctx context.Context, payload *models.Match)
 	return payload, nil
 }
 
+// EndMatch ends a current match on behalf of one of its participants, and reports whether it did.
+// A match that is no longer current is left alone, so only one of concurrent calls ends it.
+func (m matchRepository) EndMatch(ctx context.Context, id, userID string, at time.Time) (bool, error) {
+	result, err := m.mongo.coll(m.collection).UpdateOne(
+		ctx,
+		bson.M{"_id": id, "matched": true},
+		bson.M{"$set": bson.M{
+			"matched":      false,
+			"unmatched_at": at,
+			"unmatched_by": userID,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// RecordActivity moves the last activity of a current match forward to the given time. Ended
+// matches and older times are left alone, so it never undoes an unmatch or goes back in time.
+func (m matchRepository) RecordActivity(ctx context.Context, id string, at time.Time) error {
+	_, err := m.mongo.coll(m.collection).UpdateOne(
+		ctx,
+		bson.M{"_id": id, "matched": true},
+		bson.M{"$max": bson.M{"last_activity_at": at}},
+	)
+	return err
+}
+
+// GetExpiredMatches returns up to limit current matches that expired by now, the longest expired first.
+func (m matchRepository) GetExpiredMatches(ctx context.Context, expireAfter time.Duration, now time.Time, limit int) ([]*models.Match, error) {
+	return m.findExpiring(ctx, buildExpiredQuery(expireAfter, now), limit)
+}
+
+// GetMatchesToRemind returns up to limit current matches that expire within warnBefore of now and
+// whose participants were not reminded of that expiry yet.
+func (m matchRepository) GetMatchesToRemind(ctx context.Context, expireAfter, warnBefore time.Duration, now time.Time, limit int) ([]*models.Match, error) {
+	return m.findExpiring(ctx, buildRemindQuery(expireAfter, warnBefore, now), limit)
+}
+
+func (m matchRepository) findExpiring(ctx context.Context, query bson.M, limit int) ([]*models.Match, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "last_activity_at", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := m.mongo.coll(m.collection).Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []*models.Match
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ExpireMatch ends a current match that expired by the given time, and reports whether it did. A
+// match that saw activity or was extended in the meantime is left alone.
+func (m matchRepository) ExpireMatch(ctx context.Context, id string, expireAfter time.Duration, at time.Time) (bool, error) {
+	query := buildExpiredQuery(expireAfter, at)
+	query["_id"] = id
+	result, err := m.mongo.coll(m.collection).UpdateOne(ctx, query, bson.M{"$set": bson.M{
+		"matched":      false,
+		"unmatched_at": at,
+		"expired_at":   at,
+	}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// MarkExpiryReminded records that the participants of a current match were reminded that it
+// expires at the given time, and reports whether they were not already. A match whose expiry
+// moved in the meantime is left alone.
+func (m matchRepository) MarkExpiryReminded(ctx context.Context, id string, expireAfter time.Duration, expiresAt time.Time) (bool, error) {
+	result, err := m.mongo.coll(m.collection).UpdateOne(ctx,
+		bson.M{
+			"_id":                 id,
+			"matched":             true,
+			"expiry_reminded_for": bson.M{"$ne": expiresAt},
+			"$expr":               bson.M{"$eq": []interface{}{expiresAtExpr(expireAfter), expiresAt}},
+		},
+		bson.M{"$set": bson.M{"expiry_reminded_for": expiresAt}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// ExtendMatch pushes the expiry of a current match back by extension on behalf of one of its
+// participants, and returns the match, or nil if it is no longer current or the participant
+// already extended it. The expiry is computed in the update, so two participants extending at
+// once both count.
+func (m matchRepository) ExtendMatch(ctx context.Context, id, userID string, expireAfter, extension time.Duration) (*models.Match, error) {
+	var match models.Match
+	err := m.mongo.coll(m.collection).FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "matched": true, "extended_by": bson.M{"$ne": userID}},
+		// An update pipeline, so the extension is added to the expiry as stored.
+		[]bson.M{{"$set": bson.M{
+			"extended_until": bson.M{"$add": []interface{}{expiresAtExpr(expireAfter), extension.Milliseconds()}},
+			"extended_by":    bson.M{"$concatArrays": []interface{}{bson.M{"$ifNull": []interface{}{"$extended_by", bson.A{}}}, bson.A{userID}}},
+		}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&match)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &match, nil
+}
+
+// expiresAtExpr is the aggregation expression of models.Match.ExpiresAt.
+func expiresAtExpr(expireAfter time.Duration) bson.M {
+	return bson.M{"$max": []interface{}{
+		bson.M{"$add": []interface{}{"$last_activity_at", expireAfter.Milliseconds()}},
+		"$extended_until",
+	}}
+}
+
+// buildExpiredQuery selects the current matches that expired by now. A match never expires before
+// expireAfter has passed since its last activity, which narrows the matches the index serves.
+func buildExpiredQuery(expireAfter time.Duration, now time.Time) bson.M {
+	return bson.M{
+		"matched":          true,
+		"last_activity_at": bson.M{"$lte": now.Add(-expireAfter)},
+		"$expr":            bson.M{"$lte": []interface{}{expiresAtExpr(expireAfter), now}},
+	}
+}
+
+// buildRemindQuery selects the current matches that expire after now but within warnBefore, and
+// whose participants were not reminded of that expiry yet.
+func buildRemindQuery(expireAfter, warnBefore time.Duration, now time.Time) bson.M {
+	expiresAt := expiresAtExpr(expireAfter)
+	return bson.M{
+		"matched":          true,
+		"last_activity_at": bson.M{"$lte": now.Add(warnBefore - expireAfter)},
+		"$expr": bson.M{"$and": []bson.M{
+			{"$gt": []interface{}{expiresAt, now}},
+			{"$lte": []interface{}{expiresAt, now.Add(warnBefore)}},
+			{"$ne": []interface{}{expiresAt, "$expiry_reminded_for"}},
+		}},
+	}
+}
+
 // DeleteMatch deletes a match in the database.
 func (m matchRepository) DeleteMatch(ctx context.Context, id string) error {
 	result, err := m.mongo.coll(m.collection).DeleteOne(
 		ctx,
-		bson.M{"id": id},
+		bson.M{"_id": id},
 	)
 	if err != nil {
 		return err
@@ -117,6 +271,21 @@ func (m matchRepository) DeleteMatch(ctx context.Context, id string) error {
 	return nil
 }
 
+// DeleteQuietMatch deletes a current match that saw no activity since it was made, and reports
+// whether it did. A match with messages or an extension is left alone.
+func (m matchRepository) DeleteQuietMatch(ctx context.Context, id string) (bool, error) {
+	result, err := m.mongo.coll(m.collection).DeleteOne(ctx, bson.M{
+		"_id":         id,
+		"matched":     true,
+		"extended_by": bson.M{"$exists": false},
+		"$expr":       bson.M{"$lte": []interface{}{"$last_activity_at", "$matched_at"}},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
 func NewMatchRepo(store *MongoStore) repository.MatchRepository {
 	return &matchRepository{
 		mongo:      store,
@@ -0,0 +1,114 @@
+package mongodb
+
+import (
+	"api/models"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+	"time"
+)
+
+func TestBuildExpiredQuery(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+	expiresAt := bson.M{"$max": []interface{}{
+		bson.M{"$add": []interface{}{"$last_activity_at", week.Milliseconds()}},
+		"$extended_until",
+	}}
+
+	assert.Equal(t, bson.M{
+		"matched": true,
+		// Only matches quiet for a week can have expired, extended or not.
+		"last_activity_at": bson.M{"$lte": now.Add(-week)},
+		"$expr":            bson.M{"$lte": []interface{}{expiresAt, now}},
+	}, buildExpiredQuery(week, now))
+}
+
+func TestBuildRemindQuery(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	week, day := 7*24*time.Hour, 24*time.Hour
+	expiresAt := expiresAtExpr(week)
+
+	assert.Equal(t, bson.M{
+		"matched":          true,
+		"last_activity_at": bson.M{"$lte": now.Add(-6 * day)},
+		"$expr": bson.M{"$and": []bson.M{
+			{"$gt": []interface{}{expiresAt, now}},
+			{"$lte": []interface{}{expiresAt, now.Add(day)}},
+			// Each expiry is reminded of once; an extension or a message makes a new one.
+			{"$ne": []interface{}{expiresAt, "$expiry_reminded_for"}},
+		}},
+	}, buildRemindQuery(week, day, now))
+}
+
+func TestMatchIndexes_PairKeyIsUnique(t *testing.T) {
+	var pairKey *mongo.IndexModel
+	indexes := matchIndexes()
+	for i := range indexes {
+		if name := indexes[i].Options.Name; name != nil && *name == "pair_key_unique" {
+			pairKey = &indexes[i]
+		}
+	}
+	if assert.NotNil(t, pairKey, "no pair key index") {
+		assert.Equal(t, bson.D{{Key: "pair_key", Value: 1}}, pairKey.Keys)
+		assert.True(t, *pairKey.Options.Unique)
+		// Matches stored before the pair key existed are left out, rather than colliding on a missing key.
+		assert.Equal(t, bson.M{"pair_key": bson.M{"$exists": true}}, pairKey.Options.PartialFilterExpression)
+	}
+}
+
+func TestMatchRepository_CreateMatch(t *testing.T) {
+	matchedAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	existing := models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true, PairKey: "alice:bob", MatchedAt: matchedAt}
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("upserts on the pair key", func(mt *mtest.T) {
+		repo := matchRepository{mongo: &MongoStore{client: mt.Client, dbName: mt.DB.Name()}, collection: mt.Coll.Name()}
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "value", Value: existing}))
+
+		match, err := repo.CreateMatch(context.Background(), &models.Match{ID: "m2", Profiles: []string{"bob", "alice"}, Matched: true})
+		assert.NoError(mt, err)
+		assert.Equal(mt, existing.ID, match.ID)
+
+		// The pair key is the same whichever profile swiped first.
+		command := mt.GetStartedEvent().Command
+		assert.Equal(mt, "alice:bob", command.Lookup("query", "pair_key").StringValue())
+		assert.Equal(mt, "alice:bob", command.Lookup("update", "$setOnInsert", "pair_key").StringValue())
+		assert.True(mt, command.Lookup("upsert").Boolean())
+	})
+
+	mt.Run("returns the match that won a race for the pair", func(mt *mtest.T) {
+		repo := matchRepository{mongo: &MongoStore{client: mt.Client, dbName: mt.DB.Name()}, collection: mt.Coll.Name()}
+		stored, err := bson.Marshal(existing)
+		assert.NoError(mt, err)
+		var storedDoc bson.D
+		assert.NoError(mt, bson.Unmarshal(stored, &storedDoc))
+		mt.AddMockResponses(
+			// Both upserts missed the document, and this one hit the unique pair key index.
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 11000, Name: "DuplicateKey", Message: "E11000 duplicate key error"}),
+			mtest.CreateCursorResponse(0, mt.DB.Name()+"."+mt.Coll.Name(), mtest.FirstBatch, storedDoc),
+		)
+
+		match, err := repo.CreateMatch(context.Background(), &models.Match{ID: "m2", Profiles: []string{"bob", "alice"}, Matched: true})
+		assert.NoError(mt, err)
+		assert.Equal(mt, &existing, match)
+
+		// The upsert is followed by a read of the match that was stored.
+		mt.GetStartedEvent()
+		command := mt.GetStartedEvent().Command
+		assert.Equal(mt, "find", command.Index(0).Key())
+		assert.Equal(mt, "alice:bob", command.Lookup("filter", "pair_key").StringValue())
+	})
+
+	mt.Run("fails on any other error", func(mt *mtest.T) {
+		repo := matchRepository{mongo: &MongoStore{client: mt.Client, dbName: mt.DB.Name()}, collection: mt.Coll.Name()}
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 91, Name: "ShutdownInProgress", Message: "shutting down"}))
+
+		match, err := repo.CreateMatch(context.Background(), &models.Match{ID: "m2", Profiles: []string{"bob", "alice"}, Matched: true})
+		assert.Error(mt, err)
+		assert.Nil(mt, match)
+	})
+}
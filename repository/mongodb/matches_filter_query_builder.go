@@ -7,7 +7,8 @@ import (
 )
 
 // MatchedUserInfoQueryBuilder is a builder for constructing MongoDB aggregation pipelines
-// specifically for matching user information.
+// specifically for matching user information. Every row of the result is one match of
+// filter.UserID, with the other party of the match as its user.
 type MatchedUserInfoQueryBuilder struct {
 	filter   models.MatchFilter
 	pipeline []bson.M
@@ -21,44 +22,123 @@ func NewMatchedUserInfoQueryBuilder(filter models.MatchFilter) *MatchedUserInfoQ
 	}
 }
 
-func (qb *MatchedUserInfoQueryBuilder) MatchProfiles(profiles []string) *MatchedUserInfoQueryBuilder {
-	matchFilter := bson.M{"profiles": bson.M{"$in": profiles}}
+// MatchProfiles keeps the current matches the filtered user takes part in.
+func (qb *MatchedUserInfoQueryBuilder) MatchProfiles() *MatchedUserInfoQueryBuilder {
+	matchFilter := bson.M{"profiles": qb.filter.UserID, "matched": true}
 	qb.pipeline = append(qb.pipeline, bson.M{"$match": matchFilter})
 	return qb
 }
 
-func (qb *MatchedUserInfoQueryBuilder) UnwindProfiles() *MatchedUserInfoQueryBuilder {
-	qb.pipeline = append(qb.pipeline, bson.M{"$unwind": "$profiles"})
+// ExcludeDeactivated drops the matches whose other party deactivated their account, before the
+// page is cut so that pages stay full.
+func (qb *MatchedUserInfoQueryBuilder) ExcludeDeactivated() *MatchedUserInfoQueryBuilder {
+	qb.pipeline = append(qb.pipeline,
+		lookupDeactivatedStage(qb.otherProfile()),
+		bson.M{"$match": bson.M{"deactivated": bson.M{"$eq": []interface{}{}}}},
+	)
 	return qb
 }
 
+// After keeps the matches that come after the cursor, i.e. older ones.
+func (qb *MatchedUserInfoQueryBuilder) After(cursor *models.MatchCursor) *MatchedUserInfoQueryBuilder {
+	if cursor == nil {
+		return qb
+	}
+	afterStage := bson.M{
+		"$match": bson.M{
+			"$or": []bson.M{
+				{"matched_at": bson.M{"$lt": cursor.MatchedAt}},
+				{"matched_at": cursor.MatchedAt, "_id": bson.M{"$lt": cursor.ID}},
+			},
+		},
+	}
+	qb.pipeline = append(qb.pipeline, afterStage)
+	return qb
+}
+
+// Paginate sorts matches newest first and limits the result to the given page size.
+func (qb *MatchedUserInfoQueryBuilder) Paginate(limit int) *MatchedUserInfoQueryBuilder {
+	qb.pipeline = append(qb.pipeline,
+		bson.M{"$sort": bson.D{{Key: "matched_at", Value: -1}, {Key: "_id", Value: -1}}},
+		bson.M{"$limit": limit},
+	)
+	return qb
+}
+
+// LookupUsers looks up the other party of each match, leaving the filtered user out.
 func (qb *MatchedUserInfoQueryBuilder) LookupUsers() *MatchedUserInfoQueryBuilder {
+	otherProfile := bson.M{
+		"$addFields": bson.M{
+			"other_profile": qb.otherProfile(),
+		},
+	}
 	lookupStage := bson.M{
 		"$lookup": bson.M{
 			"from":         constants.UserCollection,
-			"localField":   "profiles",
+			"localField":   "other_profile",
 			"foreignField": "id",
 			"as":           "user",
 		},
 	}
-	qb.pipeline = append(qb.pipeline, lookupStage)
+	qb.pipeline = append(qb.pipeline, otherProfile, lookupStage)
 	return qb
 }
 
+// otherProfile is the expression of the ID of the other party of a match.
+func (qb *MatchedUserInfoQueryBuilder) otherProfile() bson.M {
+	return bson.M{"$arrayElemAt": []interface{}{
+		bson.M{"$filter": bson.M{
+			"input": "$profiles",
+			"cond":  bson.M{"$ne": []interface{}{"$$this", qb.filter.UserID}},
+		}},
+		0,
+	}}
+}
+
+// UnwindUsers turns the looked up user into a single document. Matches whose other party no
+// longer exists are dropped.
 func (qb *MatchedUserInfoQueryBuilder) UnwindUsers() *MatchedUserInfoQueryBuilder {
 	qb.pipeline = append(qb.pipeline, bson.M{"$unwind": "$user"})
 	return qb
 }
 
-func (qb *MatchedUserInfoQueryBuilder) GroupResults() *MatchedUserInfoQueryBuilder {
-	groupStage := bson.M{
-		"$group": bson.M{
-			"_id":           nil,
-			"current_user":  bson.M{"$first": "$user"},
-			"matched_users": bson.M{"$push": "$user"},
+// LookupUnreadCount counts, as "unread", the messages of each match from the other party that the
+// filtered user has not read.
+func (qb *MatchedUserInfoQueryBuilder) LookupUnreadCount() *MatchedUserInfoQueryBuilder {
+	lookupStage := bson.M{
+		"$lookup": bson.M{
+			"from": constants.MessageCollection,
+			"let":  bson.M{"matchId": "$_id"},
+			"pipeline": []bson.M{
+				{"$match": bson.M{
+					"$expr":     bson.M{"$eq": []interface{}{"$match_id", "$$matchId"}},
+					"sender_id": bson.M{"$ne": qb.filter.UserID},
+					"read_at":   bson.M{"$exists": false},
+				}},
+				{"$count": "count"},
+			},
+			"as": "unread",
 		},
 	}
-	qb.pipeline = append(qb.pipeline, groupStage)
+	qb.pipeline = append(qb.pipeline, lookupStage)
+	return qb
+}
+
+// Projection shapes each match as a models.MatchedUser, with the public profile of the other party
+// and its expiry when matches expire.
+func (qb *MatchedUserInfoQueryBuilder) Projection() *MatchedUserInfoQueryBuilder {
+	projection := bson.M{
+		"_id":              0,
+		"match_id":         "$_id",
+		"matched_at":       1,
+		"last_activity_at": 1,
+		"unread_count":     bson.M{"$ifNull": []interface{}{bson.M{"$arrayElemAt": []interface{}{"$unread.count", 0}}, 0}},
+		"user":             publicProfile("$user"),
+	}
+	if qb.filter.ExpireAfter > 0 {
+		projection["expires_at"] = expiresAtExpr(qb.filter.ExpireAfter)
+	}
+	qb.pipeline = append(qb.pipeline, bson.M{"$project": projection})
 	return qb
 }
 
@@ -0,0 +1,125 @@
+package mongodb
+
+import (
+	"api/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+	"time"
+)
+
+func TestBuildMatchesPipeline_OnlyTheOtherParty(t *testing.T) {
+	pipeline := buildMatchesPipeline(models.MatchFilter{UserID: "alice"}, nil, 21)
+
+	assert.Equal(t, bson.M{"$match": bson.M{"profiles": "alice", "matched": true}}, pipeline[0])
+
+	otherProfile := pipeline[stageIndex(t, pipeline, "$addFields")]["$addFields"].(bson.M)["other_profile"].(bson.M)
+	filter := otherProfile["$arrayElemAt"].([]interface{})[0].(bson.M)["$filter"].(bson.M)
+	assert.Equal(t, bson.M{"$ne": []interface{}{"$$this", "alice"}}, filter["cond"])
+
+	lookup := pipeline[stageIndex(t, pipeline, "$addFields")+1]["$lookup"].(bson.M)
+	assert.Equal(t, "other_profile", lookup["localField"])
+
+	// No $group: each match stays its own row rather than being folded with the caller.
+	for _, stage := range pipeline {
+		assert.NotContains(t, stage, "$group")
+	}
+}
+
+func TestBuildMatchesPipeline_ExcludesDeactivatedUsers(t *testing.T) {
+	pipeline := buildMatchesPipeline(models.MatchFilter{UserID: "alice"}, nil, 21)
+
+	// Matches with deactivated users are dropped before the page is cut, so pages stay full.
+	lookup := pipeline[1]["$lookup"].(bson.M)
+	assert.Equal(t, "users", lookup["from"])
+	otherUserID := lookup["let"].(bson.M)["otherUserId"].(bson.M)
+	filter := otherUserID["$arrayElemAt"].([]interface{})[0].(bson.M)["$filter"].(bson.M)
+	assert.Equal(t, bson.M{"$ne": []interface{}{"$$this", "alice"}}, filter["cond"])
+	assert.Equal(t, models.Deactivated, lookup["pipeline"].([]bson.M)[0]["$match"].(bson.M)["status"])
+	assert.Equal(t, bson.M{"$match": bson.M{"deactivated": bson.M{"$eq": []interface{}{}}}}, pipeline[2])
+	assert.Less(t, 2, stageIndex(t, pipeline, "$limit"))
+}
+
+func TestBuildMatchesPipeline_Pagination(t *testing.T) {
+	matchedAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	sortStage := bson.M{"$sort": bson.D{{Key: "matched_at", Value: -1}, {Key: "_id", Value: -1}}}
+
+	testCases := []struct {
+		name     string
+		after    *models.MatchCursor
+		limit    int
+		expected []bson.M
+	}{
+		{
+			name:     "first page",
+			limit:    21,
+			expected: []bson.M{sortStage, {"$limit": 21}},
+		},
+		{
+			name:  "next page",
+			after: &models.MatchCursor{MatchedAt: matchedAt, ID: "01hkz7pmjd"},
+			limit: 11,
+			expected: []bson.M{
+				{"$match": bson.M{"$or": []bson.M{
+					{"matched_at": bson.M{"$lt": matchedAt}},
+					{"matched_at": matchedAt, "_id": bson.M{"$lt": "01hkz7pmjd"}},
+				}}},
+				sortStage,
+				{"$limit": 11},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pipeline := buildMatchesPipeline(models.MatchFilter{UserID: "alice"}, tc.after, tc.limit)
+			// The page is cut before the users are looked up.
+			assert.Equal(t, tc.expected, pipeline[3:stageIndex(t, pipeline, "$addFields")])
+		})
+	}
+}
+
+func TestBuildMatchesPipeline_UnreadCount(t *testing.T) {
+	pipeline := buildMatchesPipeline(models.MatchFilter{UserID: "alice"}, nil, 21)
+
+	unread := -1
+	for i, stage := range pipeline {
+		if lookup, ok := stage["$lookup"].(bson.M); ok && lookup["as"] == "unread" {
+			unread = i
+		}
+	}
+	// Only the matches on the page are counted.
+	assert.Less(t, stageIndex(t, pipeline, "$limit"), unread)
+
+	lookup := pipeline[unread]["$lookup"].(bson.M)
+	assert.Equal(t, "messages", lookup["from"])
+	match := lookup["pipeline"].([]bson.M)[0]["$match"].(bson.M)
+	assert.Equal(t, bson.M{"$ne": "alice"}, match["sender_id"])
+	assert.Equal(t, bson.M{"$exists": false}, match["read_at"])
+
+	projection := pipeline[len(pipeline)-1]["$project"].(bson.M)
+	assert.Contains(t, projection, "unread_count")
+}
+
+func TestBuildMatchesPipeline_ExpiresAt(t *testing.T) {
+	projection := func(filter models.MatchFilter) bson.M {
+		pipeline := buildMatchesPipeline(filter, nil, 21)
+		return pipeline[len(pipeline)-1]["$project"].(bson.M)
+	}
+
+	assert.NotContains(t, projection(models.MatchFilter{UserID: "alice"}), "expires_at")
+	week := 7 * 24 * time.Hour
+	assert.Equal(t, expiresAtExpr(week), projection(models.MatchFilter{UserID: "alice", ExpireAfter: week})["expires_at"])
+}
+
+func TestBuildMatchesPipeline_OnlyThePublicProfile(t *testing.T) {
+	pipeline := buildMatchesPipeline(models.MatchFilter{UserID: "alice"}, nil, 21)
+	user := pipeline[len(pipeline)-1]["$project"].(bson.M)["user"].(bson.M)
+
+	assert.Equal(t, "$user.name", user["name"])
+	assert.Contains(t, user, "age")
+	for _, private := range []string{"email", "password", "location", "date_of_birth", "timezone", "preferences",
+		"swipe_usage", "role", "status", "deactivation_reason", "suspended_until", "warned_at"} {
+		assert.NotContains(t, user, private)
+	}
+}
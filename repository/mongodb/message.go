@@ -0,0 +1,103 @@
+package mongodb
+
+import (
+	"api/constants"
+	"api/models"
+	"api/repository"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type messageRepository struct {
+	mongo      *MongoStore
+	collection string
+}
+
+// CreateMessage stores a new message.
+func (m messageRepository) CreateMessage(ctx context.Context, payload *models.Message) (*models.Message, error) {
+	if _, err := m.mongo.coll(m.collection).InsertOne(ctx, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// GetMessageById returns a message by its ID, or nil if there is none.
+func (m messageRepository) GetMessageById(ctx context.Context, id string) (*models.Message, error) {
+	var message models.Message
+	err := m.mongo.coll(m.collection).FindOne(ctx, bson.M{"id": id}).Decode(&message)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &message, nil
+}
+
+// ListMessages returns one page of the messages of a match, newest first.
+func (m messageRepository) ListMessages(ctx context.Context, matchID string, after *models.MessageCursor, limit int) ([]*models.Message, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "sent_at", Value: -1}, {Key: "id", Value: -1}}).
+		SetLimit(int64(limit))
+	cursor, err := m.mongo.coll(m.collection).Find(ctx, buildMessagesQuery(matchID, after), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []*models.Message
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MarkDelivered marks the messages of a match sent to the recipient as delivered at the given
+// time. Messages delivered before keep their first delivery time.
+func (m messageRepository) MarkDelivered(ctx context.Context, matchID, recipientID string, at time.Time) error {
+	_, err := m.mongo.coll(m.collection).UpdateMany(ctx,
+		bson.M{"match_id": matchID, "sender_id": bson.M{"$ne": recipientID}, "delivered_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"delivered_at": at}},
+	)
+	return err
+}
+
+// MarkRead marks the unread messages of a match sent to the reader up to the given time as read,
+// and as delivered if they were not yet, and returns how many it marked.
+func (m messageRepository) MarkRead(ctx context.Context, matchID, readerID string, upTo, at time.Time) (int64, error) {
+	result, err := m.mongo.coll(m.collection).UpdateMany(ctx,
+		bson.M{"match_id": matchID, "sender_id": bson.M{"$ne": readerID}, "sent_at": bson.M{"$lte": upTo}, "read_at": bson.M{"$exists": false}},
+		// An update pipeline, so delivered_at can fall back to its own value.
+		[]bson.M{{"$set": bson.M{
+			"read_at":      at,
+			"delivered_at": bson.M{"$ifNull": []interface{}{"$delivered_at", at}},
+		}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// buildMessagesQuery builds the query behind ListMessages.
+func buildMessagesQuery(matchID string, after *models.MessageCursor) bson.M {
+	query := bson.M{"match_id": matchID}
+	if after != nil {
+		query["$or"] = []bson.M{
+			{"sent_at": bson.M{"$lt": after.SentAt}},
+			{"sent_at": after.SentAt, "id": bson.M{"$lt": after.ID}},
+		}
+	}
+	return query
+}
+
+func NewMessageRepo(store *MongoStore) repository.MessageRepository {
+	return &messageRepository{
+		mongo:      store,
+		collection: constants.MessageCollection,
+	}
+}
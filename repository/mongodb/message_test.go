@@ -0,0 +1,22 @@
+package mongodb
+
+import (
+	"api/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+	"time"
+)
+
+func TestBuildMessagesQuery(t *testing.T) {
+	sentAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, bson.M{"match_id": "m1"}, buildMessagesQuery("m1", nil))
+	assert.Equal(t, bson.M{
+		"match_id": "m1",
+		"$or": []bson.M{
+			{"sent_at": bson.M{"$lt": sentAt}},
+			{"sent_at": sentAt, "id": bson.M{"$lt": "01hkz7pmjd"}},
+		},
+	}, buildMessagesQuery("m1", &models.MessageCursor{SentAt: sentAt, ID: "01hkz7pmjd"}))
+}
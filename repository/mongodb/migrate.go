@@ -0,0 +1,167 @@
+package mongodb
+
+import (
+	"api/constants"
+	"api/models"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrate brings data stored by earlier versions in line with the indexes and queries of this
+// one. It runs before the indexes are created, and does nothing on data that is already migrated.
+func migrate(ctx context.Context, client *mongo.Client, dbName string) error {
+	db := client.Database(dbName)
+	if err := retireDuplicateEmails(ctx, db.Collection(constants.UserCollection)); err != nil {
+		return err
+	}
+	if err := backfillMatchPairKeys(ctx, db.Collection(constants.MatchCollection)); err != nil {
+		return err
+	}
+	return removeDuplicateSwipes(ctx, db.Collection(constants.SwipeCollection))
+}
+
+// retireDuplicateEmails keeps the email of only the oldest of the users who registered with the
+// same email, which earlier versions let concurrent registrations do, so the unique email index
+// can be built. Logging in already reached only one of them. The others keep their email behind
+// their ID, which no login matches. It does nothing once the index exists.
+func retireDuplicateEmails(ctx context.Context, coll *mongo.Collection) error {
+	indexed, err := hasIndex(ctx, coll, emailUniqueIndexName)
+	if err != nil || indexed {
+		return err
+	}
+
+	cursor, err := coll.Aggregate(ctx, buildDuplicateEmailsPipeline(), options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var duplicates struct {
+			IDs []interface{} `bson:"ids"`
+		}
+		if err := cursor.Decode(&duplicates); err != nil {
+			return err
+		}
+		// The oldest user comes first and keeps the email.
+		_, err := coll.UpdateMany(ctx,
+			bson.M{"_id": bson.M{"$in": duplicates.IDs[1:]}},
+			[]bson.M{{"$set": bson.M{"email": bson.M{"$concat": []interface{}{"$id", ":", "$email"}}}}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// buildDuplicateEmailsPipeline groups the users who share an email, with the document IDs of
+// each group, oldest user first.
+func buildDuplicateEmailsPipeline() []bson.M {
+	return []bson.M{
+		{"$match": bson.M{"email": bson.M{"$type": "string"}}},
+		{"$sort": bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{"$group": bson.M{
+			"_id":   "$email",
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}
+}
+
+// backfillMatchPairKeys gives the pair key to matches stored before it existed, oldest first. When
+// a pair matched more than once, only its oldest match gets the key, so the key stays unique.
+func backfillMatchPairKeys(ctx context.Context, coll *mongo.Collection) error {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "matched_at", Value: 1}}).
+		SetProjection(bson.M{"profiles": 1})
+	cursor, err := coll.Find(ctx, bson.M{"pair_key": bson.M{"$exists": false}}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var match models.Match
+		if err := cursor.Decode(&match); err != nil {
+			return err
+		}
+		_, err := coll.UpdateOne(ctx,
+			bson.M{"_id": match.ID, "pair_key": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"pair_key": models.MatchPairKey(match.Profiles)}},
+		)
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// removeDuplicateSwipes keeps only the latest of the swipes a user made on the same prospect, which
+// earlier versions allowed, so the unique swipe index can be built. It does nothing once the index
+// exists.
+func removeDuplicateSwipes(ctx context.Context, coll *mongo.Collection) error {
+	indexed, err := hasIndex(ctx, coll, swipeUniqueIndexName)
+	if err != nil || indexed {
+		return err
+	}
+
+	cursor, err := coll.Aggregate(ctx, buildDuplicateSwipesPipeline(), options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var duplicates struct {
+			IDs []interface{} `bson:"ids"`
+		}
+		if err := cursor.Decode(&duplicates); err != nil {
+			return err
+		}
+		// The latest swipe comes first and is kept.
+		if _, err := coll.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": duplicates.IDs[1:]}}); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// buildDuplicateSwipesPipeline groups the swipes a user made on the same prospect more than once,
+// with the document IDs of each group, latest swipe first.
+func buildDuplicateSwipesPipeline() []bson.M {
+	return []bson.M{
+		{"$sort": bson.D{{Key: "swipe_time", Value: -1}}},
+		{"$group": bson.M{
+			"_id":   bson.M{"user_id": "$user_id", "prospect_id": "$prospect_id"},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}
+}
+
+// hasIndex reports whether the collection has an index of the given name.
+func hasIndex(ctx context.Context, coll *mongo.Collection, name string) (bool, error) {
+	cursor, err := coll.Indexes().List(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var index struct {
+			Name string `bson:"name"`
+		}
+		if err := cursor.Decode(&index); err != nil {
+			return false, err
+		}
+		if index.Name == name {
+			return true, nil
+		}
+	}
+	return false, cursor.Err()
+}
@@ -0,0 +1,74 @@
+package mongodb
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+)
+
+func TestBuildDuplicateSwipesPipeline(t *testing.T) {
+	assert.Equal(t, []bson.M{
+		// Latest first, so the swipe that is kept leads each group.
+		{"$sort": bson.D{{Key: "swipe_time", Value: -1}}},
+		{"$group": bson.M{
+			"_id":   bson.M{"user_id": "$user_id", "prospect_id": "$prospect_id"},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}, buildDuplicateSwipesPipeline())
+}
+
+func TestBuildDuplicateEmailsPipeline(t *testing.T) {
+	assert.Equal(t, []bson.M{
+		// Users without an email do not share one.
+		{"$match": bson.M{"email": bson.M{"$type": "string"}}},
+		// Oldest first, so the user who keeps the email leads each group.
+		{"$sort": bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		{"$group": bson.M{
+			"_id":   "$email",
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	}, buildDuplicateEmailsPipeline())
+}
+
+func TestBackfillMatchPairKeys(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("keys the matches stored without one", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + mt.Coll.Name()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, ns, mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: "m1"}, {Key: "profiles", Value: bson.A{"bob", "alice"}}},
+				bson.D{{Key: "_id", Value: "m2"}, {Key: "profiles", Value: bson.A{"alice", "bob"}}},
+			),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			// The pair matched twice; its later match stays without the key.
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11000, Message: "E11000 duplicate key error"}),
+		)
+
+		assert.NoError(mt, backfillMatchPairKeys(context.Background(), mt.Coll))
+
+		find := mt.GetStartedEvent().Command
+		assert.Equal(mt, "find", find.Index(0).Key())
+		assert.False(mt, find.Lookup("filter", "pair_key", "$exists").Boolean())
+		update := mt.GetStartedEvent().Command
+		assert.Equal(mt, "m1", update.Lookup("updates").Array().Index(0).Value().Document().Lookup("q", "_id").StringValue())
+		assert.Equal(mt, "alice:bob", update.Lookup("updates").Array().Index(0).Value().Document().Lookup("u", "$set", "pair_key").StringValue())
+	})
+
+	mt.Run("fails on any other error", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, mt.DB.Name()+"."+mt.Coll.Name(), mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: "m1"}, {Key: "profiles", Value: bson.A{"bob", "alice"}}},
+			),
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 91, Name: "ShutdownInProgress", Message: "shutting down"}),
+		)
+
+		assert.Error(mt, backfillMatchPairKeys(context.Background(), mt.Coll))
+	})
+}
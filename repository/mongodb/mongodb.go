@@ -1,7 +1,7 @@
 package mongodb
 
 import (
-	"api/config"
+	"api/constants"
 	"context"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -9,24 +9,38 @@ import (
 	"time"
 )
 
+const (
+	// emailUniqueIndexName is the name of the index that lets an email register only once.
+	emailUniqueIndexName = "email_unique"
+	// swipeUniqueIndexName is the name of the index that lets a user swipe on a prospect only once.
+	swipeUniqueIndexName = "user_prospect_unique"
+)
+
 type MongoStore struct {
 	client *mongo.Client
 	dbName string
 }
 
-func NewMongoConnection(connectURI, databaseName string) (*MongoStore, error) {
+func NewMongoConnection(connectURI, databaseName, appName string) (*MongoStore, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	opts := options.Client().ApplyURI(connectURI)
-	opts.SetAppName(config.ServiceName)
+	opts.SetAppName(appName)
 
 	client, err := mongo.Connect(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	err = createIndexes(ctx, client, databaseName)
+	// Migrating and building indexes over existing data can take longer than connecting, so
+	// neither is held to the connect timeout.
+	err = migrate(context.Background(), client, databaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = createIndexes(context.Background(), client, databaseName)
 	if err != nil {
 		return nil, err
 	}
@@ -35,13 +49,98 @@ func NewMongoConnection(connectURI, databaseName string) (*MongoStore, error) {
 }
 
 func createIndexes(ctx context.Context, client *mongo.Client, dbName string) error {
+	db := client.Database(dbName)
+
 	// Defining an index model for a 2dsphere index on the location field.
 	indexModel := mongo.IndexModel{
-		Keys:    bson.D{{"location", "2dsphere"}},
+		Keys:    bson.D{{Key: "location", Value: "2dsphere"}},
 		Options: options.Index().SetName("location_2dsphere"),
 	}
+	// An email can register only once.
+	emailIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetName(emailUniqueIndexName).SetUnique(true),
+	}
+	if _, err := db.Collection(constants.UserCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{indexModel, emailIndex}); err != nil {
+		return err
+	}
+
+	// A user can swipe on a prospect only once.
+	swipeIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "prospect_id", Value: 1}},
+		Options: options.Index().SetName(swipeUniqueIndexName).SetUnique(true),
+	}
+	// Serves a user's most recent swipe, for rewinds.
+	latestSwipeIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "swipe_time", Value: -1}},
+		Options: options.Index().SetName("user_swipe_time"),
+	}
+	// Serves the likes a user received, newest first.
+	receivedSwipeIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "prospect_id", Value: 1}, {Key: "swipe_time", Value: -1}},
+		Options: options.Index().SetName("prospect_swipe_time"),
+	}
+	swipeIndexes := []mongo.IndexModel{swipeIndex, latestSwipeIndex, receivedSwipeIndex}
+	if _, err := db.Collection(constants.SwipeCollection).Indexes().CreateMany(ctx, swipeIndexes); err != nil {
+		return err
+	}
+
+	if _, err := db.Collection(constants.MatchCollection).Indexes().CreateMany(ctx, matchIndexes()); err != nil {
+		return err
+	}
+
+	// A user can block another user only once. The index also serves blocks by their blocker.
+	blockIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "blocker_id", Value: 1}, {Key: "blocked_id", Value: 1}},
+		Options: options.Index().SetName("blocker_blocked_unique").SetUnique(true),
+	}
+	// Serves the blocks against a user, which hide them from their blockers.
+	blockedIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "blocked_id", Value: 1}},
+		Options: options.Index().SetName("blocked_id"),
+	}
+	if _, err := db.Collection(constants.BlockCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{blockIndex, blockedIndex}); err != nil {
+		return err
+	}
 
-	_, err := client.Database(dbName).Collection("users").Indexes().CreateOne(ctx, indexModel)
+	// Serves the moderation queue, oldest first, by state.
+	reportQueueIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "state", Value: 1}, {Key: "created_at", Value: 1}, {Key: "id", Value: 1}},
+		Options: options.Index().SetName("state_created_at"),
+	}
+	if _, err := db.Collection(constants.ReportCollection).Indexes().CreateOne(ctx, reportQueueIndex); err != nil {
+		return err
+	}
+
+	// Serves the audit trail of a report.
+	auditIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "report_id", Value: 1}, {Key: "created_at", Value: 1}},
+		Options: options.Index().SetName("report_created_at"),
+	}
+	// Serves the audit trail of a user.
+	userAuditIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "target_user_id", Value: 1}, {Key: "created_at", Value: 1}},
+		Options: options.Index().SetName("target_user_created_at"),
+	}
+	if _, err := db.Collection(constants.AuditCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{auditIndex, userAuditIndex}); err != nil {
+		return err
+	}
+
+	// Serves the messages of a match, newest first, and its unread counts.
+	messageIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "match_id", Value: 1}, {Key: "sent_at", Value: -1}, {Key: "id", Value: -1}},
+		Options: options.Index().SetName("match_sent_at"),
+	}
+	if _, err := db.Collection(constants.MessageCollection).Indexes().CreateOne(ctx, messageIndex); err != nil {
+		return err
+	}
+
+	// Serves a user's inbox, newest first.
+	notificationIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "id", Value: -1}},
+		Options: options.Index().SetName("user_created_at"),
+	}
+	_, err := db.Collection(constants.NotificationCollection).Indexes().CreateOne(ctx, notificationIndex)
 	return err
 }
 
@@ -49,3 +148,43 @@ func createIndexes(ctx context.Context, client *mongo.Client, dbName string) err
 func (conn *MongoStore) coll(name string) *mongo.Collection {
 	return conn.client.Database(conn.dbName).Collection(name)
 }
+
+// findOrInsert decodes into result the document of the collection that matches the filter, which it
+// first inserts as the given document if there is none. The filter must be covered by a unique
+// index: two upserts of a new document can both miss it, and the loser hits the index and reads the
+// winner's document instead.
+func findOrInsert(ctx context.Context, coll *mongo.Collection, filter bson.M, document interface{}, result interface{}) error {
+	err := coll.FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.M{"$setOnInsert": document},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(result)
+	if mongo.IsDuplicateKeyError(err) {
+		err = coll.FindOne(ctx, filter).Decode(result)
+	}
+	return err
+}
+
+// matchIndexes are the indexes of the matches collection.
+func matchIndexes() []mongo.IndexModel {
+	// A pair of profiles can match only once, whoever swiped first. Matches created before
+	// the pair key existed get it from migrate, except repeat matches of a pair, which are left
+	// out of the index.
+	matchIndex := mongo.IndexModel{
+		Keys: bson.D{{Key: "pair_key", Value: 1}},
+		Options: options.Index().SetName("pair_key_unique").SetUnique(true).
+			SetPartialFilterExpression(bson.M{"pair_key": bson.M{"$exists": true}}),
+	}
+	// Serves a user's matches, newest first.
+	matchListIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "profiles", Value: 1}, {Key: "matched_at", Value: -1}, {Key: "_id", Value: -1}},
+		Options: options.Index().SetName("profiles_matched_at"),
+	}
+	// Serves the expiry sweeper, which looks for the current matches quiet for the longest.
+	matchExpiryIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "matched", Value: 1}, {Key: "last_activity_at", Value: 1}},
+		Options: options.Index().SetName("matched_last_activity_at"),
+	}
+	return []mongo.IndexModel{matchIndex, matchListIndex, matchExpiryIndex}
+}
@@ -0,0 +1,76 @@
+package mongodb
+
+import (
+	"api/constants"
+	"api/models"
+	"api/repository"
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type notificationRepository struct {
+	mongo      *MongoStore
+	collection string
+}
+
+// CreateNotification stores a new notification.
+func (n notificationRepository) CreateNotification(ctx context.Context, payload *models.Notification) (*models.Notification, error) {
+	if _, err := n.mongo.coll(n.collection).InsertOne(ctx, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// ListNotifications returns one page of the notifications of a user, newest first.
+func (n notificationRepository) ListNotifications(ctx context.Context, userID string, after *models.NotificationCursor, limit int) ([]*models.Notification, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "id", Value: -1}}).
+		SetLimit(int64(limit))
+	cursor, err := n.mongo.coll(n.collection).Find(ctx, buildNotificationsQuery(userID, after), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []*models.Notification
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MarkNotificationsRead marks the unread notifications of a user with the given IDs, or all of
+// them when no IDs are given, as read, and returns how many it marked. IDs of notifications of
+// other users are ignored.
+func (n notificationRepository) MarkNotificationsRead(ctx context.Context, userID string, ids []string, at time.Time) (int64, error) {
+	query := bson.M{"user_id": userID, "read_at": bson.M{"$exists": false}}
+	if len(ids) > 0 {
+		query["id"] = bson.M{"$in": ids}
+	}
+	result, err := n.mongo.coll(n.collection).UpdateMany(ctx, query, bson.M{"$set": bson.M{"read_at": at}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// buildNotificationsQuery builds the query behind ListNotifications.
+func buildNotificationsQuery(userID string, after *models.NotificationCursor) bson.M {
+	query := bson.M{"user_id": userID}
+	if after != nil {
+		query["$or"] = []bson.M{
+			{"created_at": bson.M{"$lt": after.CreatedAt}},
+			{"created_at": after.CreatedAt, "id": bson.M{"$lt": after.ID}},
+		}
+	}
+	return query
+}
+
+func NewNotificationRepo(store *MongoStore) repository.NotificationRepository {
+	return &notificationRepository{
+		mongo:      store,
+		collection: constants.NotificationCollection,
+	}
+}
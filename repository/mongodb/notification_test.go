@@ -0,0 +1,22 @@
+package mongodb
+
+import (
+	"api/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+	"time"
+)
+
+func TestBuildNotificationsQuery(t *testing.T) {
+	createdAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, bson.M{"user_id": "alice"}, buildNotificationsQuery("alice", nil))
+	assert.Equal(t, bson.M{
+		"user_id": "alice",
+		"$or": []bson.M{
+			{"created_at": bson.M{"$lt": createdAt}},
+			{"created_at": createdAt, "id": bson.M{"$lt": "01hkz7pmjd"}},
+		},
+	}, buildNotificationsQuery("alice", &models.NotificationCursor{CreatedAt: createdAt, ID: "01hkz7pmjd"}))
+}
@@ -0,0 +1,119 @@
+package mongodb
+
+import (
+	"api/constants"
+	"api/models"
+	"api/repository"
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type reportRepository struct {
+	mongo      *MongoStore
+	collection string
+}
+
+// CreateReport stores a new report.
+func (r reportRepository) CreateReport(ctx context.Context, payload *models.Report) (*models.Report, error) {
+	if _, err := r.mongo.coll(r.collection).InsertOne(ctx, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// GetReportById returns a report by its ID, or nil when there is none.
+func (r reportRepository) GetReportById(ctx context.Context, id string) (*models.Report, error) {
+	var report models.Report
+	err := r.mongo.coll(r.collection).FindOne(ctx, bson.M{"id": id}).Decode(&report)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ListReports returns one page of the reports that match the filter, oldest first.
+func (r reportRepository) ListReports(ctx context.Context, filter models.ReportFilter, after *models.ReportCursor, limit int) ([]*models.Report, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "id", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.mongo.coll(r.collection).Find(ctx, buildReportsQuery(filter, after), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []*models.Report
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// buildReportsQuery builds the query behind ListReports.
+func buildReportsQuery(filter models.ReportFilter, after *models.ReportCursor) bson.M {
+	query := bson.M{}
+	if filter.State != nil {
+		query["state"] = *filter.State
+	}
+	if after != nil {
+		query["$or"] = []bson.M{
+			{"created_at": bson.M{"$gt": after.CreatedAt}},
+			{"created_at": after.CreatedAt, "id": bson.M{"$gt": after.ID}},
+		}
+	}
+	return query
+}
+
+// ClaimReport moves an open report to review by the given moderator. It returns nil when the
+// report is not open, so of two moderators claiming a report at once only one gets it.
+func (r reportRepository) ClaimReport(ctx context.Context, id, moderatorID string, at time.Time) (*models.Report, error) {
+	return r.transition(ctx, bson.M{"id": id, "state": models.OpenReport}, bson.M{
+		"state":      models.ReviewingReport,
+		"claimed_by": moderatorID,
+		"claimed_at": at,
+	})
+}
+
+// ResolveReport closes a report under review by the given moderator. It returns nil when the
+// report is not under review by them.
+func (r reportRepository) ResolveReport(ctx context.Context, id, moderatorID string, resolution models.ReportResolution, at time.Time) (*models.Report, error) {
+	return r.transition(ctx, bson.M{"id": id, "state": models.ReviewingReport, "claimed_by": moderatorID}, bson.M{
+		"state":       resolution.State,
+		"action":      resolution.Action,
+		"note":        resolution.Note,
+		"resolved_at": at,
+	})
+}
+
+// transition sets the given fields on the report that matches the filter and returns the updated
+// report, or nil when no report matches.
+func (r reportRepository) transition(ctx context.Context, filter, set bson.M) (*models.Report, error) {
+	var report models.Report
+	err := r.mongo.coll(r.collection).FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&report)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+func NewReportRepo(store *MongoStore) repository.ReportRepository {
+	return &reportRepository{
+		mongo:      store,
+		collection: constants.ReportCollection,
+	}
+}
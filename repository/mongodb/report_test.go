@@ -0,0 +1,49 @@
+package mongodb
+
+import (
+	"api/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+	"time"
+)
+
+func TestBuildReportsQuery(t *testing.T) {
+	createdAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	open := models.OpenReport
+
+	testCases := []struct {
+		name     string
+		filter   models.ReportFilter
+		after    *models.ReportCursor
+		expected bson.M
+	}{
+		{
+			name:     "whole queue",
+			expected: bson.M{},
+		},
+		{
+			name:     "open reports",
+			filter:   models.ReportFilter{State: &open},
+			expected: bson.M{"state": models.OpenReport},
+		},
+		{
+			name:   "next page, oldest first",
+			filter: models.ReportFilter{State: &open},
+			after:  &models.ReportCursor{CreatedAt: createdAt, ID: "01hkz7pmjd"},
+			expected: bson.M{
+				"state": models.OpenReport,
+				"$or": []bson.M{
+					{"created_at": bson.M{"$gt": createdAt}},
+					{"created_at": createdAt, "id": bson.M{"$gt": "01hkz7pmjd"}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, buildReportsQuery(tc.filter, tc.after))
+		})
+	}
+}
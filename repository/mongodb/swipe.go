@@ -8,6 +8,7 @@ import (
 	"errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type swipeRepository struct {
@@ -29,18 +30,140 @@ func (s swipeRepository) GetSwipeByUserAndProspect(ctx context.Context, userID,
 	return &swipe, nil
 }
 
-// CreateSwipe creates a new swipe in the database.
-func (s swipeRepository) CreateSwipe(ctx context.Context, payload *models.Swipe) (*models.Swipe, error) {
-	existingSwipe, err := s.GetSwipeByUserAndProspect(ctx, payload.UserID, payload.ProspectID)
+// GetLatestSwipe returns the most recent swipe of the given user, or nil when there is none.
+func (s swipeRepository) GetLatestSwipe(ctx context.Context, userID string) (*models.Swipe, error) {
+	var swipe models.Swipe
+	opts := options.FindOne().SetSort(bson.D{{Key: "swipe_time", Value: -1}})
+	err := s.mongo.coll(s.collection).FindOne(ctx, bson.M{"user_id": userID}, opts).Decode(&swipe)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &swipe, nil
+}
+
+// ListSwipes returns one page of the swipes that match the filter, newest first.
+func (s swipeRepository) ListSwipes(ctx context.Context, filter models.SwipeFilter, after *models.SwipeCursor, limit int) ([]*models.Swipe, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "swipe_time", Value: -1}, {Key: "id", Value: -1}}).
+		SetLimit(int64(limit))
+	cursor, err := s.mongo.coll(s.collection).Find(ctx, buildSwipesQuery(filter, after), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []*models.Swipe
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// buildSwipesQuery builds the query behind ListSwipes. The swipe date range is inclusive.
+func buildSwipesQuery(filter models.SwipeFilter, after *models.SwipeCursor) bson.M {
+	query := bson.M{"user_id": filter.UserID}
+	if filter.ProspectID != nil {
+		query["prospect_id"] = *filter.ProspectID
+	}
+	if filter.Interested != nil {
+		query["interested"] = *filter.Interested
+	}
+	swipeTime := bson.M{}
+	if !filter.MinSwipeDate.IsZero() {
+		swipeTime["$gte"] = filter.MinSwipeDate
+	}
+	if !filter.MaxSwipeDate.IsZero() {
+		swipeTime["$lte"] = filter.MaxSwipeDate
+	}
+	if len(swipeTime) > 0 {
+		query["swipe_time"] = swipeTime
+	}
+	if after != nil {
+		query["$or"] = []bson.M{
+			{"swipe_time": bson.M{"$lt": after.SwipeTime}},
+			{"swipe_time": after.SwipeTime, "id": bson.M{"$lt": after.ID}},
+		}
+	}
+	return query
+}
+
+// GetReceivedLikes returns one page of the likes the given user received from users they have
+// not swiped on yet, newest first, each with the user who liked.
+func (s swipeRepository) GetReceivedLikes(ctx context.Context, userID string, after *models.ReceivedLikeCursor, limit int) ([]*models.ReceivedLike, error) {
+	cursor, err := s.mongo.coll(s.collection).Aggregate(ctx, buildReceivedLikesPipeline(userID, after, limit))
 	if err != nil {
 		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	if existingSwipe != nil {
-		return nil, repository.ErrDuplicateFound
+	var result []*models.ReceivedLike
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, err
 	}
-	_, err = s.mongo.coll(s.collection).InsertOne(ctx, payload)
+	return result, nil
+}
+
+// buildReceivedLikesPipeline builds the aggregation pipeline behind GetReceivedLikes.
+func buildReceivedLikesPipeline(userID string, after *models.ReceivedLikeCursor, limit int) []bson.M {
+	pipeline := []bson.M{
+		{"$match": bson.M{"prospect_id": userID, "interested": true}},
+		// Likes the user already answered, with a like or a pass, are no longer pending.
+		{"$lookup": bson.M{
+			"from": constants.SwipeCollection,
+			"let":  bson.M{"likerId": "$user_id"},
+			"pipeline": []bson.M{{"$match": bson.M{"$expr": bson.M{"$and": []bson.M{
+				{"$eq": []interface{}{"$user_id", userID}},
+				{"$eq": []interface{}{"$prospect_id", "$$likerId"}},
+			}}}}},
+			"as": "answers",
+		}},
+		{"$match": bson.M{"answers": bson.M{"$eq": []interface{}{}}}},
+		// Likes from users the user blocked, or was blocked by, are hidden too.
+		lookupBlocksStage(userID, "$user_id"),
+		{"$match": bson.M{"blocks": bson.M{"$eq": []interface{}{}}}},
+		// So are likes from users who deactivated their account since.
+		lookupDeactivatedStage("$user_id"),
+		{"$match": bson.M{"deactivated": bson.M{"$eq": []interface{}{}}}},
+	}
+	if after != nil {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"$or": []bson.M{
+			{"swipe_time": bson.M{"$lt": after.LikedAt}},
+			{"swipe_time": after.LikedAt, "id": bson.M{"$lt": after.ID}},
+		}}})
+	}
+	return append(pipeline,
+		bson.M{"$sort": bson.D{{Key: "swipe_time", Value: -1}, {Key: "id", Value: -1}}},
+		bson.M{"$limit": limit},
+		// Users are looked up after the page is cut, so only the users of the page are read.
+		bson.M{"$lookup": bson.M{
+			"from":         constants.UserCollection,
+			"localField":   "user_id",
+			"foreignField": "id",
+			"as":           "user",
+		}},
+		bson.M{"$unwind": "$user"},
+		bson.M{"$project": bson.M{
+			"_id":      0,
+			"swipe_id": "$id",
+			"kind":     bson.M{"$ifNull": []interface{}{"$kind", models.LikeSwipe}},
+			"liked_at": "$swipe_time",
+			// Liking someone shows them who you are, not your contact details or account.
+			"user": publicProfile("$user"),
+		}},
+	)
+}
+
+// CreateSwipe creates a new swipe in the database. The unique user and prospect index turns a
+// second swipe on the same prospect into repository.ErrDuplicateFound, even when both race.
+func (s swipeRepository) CreateSwipe(ctx context.Context, payload *models.Swipe) (*models.Swipe, error) {
+	_, err := s.mongo.coll(s.collection).InsertOne(ctx, payload)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, repository.ErrDuplicateFound
+		}
 		return nil, err
 	}
 
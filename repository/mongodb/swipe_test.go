@@ -0,0 +1,128 @@
+package mongodb
+
+import (
+	"api/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"testing"
+	"time"
+)
+
+func TestBuildReceivedLikesPipeline_OnlyUnansweredLikes(t *testing.T) {
+	pipeline := buildReceivedLikesPipeline("alice", nil, 21)
+
+	assert.Equal(t, bson.M{"$match": bson.M{"prospect_id": "alice", "interested": true}}, pipeline[0])
+
+	answers := pipeline[1]["$lookup"].(bson.M)
+	assert.Equal(t, bson.M{"likerId": "$user_id"}, answers["let"])
+	assert.Equal(t, bson.M{"$match": bson.M{"answers": bson.M{"$eq": []interface{}{}}}}, pipeline[2])
+
+	// Likes across a block, in either direction, are hidden.
+	assert.Equal(t, lookupBlocksStage("alice", "$user_id"), pipeline[3])
+	assert.Equal(t, bson.M{"$match": bson.M{"blocks": bson.M{"$eq": []interface{}{}}}}, pipeline[4])
+
+	// So are likes from users who deactivated their account.
+	assert.Equal(t, lookupDeactivatedStage("$user_id"), pipeline[5])
+	assert.Equal(t, bson.M{"$match": bson.M{"deactivated": bson.M{"$eq": []interface{}{}}}}, pipeline[6])
+}
+
+func TestBuildReceivedLikesPipeline_Pagination(t *testing.T) {
+	likedAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	sortStage := bson.M{"$sort": bson.D{{Key: "swipe_time", Value: -1}, {Key: "id", Value: -1}}}
+
+	testCases := []struct {
+		name     string
+		after    *models.ReceivedLikeCursor
+		limit    int
+		expected []bson.M
+	}{
+		{
+			name:     "first page",
+			limit:    21,
+			expected: []bson.M{sortStage, {"$limit": 21}},
+		},
+		{
+			name:  "next page",
+			after: &models.ReceivedLikeCursor{LikedAt: likedAt, ID: "01hkz7pmjd"},
+			limit: 11,
+			expected: []bson.M{
+				{"$match": bson.M{"$or": []bson.M{
+					{"swipe_time": bson.M{"$lt": likedAt}},
+					{"swipe_time": likedAt, "id": bson.M{"$lt": "01hkz7pmjd"}},
+				}}},
+				sortStage,
+				{"$limit": 11},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pipeline := buildReceivedLikesPipeline("alice", tc.after, tc.limit)
+			// The page is cut before the likers are looked up.
+			lookupUsers := len(pipeline) - 3
+			assert.Equal(t, tc.expected, pipeline[7:lookupUsers])
+			assert.Equal(t, "user_id", pipeline[lookupUsers]["$lookup"].(bson.M)["localField"])
+		})
+	}
+}
+
+func TestBuildReceivedLikesPipeline_OnlyThePublicProfile(t *testing.T) {
+	pipeline := buildReceivedLikesPipeline("alice", nil, 21)
+	user := pipeline[len(pipeline)-1]["$project"].(bson.M)["user"].(bson.M)
+
+	assert.Equal(t, "$user.name", user["name"])
+	for _, private := range []string{"email", "password", "location", "date_of_birth", "timezone", "preferences",
+		"swipe_usage", "role", "status", "deactivation_reason", "suspended_until", "warned_at"} {
+		assert.NotContains(t, user, private)
+	}
+}
+
+func TestBuildSwipesQuery(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 17, 23, 59, 59, 0, time.UTC)
+	prospectID := "bob"
+	interested := false
+
+	testCases := []struct {
+		name     string
+		filter   models.SwipeFilter
+		after    *models.SwipeCursor
+		expected bson.M
+	}{
+		{
+			name:     "all swipes",
+			filter:   models.SwipeFilter{UserID: "alice"},
+			expected: bson.M{"user_id": "alice"},
+		},
+		{
+			name:   "passes on a prospect in a date range",
+			filter: models.SwipeFilter{UserID: "alice", ProspectID: &prospectID, Interested: &interested, MinSwipeDate: from, MaxSwipeDate: to},
+			expected: bson.M{
+				"user_id":     "alice",
+				"prospect_id": "bob",
+				"interested":  false,
+				"swipe_time":  bson.M{"$gte": from, "$lte": to},
+			},
+		},
+		{
+			name:   "next page from a date",
+			filter: models.SwipeFilter{UserID: "alice", MinSwipeDate: from},
+			after:  &models.SwipeCursor{SwipeTime: to, ID: "01hkz7pmjd"},
+			expected: bson.M{
+				"user_id":    "alice",
+				"swipe_time": bson.M{"$gte": from},
+				"$or": []bson.M{
+					{"swipe_time": bson.M{"$lt": to}},
+					{"swipe_time": to, "id": bson.M{"$lt": "01hkz7pmjd"}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, buildSwipesQuery(tc.filter, tc.after))
+		})
+	}
+}
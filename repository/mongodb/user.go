@@ -6,7 +6,11 @@ import (
 	"api/repository"
 	"api/utils"
 	"context"
+	"errors"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
 )
 
 type userRepository struct {
@@ -15,16 +19,12 @@ type userRepository struct {
 	jwtSecret  string
 }
 
-// Discover returns a list of users that match the given filter.
-func (u userRepository) Discover(ctx context.Context, filter models.UserFilter, user models.User) ([]*models.User, error) {
-	qb := NewDiscoverQueryBuilder(user, filter).
-		LookupSwipes().
-		MatchSwipesEmpty().
-		LookupSwipesCount().
-		Projection().
-		AgeFilter(filter.MinAge, filter.MaxAge)
+// Discover returns up to limit of the users that match the given filter, the candidates the user
+// is shown: super-likers of the user first, then the nearest.
+func (u userRepository) Discover(ctx context.Context, filter models.UserFilter, user models.User, limit int) ([]*models.User, error) {
+	pipeline := buildDiscoverPipeline(filter, user, limit, time.Now())
 
-	cursor, err := u.mongo.coll(u.collection).Aggregate(ctx, qb.Build())
+	cursor, err := u.mongo.coll(u.collection).Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
@@ -37,6 +37,27 @@ func (u userRepository) Discover(ctx context.Context, filter models.UserFilter,
 	return result, nil
 }
 
+// buildDiscoverPipeline builds the aggregation pipeline behind Discover, as of the given time. The
+// filters narrow the geospatial search, so only the users who pass them are looked up.
+func buildDiscoverPipeline(filter models.UserFilter, user models.User, limit int, now time.Time) []bson.M {
+	return NewDiscoverQueryBuilder(user, filter).
+		ExcludeUnavailable(now).
+		AgeFilter(filter.MinAge, filter.MaxAge, now).
+		HeightFilter(filter.MinHeight, filter.MaxHeight).
+		AttributesFilter(filter).
+		DealBreakers(filter.Preferences, now).
+		LookupSwipes(user.ID).
+		MatchSwipesEmpty(user.ID).
+		LookupMatches(user.ID).
+		MatchMatchesEmpty().
+		LookupBlocks(user.ID).
+		MatchBlocksEmpty().
+		LookupSuperLikes(user.ID).
+		Projection().
+		Nearest(limit).
+		Build()
+}
+
 // GetUserCount returns the total number of users in the database.
 func (u userRepository) GetUserCount(ctx context.Context) (int, error) {
 	count, err := u.mongo.coll(u.collection).CountDocuments(ctx, bson.M{})
@@ -60,25 +81,15 @@ func (u userRepository) InsertUsers(ctx context.Context, users []*models.User) e
 	return nil
 }
 
-// CreateUser creates a new user in the database.
+// CreateUser creates a new user in the database. The unique email index turns a second user with
+// the same email into repository.ErrDuplicateFound, even when both register at once.
 func (u userRepository) CreateUser(ctx context.Context, payload *models.User) (*models.User, error) {
-
-	filters := bson.M{
-		"$or": []bson.M{
-			{
-				"email": payload.Email,
-			},
-			// if we want to add more fields to check for duplicity, we can add them here.
-		},
-	}
-
-	var Profile models.User
-	if err := u.mongo.coll(u.collection).FindOne(ctx, filters).Decode(&Profile); err == nil {
-		return nil, repository.ErrDuplicateFound
-	}
 	payload.ID = utils.GenerateId()
 	_, err := u.mongo.coll(u.collection).InsertOne(ctx, payload)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, repository.ErrDuplicateFound
+		}
 		return nil, err
 	}
 	return payload, nil
@@ -94,14 +105,148 @@ func (u userRepository) GetUserByEmail(ctx context.Context, email string) (*mode
 	return u.GetProfileByField(ctx, "email", email)
 }
 
-// UpdateUser updates a user in the database.
-func (u userRepository) UpdateUser(ctx context.Context, payload *models.User) (*models.User, error) {
-	update := bson.M{"$set": payload}
-	_, err := u.mongo.coll(u.collection).UpdateOne(ctx, bson.M{"id": payload.ID}, update)
+// UpdateUser applies a partial update to a user and returns the updated user.
+// Only the non-nil fields of the update are written.
+func (u userRepository) UpdateUser(ctx context.Context, id string, update models.UserUpdate) (*models.User, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var profile models.User
+	err := u.mongo.coll(u.collection).FindOneAndUpdate(ctx, bson.M{"id": id}, bson.M{"$set": update}, opts).Decode(&profile)
 	if err != nil {
 		return nil, err
 	}
-	return payload, nil
+	return &profile, nil
+}
+
+// DeactivateUser deactivates a user on their own behalf and returns the updated user. It returns
+// nil, without updating, when a moderator deactivated the user, so the ban is never turned into
+// a deactivation the user can undo.
+func (u userRepository) DeactivateUser(ctx context.Context, id string) (*models.User, error) {
+	return u.setUnbannedStatus(ctx, id, models.Deactivated, models.SelfDeactivation)
+}
+
+// ReactivateUser reactivates a user and returns the updated user. It returns nil, without
+// updating, when a moderator deactivated the user, as only a moderator can undo that.
+func (u userRepository) ReactivateUser(ctx context.Context, id string) (*models.User, error) {
+	return u.setUnbannedStatus(ctx, id, models.Activated, "")
+}
+
+// setUnbannedStatus sets the status of a user whom no moderator deactivated and returns the
+// updated user, or nil if a moderator did.
+func (u userRepository) setUnbannedStatus(ctx context.Context, id string, status models.Status, reason models.DeactivationReason) (*models.User, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var profile models.User
+	err := u.mongo.coll(u.collection).FindOneAndUpdate(
+		ctx,
+		buildUnbannedUserQuery(id),
+		bson.M{"$set": bson.M{"status": status, "deactivation_reason": reason}},
+		opts,
+	).Decode(&profile)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// buildUnbannedUserQuery matches the user unless a moderator deactivated them. The check is in the
+// filter, so a ban that lands while the user deactivates or reactivates is never overwritten.
+func buildUnbannedUserQuery(id string) bson.M {
+	return bson.M{"id": id, "deactivation_reason": bson.M{"$ne": models.ModerationDeactivation}}
+}
+
+// UpdatePassword stores a new password hash and bumps the user's token version, which
+// revokes every token issued before the change.
+func (u userRepository) UpdatePassword(ctx context.Context, id, hashedPassword string) (*models.User, error) {
+	update := bson.M{
+		"$set": bson.M{"password": hashedPassword, "password_changed_at": time.Now()},
+		"$inc": bson.M{"token_version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var profile models.User
+	if err := u.mongo.coll(u.collection).FindOneAndUpdate(ctx, bson.M{"id": id}, update, opts).Decode(&profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// SetRole changes a user's role. It revokes the user's tokens, which carry their role, so the
+// user logs in again under the new role.
+func (u userRepository) SetRole(ctx context.Context, id string, role models.Role) (*models.User, error) {
+	update := bson.M{
+		"$set": bson.M{"role": role},
+		"$inc": bson.M{"token_version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var profile models.User
+	if err := u.mongo.coll(u.collection).FindOneAndUpdate(ctx, bson.M{"id": id}, update, opts).Decode(&profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// IncrementSwipeUsage counts one more like, pass or rewind for the user at the given time,
+// starting the given usage period afresh once the stored one has reset. It returns nil, without
+// counting, when the user already reached the limit for the period.
+func (u userRepository) IncrementSwipeUsage(ctx context.Context, id string, period models.SwipeUsage, at time.Time, counter models.SwipeCounter, limit int) (*models.User, error) {
+	_, err := u.mongo.coll(u.collection).UpdateOne(
+		ctx,
+		buildSwipeUsageResetQuery(id, at),
+		bson.M{"$set": bson.M{"swipe_usage": models.SwipeUsage{Day: period.Day, ResetsAt: period.ResetsAt}, "swipe_count": 0}},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The limit is checked in the filter, so concurrent swipes can never overshoot it.
+	field := swipeUsageField(counter)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var profile models.User
+	err = u.mongo.coll(u.collection).FindOneAndUpdate(
+		ctx,
+		bson.M{"id": id, "swipe_usage.resets_at": bson.M{"$gt": at}, field: bson.M{"$lt": limit}},
+		bson.M{"$inc": swipeUsageChange(counter, 1)},
+		opts,
+	).Decode(&profile)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// buildSwipeUsageResetQuery matches the user whose swipe usage has reset by the given time, or who
+// has none yet. The stored reset time decides, not the local date, which a time zone change moves.
+func buildSwipeUsageResetQuery(id string, at time.Time) bson.M {
+	return bson.M{"id": id, "swipe_usage.resets_at": bson.M{"$not": bson.M{"$gt": at}}}
+}
+
+// DecrementSwipeUsage gives back a like, pass or rewind counted by IncrementSwipeUsage on the given day.
+func (u userRepository) DecrementSwipeUsage(ctx context.Context, id, day string, counter models.SwipeCounter) error {
+	_, err := u.mongo.coll(u.collection).UpdateOne(
+		ctx,
+		bson.M{"id": id, "swipe_usage.day": day, swipeUsageField(counter): bson.M{"$gt": 0}},
+		bson.M{"$inc": swipeUsageChange(counter, -1)},
+	)
+	return err
+}
+
+// swipeUsageField returns the field of the given daily counter.
+func swipeUsageField(counter models.SwipeCounter) string {
+	return "swipe_usage." + string(counter)
+}
+
+// swipeUsageChange changes the given daily counter by delta. Swipes, unlike rewinds, also
+// change the user's swipe count for the day.
+func swipeUsageChange(counter models.SwipeCounter, delta int) bson.M {
+	change := bson.M{swipeUsageField(counter): delta}
+	if counter != models.RewindsCounter {
+		change["swipe_count"] = delta
+	}
+	return change
 }
 
 // GetProfileByField returns a user by the given field.
@@ -0,0 +1,56 @@
+package mongodb
+
+import (
+	"api/models"
+	"api/repository"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"testing"
+	"time"
+)
+
+func TestBuildSwipeUsageResetQuery(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	// Only the stored reset time decides: a user who moves to a time zone already on the next
+	// date keeps their usage until it is due to reset.
+	assert.Equal(t, bson.M{
+		"id":                    "alice",
+		"swipe_usage.resets_at": bson.M{"$not": bson.M{"$gt": now}},
+	}, buildSwipeUsageResetQuery("alice", now))
+}
+
+func TestBuildUnbannedUserQuery(t *testing.T) {
+	// A ban is never lifted by the user reactivating their account, nor overwritten by them
+	// deactivating it.
+	assert.Equal(t, bson.M{
+		"id":                  "alice",
+		"deactivation_reason": bson.M{"$ne": models.ModerationDeactivation},
+	}, buildUnbannedUserQuery("alice"))
+}
+
+func TestUserRepository_CreateUser(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("inserts the user", func(mt *mtest.T) {
+		repo := userRepository{mongo: &MongoStore{client: mt.Client, dbName: mt.DB.Name()}, collection: mt.Coll.Name()}
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		user, err := repo.CreateUser(context.Background(), &models.User{Email: "alice@example.com"})
+		assert.NoError(mt, err)
+		assert.NotEmpty(mt, user.ID)
+		// The email is not looked up first: the unique index alone rejects a second one.
+		assert.Equal(mt, "insert", mt.GetStartedEvent().CommandName)
+	})
+
+	mt.Run("reports a registered email as a duplicate", func(mt *mtest.T) {
+		repo := userRepository{mongo: &MongoStore{client: mt.Client, dbName: mt.DB.Name()}, collection: mt.Coll.Name()}
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11000, Message: "E11000 duplicate key error"}))
+
+		user, err := repo.CreateUser(context.Background(), &models.User{Email: "alice@example.com"})
+		assert.ErrorIs(mt, err, repository.ErrDuplicateFound)
+		assert.Nil(mt, user)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"github.com/olivere/elastic/v7"
+	"time"
 )
 
 var ErrDuplicateFound = errors.New("error: duplicate found")
@@ -14,15 +15,24 @@ type UserRepository interface {
 	CreateUser(ctx context.Context, payload *models.User) (*models.User, error)
 	GetUserById(ctx context.Context, id string) (*models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
-	UpdateUser(ctx context.Context, payload *models.User) (*models.User, error)
+	UpdateUser(ctx context.Context, id string, update models.UserUpdate) (*models.User, error)
+	DeactivateUser(ctx context.Context, id string) (*models.User, error)
+	ReactivateUser(ctx context.Context, id string) (*models.User, error)
+	UpdatePassword(ctx context.Context, id, hashedPassword string) (*models.User, error)
+	SetRole(ctx context.Context, id string, role models.Role) (*models.User, error)
 	GetUserCount(ctx context.Context) (int, error)
-	Discover(ctx context.Context, filter models.UserFilter, user models.User) ([]*models.User, error)
+	Discover(ctx context.Context, filter models.UserFilter, user models.User, limit int) ([]*models.User, error)
+	IncrementSwipeUsage(ctx context.Context, id string, period models.SwipeUsage, at time.Time, counter models.SwipeCounter, limit int) (*models.User, error)
+	DecrementSwipeUsage(ctx context.Context, id, day string, counter models.SwipeCounter) error
 }
 
 type SwipesRepository interface {
 	CreateSwipe(ctx context.Context, payload *models.Swipe) (*models.Swipe, error)
 	GetSwipeById(ctx context.Context, id string) (*models.Swipe, error)
 	GetSwipeByUserAndProspect(ctx context.Context, userID, prospectID string) (*models.Swipe, error)
+	GetLatestSwipe(ctx context.Context, userID string) (*models.Swipe, error)
+	ListSwipes(ctx context.Context, filter models.SwipeFilter, after *models.SwipeCursor, limit int) ([]*models.Swipe, error)
+	GetReceivedLikes(ctx context.Context, userID string, after *models.ReceivedLikeCursor, limit int) ([]*models.ReceivedLike, error)
 	UpdateSwipe(ctx context.Context, payload *models.Swipe) (*models.Swipe, error)
 	DeleteSwipe(ctx context.Context, id string) error
 }
@@ -30,9 +40,51 @@ type SwipesRepository interface {
 type MatchRepository interface {
 	CreateMatch(ctx context.Context, payload *models.Match) (*models.Match, error)
 	GetMatchById(ctx context.Context, id string) (*models.Match, error)
-	GetMatchesFiltered(ctx context.Context, filter models.MatchFilter) (*models.MatchedUserInfo, error)
+	GetMatchByProfiles(ctx context.Context, profiles []string) (*models.Match, error)
+	GetMatchesFiltered(ctx context.Context, filter models.MatchFilter, after *models.MatchCursor, limit int) ([]*models.MatchedUser, error)
 	UpdateMatch(ctx context.Context, payload *models.Match) (*models.Match, error)
+	EndMatch(ctx context.Context, id, userID string, at time.Time) (bool, error)
+	RecordActivity(ctx context.Context, id string, at time.Time) error
+	GetExpiredMatches(ctx context.Context, expireAfter time.Duration, now time.Time, limit int) ([]*models.Match, error)
+	GetMatchesToRemind(ctx context.Context, expireAfter, warnBefore time.Duration, now time.Time, limit int) ([]*models.Match, error)
+	ExpireMatch(ctx context.Context, id string, expireAfter time.Duration, at time.Time) (bool, error)
+	MarkExpiryReminded(ctx context.Context, id string, expireAfter time.Duration, expiresAt time.Time) (bool, error)
+	ExtendMatch(ctx context.Context, id, userID string, expireAfter, extension time.Duration) (*models.Match, error)
 	DeleteMatch(ctx context.Context, id string) error
+	DeleteQuietMatch(ctx context.Context, id string) (bool, error)
+}
+
+type MessageRepository interface {
+	CreateMessage(ctx context.Context, payload *models.Message) (*models.Message, error)
+	GetMessageById(ctx context.Context, id string) (*models.Message, error)
+	ListMessages(ctx context.Context, matchID string, after *models.MessageCursor, limit int) ([]*models.Message, error)
+	MarkDelivered(ctx context.Context, matchID, recipientID string, at time.Time) error
+	MarkRead(ctx context.Context, matchID, readerID string, upTo, at time.Time) (int64, error)
+}
+
+type NotificationRepository interface {
+	CreateNotification(ctx context.Context, payload *models.Notification) (*models.Notification, error)
+	ListNotifications(ctx context.Context, userID string, after *models.NotificationCursor, limit int) ([]*models.Notification, error)
+	MarkNotificationsRead(ctx context.Context, userID string, ids []string, at time.Time) (int64, error)
+}
+
+type BlockRepository interface {
+	CreateBlock(ctx context.Context, payload *models.Block) (*models.Block, error)
+	IsBlocked(ctx context.Context, userID, otherUserID string) (bool, error)
+}
+
+type ReportRepository interface {
+	CreateReport(ctx context.Context, payload *models.Report) (*models.Report, error)
+	GetReportById(ctx context.Context, id string) (*models.Report, error)
+	ListReports(ctx context.Context, filter models.ReportFilter, after *models.ReportCursor, limit int) ([]*models.Report, error)
+	ClaimReport(ctx context.Context, id, moderatorID string, at time.Time) (*models.Report, error)
+	ResolveReport(ctx context.Context, id, moderatorID string, resolution models.ReportResolution, at time.Time) (*models.Report, error)
+}
+
+type AuditRepository interface {
+	CreateAuditEntry(ctx context.Context, payload *models.AuditEntry) error
+	ListAuditEntries(ctx context.Context, reportID string) ([]*models.AuditEntry, error)
+	ListUserAuditEntries(ctx context.Context, userID string) ([]*models.AuditEntry, error)
 }
 
 type ElasticsearchRepository interface {
@@ -4,6 +4,7 @@ import (
 	"api/models"
 	"context"
 	"github.com/stretchr/testify/mock"
+	"time"
 )
 
 type MockUserRepository struct {
@@ -30,8 +31,28 @@ func (m *MockUserRepository) GetUserByEmail(ctx context.Context, email string) (
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) UpdateUser(ctx context.Context, payload *models.User) (*models.User, error) {
-	args := m.Called(ctx, payload)
+func (m *MockUserRepository) UpdateUser(ctx context.Context, id string, update models.UserUpdate) (*models.User, error) {
+	args := m.Called(ctx, id, update)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) DeactivateUser(ctx context.Context, id string) (*models.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) ReactivateUser(ctx context.Context, id string) (*models.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id, hashedPassword string) (*models.User, error) {
+	args := m.Called(ctx, id, hashedPassword)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) SetRole(ctx context.Context, id string, role models.Role) (*models.User, error) {
+	args := m.Called(ctx, id, role)
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
@@ -40,11 +61,21 @@ func (m *MockUserRepository) GetUserCount(ctx context.Context) (int, error) {
 	return args.Int(0), args.Error(1)
 }
 
-func (m *MockUserRepository) Discover(ctx context.Context, filter models.UserFilter, user models.User) ([]*models.User, error) {
-	args := m.Called(ctx, filter, user)
+func (m *MockUserRepository) Discover(ctx context.Context, filter models.UserFilter, user models.User, limit int) ([]*models.User, error) {
+	args := m.Called(ctx, filter, user, limit)
 	return args.Get(0).([]*models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) IncrementSwipeUsage(ctx context.Context, id string, period models.SwipeUsage, at time.Time, counter models.SwipeCounter, limit int) (*models.User, error) {
+	args := m.Called(ctx, id, period, at, counter, limit)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) DecrementSwipeUsage(ctx context.Context, id, day string, counter models.SwipeCounter) error {
+	args := m.Called(ctx, id, day, counter)
+	return args.Error(0)
+}
+
 type MockSwipeRepository struct {
 	mock.Mock
 }
@@ -54,6 +85,21 @@ func (m *MockSwipeRepository) GetSwipeByUserAndProspect(ctx context.Context, pro
 	return args.Get(0).(*models.Swipe), args.Error(1)
 }
 
+func (m *MockSwipeRepository) GetLatestSwipe(ctx context.Context, userID string) (*models.Swipe, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(*models.Swipe), args.Error(1)
+}
+
+func (m *MockSwipeRepository) ListSwipes(ctx context.Context, filter models.SwipeFilter, after *models.SwipeCursor, limit int) ([]*models.Swipe, error) {
+	args := m.Called(ctx, filter, after, limit)
+	return args.Get(0).([]*models.Swipe), args.Error(1)
+}
+
+func (m *MockSwipeRepository) GetReceivedLikes(ctx context.Context, userID string, after *models.ReceivedLikeCursor, limit int) ([]*models.ReceivedLike, error) {
+	args := m.Called(ctx, userID, after, limit)
+	return args.Get(0).([]*models.ReceivedLike), args.Error(1)
+}
+
 func (m *MockSwipeRepository) CreateSwipe(ctx context.Context, swipe *models.Swipe) (*models.Swipe, error) {
 	args := m.Called(ctx, swipe)
 	return args.Get(0).(*models.Swipe), args.Error(1)
@@ -88,9 +134,14 @@ func (m *MockMatchRepository) GetMatchById(ctx context.Context, id string) (*mod
 	return args.Get(0).(*models.Match), args.Error(1)
 }
 
-func (m *MockMatchRepository) GetMatchesFiltered(ctx context.Context, filter models.MatchFilter) (*models.MatchedUserInfo, error) {
-	args := m.Called(ctx, filter)
-	return args.Get(0).(*models.MatchedUserInfo), args.Error(1)
+func (m *MockMatchRepository) GetMatchByProfiles(ctx context.Context, profiles []string) (*models.Match, error) {
+	args := m.Called(ctx, profiles)
+	return args.Get(0).(*models.Match), args.Error(1)
+}
+
+func (m *MockMatchRepository) GetMatchesFiltered(ctx context.Context, filter models.MatchFilter, after *models.MatchCursor, limit int) ([]*models.MatchedUser, error) {
+	args := m.Called(ctx, filter, after, limit)
+	return args.Get(0).([]*models.MatchedUser), args.Error(1)
 }
 
 func (m *MockMatchRepository) UpdateMatch(ctx context.Context, payload *models.Match) (*models.Match, error) {
@@ -98,7 +149,157 @@ func (m *MockMatchRepository) UpdateMatch(ctx context.Context, payload *models.M
 	return args.Get(0).(*models.Match), args.Error(1)
 }
 
+func (m *MockMatchRepository) EndMatch(ctx context.Context, id, userID string, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, userID, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMatchRepository) RecordActivity(ctx context.Context, id string, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockMatchRepository) GetExpiredMatches(ctx context.Context, expireAfter time.Duration, now time.Time, limit int) ([]*models.Match, error) {
+	args := m.Called(ctx, expireAfter, now, limit)
+	return args.Get(0).([]*models.Match), args.Error(1)
+}
+
+func (m *MockMatchRepository) GetMatchesToRemind(ctx context.Context, expireAfter, warnBefore time.Duration, now time.Time, limit int) ([]*models.Match, error) {
+	args := m.Called(ctx, expireAfter, warnBefore, now, limit)
+	return args.Get(0).([]*models.Match), args.Error(1)
+}
+
+func (m *MockMatchRepository) ExpireMatch(ctx context.Context, id string, expireAfter time.Duration, at time.Time) (bool, error) {
+	args := m.Called(ctx, id, expireAfter, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMatchRepository) MarkExpiryReminded(ctx context.Context, id string, expireAfter time.Duration, expiresAt time.Time) (bool, error) {
+	args := m.Called(ctx, id, expireAfter, expiresAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMatchRepository) ExtendMatch(ctx context.Context, id, userID string, expireAfter, extension time.Duration) (*models.Match, error) {
+	args := m.Called(ctx, id, userID, expireAfter, extension)
+	return args.Get(0).(*models.Match), args.Error(1)
+}
+
 func (m *MockMatchRepository) DeleteMatch(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
+
+func (m *MockMatchRepository) DeleteQuietMatch(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockMessageRepository struct {
+	mock.Mock
+}
+
+func (m *MockMessageRepository) CreateMessage(ctx context.Context, payload *models.Message) (*models.Message, error) {
+	args := m.Called(ctx, payload)
+	return args.Get(0).(*models.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) GetMessageById(ctx context.Context, id string) (*models.Message, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*models.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) ListMessages(ctx context.Context, matchID string, after *models.MessageCursor, limit int) ([]*models.Message, error) {
+	args := m.Called(ctx, matchID, after, limit)
+	return args.Get(0).([]*models.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) MarkDelivered(ctx context.Context, matchID, recipientID string, at time.Time) error {
+	args := m.Called(ctx, matchID, recipientID, at)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) MarkRead(ctx context.Context, matchID, readerID string, upTo, at time.Time) (int64, error) {
+	args := m.Called(ctx, matchID, readerID, upTo, at)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepository) CreateNotification(ctx context.Context, payload *models.Notification) (*models.Notification, error) {
+	args := m.Called(ctx, payload)
+	return args.Get(0).(*models.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepository) ListNotifications(ctx context.Context, userID string, after *models.NotificationCursor, limit int) ([]*models.Notification, error) {
+	args := m.Called(ctx, userID, after, limit)
+	return args.Get(0).([]*models.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkNotificationsRead(ctx context.Context, userID string, ids []string, at time.Time) (int64, error) {
+	args := m.Called(ctx, userID, ids, at)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockBlockRepository struct {
+	mock.Mock
+}
+
+func (m *MockBlockRepository) CreateBlock(ctx context.Context, block *models.Block) (*models.Block, error) {
+	args := m.Called(ctx, block)
+	return args.Get(0).(*models.Block), args.Error(1)
+}
+
+func (m *MockBlockRepository) IsBlocked(ctx context.Context, userID, otherUserID string) (bool, error) {
+	args := m.Called(ctx, userID, otherUserID)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockReportRepository struct {
+	mock.Mock
+}
+
+func (m *MockReportRepository) CreateReport(ctx context.Context, report *models.Report) (*models.Report, error) {
+	args := m.Called(ctx, report)
+	return args.Get(0).(*models.Report), args.Error(1)
+}
+
+func (m *MockReportRepository) GetReportById(ctx context.Context, id string) (*models.Report, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*models.Report), args.Error(1)
+}
+
+func (m *MockReportRepository) ListReports(ctx context.Context, filter models.ReportFilter, after *models.ReportCursor, limit int) ([]*models.Report, error) {
+	args := m.Called(ctx, filter, after, limit)
+	return args.Get(0).([]*models.Report), args.Error(1)
+}
+
+func (m *MockReportRepository) ClaimReport(ctx context.Context, id, moderatorID string, at time.Time) (*models.Report, error) {
+	args := m.Called(ctx, id, moderatorID, at)
+	return args.Get(0).(*models.Report), args.Error(1)
+}
+
+func (m *MockReportRepository) ResolveReport(ctx context.Context, id, moderatorID string, resolution models.ReportResolution, at time.Time) (*models.Report, error) {
+	args := m.Called(ctx, id, moderatorID, resolution, at)
+	return args.Get(0).(*models.Report), args.Error(1)
+}
+
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepository) ListAuditEntries(ctx context.Context, reportID string) ([]*models.AuditEntry, error) {
+	args := m.Called(ctx, reportID)
+	return args.Get(0).([]*models.AuditEntry), args.Error(1)
+}
+
+func (m *MockAuditRepository) ListUserAuditEntries(ctx context.Context, userID string) ([]*models.AuditEntry, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*models.AuditEntry), args.Error(1)
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"api/controllers"
+	"api/models"
 	"github.com/go-chi/chi"
 	swaggerMiddleware "github.com/go-openapi/runtime/middleware"
 	"github.com/rs/cors"
@@ -20,6 +21,7 @@ func configureCORS() func(http.Handler) http.Handler {
 			http.MethodGet,
 			http.MethodPost,
 			http.MethodPut,
+			http.MethodPatch,
 			http.MethodDelete,
 		},
 	}).Handler
@@ -40,7 +42,47 @@ func ConfigureRoutes(router *chi.Mux, controller *controllers.Controller) {
 		r.Post("/user/create", controller.RegisterUser)
 		r.Post("/login", controller.LoginUser)
 		r.Get("/user", controller.GetUser)
+		r.Patch("/user", controller.UpdateUser)
+		r.Put("/user/password", controller.UpdatePassword)
+		r.Post("/user/deactivate", controller.DeactivateUser)
+		r.Post("/user/reactivate", controller.ReactivateUser)
+		r.Get("/user/preferences", controller.GetPreferences)
+		r.Put("/user/preferences", controller.UpdatePreferences)
 		r.Get("/discover", controller.DiscoverUsers)
+		r.Get("/events", controller.StreamEvents)
 		r.Post("/swipe", controller.SwipeUser)
+		r.Get("/swipe/quota", controller.GetSwipeQuota)
+		r.Post("/swipe/undo", controller.UndoSwipe)
+		r.Get("/swipes", controller.ListSwipes)
+		r.Get("/likes/received", controller.GetReceivedLikes)
+		r.Post("/likes/received/{id}/like", controller.LikeBack)
+		r.Get("/matches", controller.GetMatches)
+		r.Delete("/matches/{id}", controller.Unmatch)
+		r.Post("/matches/{id}/extend", controller.ExtendMatch)
+		r.Post("/matches/{id}/messages", controller.SendMessage)
+		r.Get("/matches/{id}/messages", controller.ListMessages)
+		r.Post("/matches/{id}/read", controller.MarkMessagesRead)
+		r.Post("/matches/{id}/typing", controller.SetTyping)
+		r.Get("/notifications", controller.ListNotifications)
+		r.Post("/notifications/read", controller.MarkNotificationsRead)
+		r.Post("/users/{id}/block", controller.BlockUser)
+		r.Post("/users/{id}/report", controller.ReportUser)
+		r.Route("/admin", func(r chi.Router) {
+			r.Group(func(r chi.Router) {
+				r.Use(controller.Middlewares.RequireRole(models.ModeratorRole, models.AdminRole))
+				r.Get("/reports", controller.ListReports)
+				r.Post("/reports/{id}/claim", controller.ClaimReport)
+				r.Post("/reports/{id}/resolve", controller.ResolveReport)
+				r.Get("/reports/{id}/audit", controller.GetReportAuditTrail)
+			})
+			r.Group(func(r chi.Router) {
+				r.Use(controller.Middlewares.RequireRole(models.AdminRole))
+				r.Get("/users/{id}", controller.GetUserAsAdmin)
+				r.Get("/users/{id}/audit", controller.GetUserAuditTrail)
+				r.Put("/users/{id}/status", controller.ChangeUserStatus)
+				r.Put("/users/{id}/role", controller.ChangeUserRole)
+				r.Post("/users/{id}/password-reset", controller.ResetUserPassword)
+			})
+		})
 	})
 }
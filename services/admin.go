@@ -0,0 +1,170 @@
+package services
+
+import (
+	"api/models"
+	"api/repository"
+	"api/utils"
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+var (
+	ErrCannotChangeOwnAccess = errors.New("you cannot change your own status or role")
+	ErrFailedChangeStatus    = errors.New("failed to change status")
+	ErrFailedChangeRole      = errors.New("failed to change role")
+	ErrFailedResetPassword   = errors.New("failed to reset password")
+)
+
+// AdminService lets admins look up any user and manage their status, role and password. Every
+// change is written to the moderation audit trail before it is applied.
+type AdminService struct {
+	logger          *logrus.Logger
+	userRepository  repository.UserRepository
+	auditRepository repository.AuditRepository
+	passwordPolicy  models.PasswordPolicy
+	now             func() time.Time
+}
+
+func NewAdminService(logger *logrus.Logger, userRepository repository.UserRepository,
+	auditRepository repository.AuditRepository, passwordPolicy models.PasswordPolicy) *AdminService {
+	return &AdminService{
+		logger:          logger,
+		userRepository:  userRepository,
+		auditRepository: auditRepository,
+		passwordPolicy:  passwordPolicy,
+		now:             time.Now,
+	}
+}
+
+// GetUser returns any user's profile.
+func (a *AdminService) GetUser(ctx context.Context, id string) (*models.User, error) {
+	user, err := a.userRepository.GetUserById(ctx, id)
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("failed to get user profile by ID")
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// GetUserAuditTrail returns every moderation step and admin change taken on a user, oldest first.
+func (a *AdminService) GetUserAuditTrail(ctx context.Context, id string) ([]*models.AuditEntry, error) {
+	if _, err := a.GetUser(ctx, id); err != nil {
+		return nil, err
+	}
+	entries, err := a.auditRepository.ListUserAuditEntries(ctx, id)
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error(ErrFailedGetAuditTrail)
+		return nil, ErrFailedGetAuditTrail
+	}
+	return entries, nil
+}
+
+// ChangeStatus activates or deactivates a user. A deactivation by an admin is a moderation
+// deactivation, which locks the user out until an admin activates them again.
+func (a *AdminService) ChangeStatus(ctx context.Context, admin models.User, id string, payload models.StatusPayload) (*models.User, error) {
+	if id == admin.ID {
+		return nil, ErrCannotChangeOwnAccess
+	}
+	if _, err := a.GetUser(ctx, id); err != nil {
+		return nil, err
+	}
+
+	var reason models.DeactivationReason
+	if payload.Status == models.Deactivated {
+		reason = models.ModerationDeactivation
+	}
+	if err := a.record(ctx, admin, id, models.StatusChangedEvent, string(payload.Status), payload.Note); err != nil {
+		return nil, ErrFailedChangeStatus
+	}
+	user, err := a.userRepository.UpdateUser(ctx, id, models.UserUpdate{Status: &payload.Status, DeactivationReason: &reason})
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error(ErrFailedChangeStatus)
+		return nil, ErrFailedChangeStatus
+	}
+	return user, nil
+}
+
+// ChangeRole gives a user another role. The user's tokens are revoked, so they log in again
+// under the new role.
+func (a *AdminService) ChangeRole(ctx context.Context, admin models.User, id string, payload models.RolePayload) (*models.User, error) {
+	if id == admin.ID {
+		return nil, ErrCannotChangeOwnAccess
+	}
+	if _, err := a.GetUser(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if err := a.record(ctx, admin, id, models.RoleChangedEvent, string(payload.Role), payload.Note); err != nil {
+		return nil, ErrFailedChangeRole
+	}
+	user, err := a.userRepository.SetRole(ctx, id, payload.Role)
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error(ErrFailedChangeRole)
+		return nil, ErrFailedChangeRole
+	}
+	return user, nil
+}
+
+// ResetPassword replaces a user's password with a random temporary password, revoking all of
+// their tokens, and returns the temporary password for the admin to pass on.
+func (a *AdminService) ResetPassword(ctx context.Context, admin models.User, id string) (*models.PasswordResetResponse, error) {
+	if _, err := a.GetUser(ctx, id); err != nil {
+		return nil, err
+	}
+
+	password, err := utils.GenerateTemporaryPassword(max(a.passwordPolicy.MinLength, 16))
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error("failed to generate temporary password")
+		return nil, ErrFailedResetPassword
+	}
+	if err := a.record(ctx, admin, id, models.PasswordResetEvent, "", ""); err != nil {
+		return nil, ErrFailedResetPassword
+	}
+	if _, err := a.userRepository.UpdatePassword(ctx, id, utils.EncryptPassword(password)); err != nil {
+		a.logger.WithContext(ctx).WithError(err).Error(ErrFailedResetPassword)
+		return nil, ErrFailedResetPassword
+	}
+	return &models.PasswordResetResponse{TemporaryPassword: password}, nil
+}
+
+// PromoteAdmins makes admins of the given users, so a fresh deployment has someone to manage it.
+// Unknown users are skipped.
+func (a *AdminService) PromoteAdmins(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		user, err := a.userRepository.GetUserById(ctx, id)
+		if err != nil {
+			a.logger.WithContext(ctx).WithError(err).WithField("user", id).Warn("cannot promote unknown user to admin")
+			continue
+		}
+		if user.Role == models.AdminRole {
+			continue
+		}
+		if err := a.record(ctx, models.User{}, id, models.RoleChangedEvent, string(models.AdminRole), "promoted to admin at startup"); err != nil {
+			return err
+		}
+		if _, err := a.userRepository.SetRole(ctx, id, models.AdminRole); err != nil {
+			return err
+		}
+		a.logger.WithContext(ctx).WithField("user", id).Info("promoted user to admin")
+	}
+	return nil
+}
+
+// record writes an admin change of a user to the audit trail.
+func (a *AdminService) record(ctx context.Context, admin models.User, targetID string, event models.AuditEvent, change, note string) error {
+	err := a.auditRepository.CreateAuditEntry(ctx, &models.AuditEntry{
+		ID:           utils.GenerateId(),
+		ModeratorID:  admin.ID,
+		TargetUserID: targetID,
+		Event:        event,
+		Change:       change,
+		Note:         note,
+		CreatedAt:    a.now(),
+	})
+	if err != nil {
+		a.logger.WithContext(ctx).WithError(err).WithField("event", event).Error("failed to audit admin action")
+	}
+	return err
+}
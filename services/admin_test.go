@@ -0,0 +1,141 @@
+package services
+
+import (
+	"api/models"
+	"api/repository"
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestAdminService_ChangeStatus(t *testing.T) {
+	changedAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	admin := models.User{ID: "admin", Role: models.AdminRole}
+	deactivated, activated := models.Deactivated, models.Activated
+	moderation, none := models.ModerationDeactivation, models.DeactivationReason("")
+
+	testCases := []struct {
+		name        string
+		targetID    string
+		payload     models.StatusPayload
+		update      *models.UserUpdate
+		auditErr    error
+		expectedErr error
+	}{
+		{
+			name:     "deactivate",
+			targetID: "bob",
+			payload:  models.StatusPayload{Status: models.Deactivated, Note: "repeat offender"},
+			update:   &models.UserUpdate{Status: &deactivated, DeactivationReason: &moderation},
+		},
+		{
+			name:     "activate clears the deactivation reason",
+			targetID: "bob",
+			payload:  models.StatusPayload{Status: models.Activated},
+			update:   &models.UserUpdate{Status: &activated, DeactivationReason: &none},
+		},
+		{
+			name:        "own account",
+			targetID:    "admin",
+			payload:     models.StatusPayload{Status: models.Deactivated},
+			expectedErr: ErrCannotChangeOwnAccess,
+		},
+		{
+			name:        "audit fails closed",
+			targetID:    "bob",
+			payload:     models.StatusPayload{Status: models.Deactivated},
+			auditErr:    errors.New("boom"),
+			expectedErr: ErrFailedChangeStatus,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(repository.MockUserRepository)
+			userRepo.On("GetUserById", mock.Anything, tc.targetID).Return(&models.User{ID: tc.targetID}, nil).Maybe()
+			userRepo.On("UpdateUser", mock.Anything, tc.targetID, mock.Anything).Return(&models.User{ID: tc.targetID}, nil).Maybe()
+			auditRepo := new(repository.MockAuditRepository)
+			auditRepo.On("CreateAuditEntry", mock.Anything, mock.Anything).Return(tc.auditErr).Maybe()
+			adminService := NewAdminService(logrus.New(), userRepo, auditRepo, testPasswordPolicy)
+			adminService.now = func() time.Time { return changedAt }
+
+			_, err := adminService.ChangeStatus(context.Background(), admin, tc.targetID, tc.payload)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				userRepo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			userRepo.AssertCalled(t, "UpdateUser", mock.Anything, "bob", *tc.update)
+			auditRepo.AssertCalled(t, "CreateAuditEntry", mock.Anything, mock.MatchedBy(func(entry *models.AuditEntry) bool {
+				return entry.Event == models.StatusChangedEvent && entry.ModeratorID == "admin" && entry.TargetUserID == "bob" &&
+					entry.Change == string(tc.payload.Status) && entry.Note == tc.payload.Note && entry.CreatedAt.Equal(changedAt)
+			}))
+		})
+	}
+}
+
+func TestAdminService_ChangeRole(t *testing.T) {
+	admin := models.User{ID: "admin", Role: models.AdminRole}
+
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("GetUserById", mock.Anything, "bob").Return(&models.User{ID: "bob"}, nil)
+	userRepo.On("SetRole", mock.Anything, "bob", models.ModeratorRole).Return(&models.User{ID: "bob", Role: models.ModeratorRole}, nil)
+	auditRepo := new(repository.MockAuditRepository)
+	auditRepo.On("CreateAuditEntry", mock.Anything, mock.Anything).Return(nil)
+	adminService := NewAdminService(logrus.New(), userRepo, auditRepo, testPasswordPolicy)
+
+	user, err := adminService.ChangeRole(context.Background(), admin, "bob", models.RolePayload{Role: models.ModeratorRole})
+	assert.NoError(t, err)
+	assert.Equal(t, models.ModeratorRole, user.Role)
+	auditRepo.AssertCalled(t, "CreateAuditEntry", mock.Anything, mock.MatchedBy(func(entry *models.AuditEntry) bool {
+		return entry.Event == models.RoleChangedEvent && entry.TargetUserID == "bob" && entry.Change == "moderator"
+	}))
+
+	// Admins cannot demote themselves and lock everyone out.
+	_, err = adminService.ChangeRole(context.Background(), admin, "admin", models.RolePayload{Role: models.UserRole})
+	assert.ErrorIs(t, err, ErrCannotChangeOwnAccess)
+}
+
+func TestAdminService_ResetPassword(t *testing.T) {
+	strictPolicy := models.PasswordPolicy{MinLength: 12, RequireUppercase: true, RequireLowercase: true, RequireDigit: true, RequireSymbol: true}
+	admin := models.User{ID: "admin", Role: models.AdminRole}
+
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("GetUserById", mock.Anything, "bob").Return(&models.User{ID: "bob"}, nil)
+	userRepo.On("UpdatePassword", mock.Anything, "bob", mock.AnythingOfType("string")).Return(&models.User{ID: "bob", TokenVersion: 1}, nil)
+	auditRepo := new(repository.MockAuditRepository)
+	auditRepo.On("CreateAuditEntry", mock.Anything, mock.Anything).Return(nil)
+	adminService := NewAdminService(logrus.New(), userRepo, auditRepo, strictPolicy)
+
+	response, err := adminService.ResetPassword(context.Background(), admin, "bob")
+	assert.NoError(t, err)
+	assert.Len(t, response.TemporaryPassword, 16)
+	assert.NoError(t, strictPolicy.Check(response.TemporaryPassword))
+	// Only the hash is stored.
+	userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, "bob", response.TemporaryPassword)
+	auditRepo.AssertCalled(t, "CreateAuditEntry", mock.Anything, mock.MatchedBy(func(entry *models.AuditEntry) bool {
+		return entry.Event == models.PasswordResetEvent && entry.TargetUserID == "bob"
+	}))
+}
+
+func TestAdminService_PromoteAdmins(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("GetUserById", mock.Anything, "alice").Return(&models.User{ID: "alice"}, nil)
+	userRepo.On("GetUserById", mock.Anything, "carol").Return(&models.User{ID: "carol", Role: models.AdminRole}, nil)
+	userRepo.On("GetUserById", mock.Anything, "ghost").Return((*models.User)(nil), errors.New("not found"))
+	userRepo.On("SetRole", mock.Anything, "alice", models.AdminRole).Return(&models.User{ID: "alice", Role: models.AdminRole}, nil)
+	auditRepo := new(repository.MockAuditRepository)
+	auditRepo.On("CreateAuditEntry", mock.Anything, mock.Anything).Return(nil)
+	adminService := NewAdminService(logrus.New(), userRepo, auditRepo, testPasswordPolicy)
+
+	err := adminService.PromoteAdmins(context.Background(), []string{"alice", "carol", "ghost"})
+	assert.NoError(t, err)
+	userRepo.AssertNumberOfCalls(t, "SetRole", 1)
+	auditRepo.AssertNumberOfCalls(t, "CreateAuditEntry", 1)
+}
@@ -0,0 +1,19 @@
+package services
+
+import (
+	"api/store"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+)
+
+// publishEvent publishes the JSON of data on the given topic. A failure is logged and not
+// returned: the change the event reports is already stored.
+func publishEvent(eventStore store.EventStore, logger *logrus.Logger, topic string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err == nil {
+		err = eventStore.Publish(topic, payload)
+	}
+	if err != nil {
+		logger.WithError(err).WithField("topic", topic).Warn("failed to publish event")
+	}
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"api/constants"
+	"api/models"
+	"api/store"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"sync"
+)
+
+// gatewayRecipients tells, for each topic the gateway pushes, which users an event concerns.
+var gatewayRecipients = map[string]func(data []byte) ([]string, error){
+	constants.MatchCreatedTopic:      matchRecipients,
+	constants.MatchExpiringSoonTopic: matchRecipients,
+	constants.MatchExpiredTopic:      matchRecipients,
+	// The user who unmatched already knows.
+	constants.MatchUnmatchedTopic: func(data []byte) ([]string, error) {
+		var event models.MatchEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, err
+		}
+		var recipients []string
+		for _, profile := range event.Profiles {
+			if profile != event.UserID {
+				recipients = append(recipients, profile)
+			}
+		}
+		return recipients, nil
+	},
+	constants.MessageSentTopic: func(data []byte) ([]string, error) {
+		var event models.MessageEvent
+		err := json.Unmarshal(data, &event)
+		return []string{event.RecipientID}, err
+	},
+	// Read receipts go to the participant whose messages were read.
+	constants.MessageReadTopic: func(data []byte) ([]string, error) {
+		var event models.ReadReceipt
+		err := json.Unmarshal(data, &event)
+		return []string{event.SenderID}, err
+	},
+	constants.TypingStartedTopic: typingRecipients,
+	constants.TypingStoppedTopic: typingRecipients,
+	constants.SwipeSuperLikedTopic: func(data []byte) ([]string, error) {
+		var event models.SwipeEvent
+		err := json.Unmarshal(data, &event)
+		return []string{event.ProspectID}, err
+	},
+}
+
+// matchRecipients sends match events to both participants.
+func matchRecipients(data []byte) ([]string, error) {
+	var event models.MatchEvent
+	err := json.Unmarshal(data, &event)
+	return event.Profiles, err
+}
+
+// typingRecipients sends typing indicators to the other participant of the match only.
+func typingRecipients(data []byte) ([]string, error) {
+	var event models.TypingEvent
+	err := json.Unmarshal(data, &event)
+	return []string{event.RecipientID}, err
+}
+
+// Gateway pushes events from the event store to the open connections of the users they concern.
+// A user can have several connections, one per device. Every connection has a bounded buffer:
+// a connection that falls behind is dropped rather than holding up the others, and its client
+// reconnects and catches up through the REST endpoints.
+type Gateway struct {
+	logger      *logrus.Logger
+	bufferSize  int
+	mu          sync.RWMutex
+	connections map[string]map[*GatewayConnection]struct{}
+	closed      bool
+}
+
+// GatewayConnection is one open connection of a user to the gateway.
+type GatewayConnection struct {
+	UserID string
+	events chan store.Event
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Events returns the events to push to the connection.
+func (c *GatewayConnection) Events() <-chan store.Event {
+	return c.events
+}
+
+// Done is closed when the gateway drops the connection, because it fell behind or the gateway
+// is shutting down.
+func (c *GatewayConnection) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *GatewayConnection) close() {
+	c.once.Do(func() { close(c.done) })
+}
+
+// NewGateway creates a gateway subscribed to every topic it pushes.
+func NewGateway(eventStore store.EventStore, logger *logrus.Logger) (*Gateway, error) {
+	g := &Gateway{
+		logger:      logger,
+		bufferSize:  constants.GatewayBufferSize,
+		connections: make(map[string]map[*GatewayConnection]struct{}),
+	}
+	// A stream that falls behind is dropped anyway, so the gateway can miss events as well.
+	for topic := range gatewayRecipients {
+		if err := eventStore.Subscribe(topic, g.deliver, store.DropWhenFull()); err != nil {
+			return nil, fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+		}
+	}
+	return g, nil
+}
+
+// Connect opens a connection for the user. It must be given back with Disconnect.
+func (g *Gateway) Connect(userID string) *GatewayConnection {
+	conn := &GatewayConnection{
+		UserID: userID,
+		events: make(chan store.Event, g.bufferSize),
+		done:   make(chan struct{}),
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		conn.close()
+		return conn
+	}
+	if g.connections[userID] == nil {
+		g.connections[userID] = make(map[*GatewayConnection]struct{})
+	}
+	g.connections[userID][conn] = struct{}{}
+	return conn
+}
+
+// Disconnect closes a connection and stops pushing events to it.
+func (g *Gateway) Disconnect(conn *GatewayConnection) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.remove(conn)
+}
+
+// Close drops every connection and refuses new ones, so the server can shut down.
+func (g *Gateway) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.closed = true
+	for _, conns := range g.connections {
+		for conn := range conns {
+			g.remove(conn)
+		}
+	}
+}
+
+// deliver pushes an event to the open connections of the users it concerns. It never blocks:
+// a connection whose buffer is full is dropped.
+func (g *Gateway) deliver(event store.Event) error {
+	recipients, err := gatewayRecipients[event.Topic()](event.Data())
+	if err != nil {
+		return fmt.Errorf("failed to read event: %w", err)
+	}
+
+	var behind []*GatewayConnection
+	g.mu.RLock()
+	for _, userID := range recipients {
+		for conn := range g.connections[userID] {
+			select {
+			case conn.events <- event:
+			default:
+				behind = append(behind, conn)
+			}
+		}
+	}
+	g.mu.RUnlock()
+
+	if len(behind) > 0 {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		for _, conn := range behind {
+			g.logger.WithField("user", conn.UserID).Warn("dropping event stream that fell behind")
+			g.remove(conn)
+		}
+	}
+	return nil
+}
+
+// remove closes a connection and forgets it. The caller holds the write lock.
+func (g *Gateway) remove(conn *GatewayConnection) {
+	conn.close()
+	conns := g.connections[conn.UserID]
+	delete(conns, conn)
+	if len(conns) == 0 {
+		delete(g.connections, conn.UserID)
+	}
+}
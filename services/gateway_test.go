@@ -0,0 +1,108 @@
+package services
+
+import (
+	"api/constants"
+	"api/models"
+	"api/store"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func publishTestEvent(t *testing.T, eventStore store.EventStore, topic string, data interface{}) {
+	payload, err := json.Marshal(data)
+	assert.NoError(t, err)
+	assert.NoError(t, eventStore.Publish(topic, payload))
+}
+
+func TestGateway_PushesEventsToTheUsersConcerned(t *testing.T) {
+	testCases := []struct {
+		name       string
+		topic      string
+		data       interface{}
+		recipients []string
+	}{
+		{name: "match created", topic: constants.MatchCreatedTopic, data: models.MatchEvent{MatchID: "m1", Profiles: []string{"alice", "bob"}}, recipients: []string{"alice", "bob"}},
+		{name: "match unmatched", topic: constants.MatchUnmatchedTopic, data: models.MatchEvent{MatchID: "m1", Profiles: []string{"alice", "bob"}, UserID: "alice"}, recipients: []string{"bob"}},
+		{name: "match expired", topic: constants.MatchExpiredTopic, data: models.MatchEvent{MatchID: "m1", Profiles: []string{"alice", "bob"}}, recipients: []string{"alice", "bob"}},
+		{name: "message sent", topic: constants.MessageSentTopic, data: models.MessageEvent{MessageID: "msg1", SenderID: "alice", RecipientID: "bob"}, recipients: []string{"bob"}},
+		{name: "message read", topic: constants.MessageReadTopic, data: models.ReadReceipt{MatchID: "m1", ReaderID: "bob", SenderID: "alice"}, recipients: []string{"alice"}},
+		{name: "typing", topic: constants.TypingStartedTopic, data: models.TypingEvent{MatchID: "m1", UserID: "alice", RecipientID: "bob"}, recipients: []string{"bob"}},
+		{name: "super-liked", topic: constants.SwipeSuperLikedTopic, data: models.SwipeEvent{SwipeID: "s1", UserID: "bob", ProspectID: "alice"}, recipients: []string{"alice"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			eventStore := store.NewEventStore(logrus.New())
+			gateway, err := NewGateway(eventStore, logrus.New())
+			assert.NoError(t, err)
+
+			conns := map[string]*GatewayConnection{}
+			for _, userID := range []string{"alice", "bob", "carol"} {
+				conns[userID] = gateway.Connect(userID)
+				defer gateway.Disconnect(conns[userID])
+			}
+
+			publishTestEvent(t, eventStore, tc.topic, tc.data)
+
+			for _, userID := range tc.recipients {
+				select {
+				case event := <-conns[userID].Events():
+					assert.Equal(t, tc.topic, event.Topic())
+				case <-time.After(time.Second):
+					t.Fatalf("%s got no %s event", userID, tc.topic)
+				}
+			}
+			// Anyone else gets nothing; a recipient would have been served by now.
+			for userID, conn := range conns {
+				assert.Empty(t, conn.Events(), "%s got an event that does not concern them", userID)
+			}
+		})
+	}
+}
+
+func TestGateway_DropsConnectionsThatFallBehind(t *testing.T) {
+	eventStore := store.NewEventStore(logrus.New())
+	gateway, err := NewGateway(eventStore, logrus.New())
+	assert.NoError(t, err)
+	gateway.bufferSize = 1
+
+	slow := gateway.Connect("alice")
+	other := gateway.Connect("bob")
+	defer gateway.Disconnect(other)
+
+	for i := 0; i < 2; i++ {
+		publishTestEvent(t, eventStore, constants.SwipeSuperLikedTopic, models.SwipeEvent{ProspectID: "alice"})
+	}
+
+	select {
+	case <-slow.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the connection that fell behind was not dropped")
+	}
+	// Other connections are not held up.
+	select {
+	case <-other.Done():
+		t.Fatal("a connection that kept up was dropped")
+	default:
+	}
+	gateway.Disconnect(slow)
+}
+
+func TestGateway_Close(t *testing.T) {
+	gateway, err := NewGateway(store.NewEventStore(logrus.New()), logrus.New())
+	assert.NoError(t, err)
+
+	conn := gateway.Connect("alice")
+	gateway.Close()
+
+	for _, conn := range []*GatewayConnection{conn, gateway.Connect("bob")} {
+		select {
+		case <-conn.Done():
+		default:
+			t.Fatal("connection left open after the gateway closed")
+		}
+	}
+}
@@ -1,21 +1,231 @@
 package services
 
 import (
+	"api/constants"
+	"api/models"
 	"api/repository"
 	"api/store"
+	"api/utils"
+	"context"
+	"errors"
 	"github.com/sirupsen/logrus"
+	"slices"
+	"time"
+)
+
+var (
+	ErrFailedGetMatches = errors.New("failed to get matches")
+	ErrMatchNotFound    = errors.New("match not found")
+	ErrFailedUnmatch    = errors.New("failed to unmatch")
+
+	ErrMatchExpiryDisabled  = errors.New("matches do not expire")
+	ErrMatchAlreadyExtended = errors.New("you already extended this match")
+	ErrFailedExtendMatch    = errors.New("failed to extend match")
+	ErrFailedSweepMatches   = errors.New("failed to sweep expired matches")
 )
 
 type MatchService struct {
 	eventStore      store.EventStore
 	logger          *logrus.Logger
 	matchRepository repository.MatchRepository
+	expiry          models.MatchExpiryPolicy
+	now             func() time.Time
 }
 
-func NewMatchService(eventStore store.EventStore, logger *logrus.Logger, matchRepository repository.MatchRepository) *MatchService {
+func NewMatchService(eventStore store.EventStore, logger *logrus.Logger, matchRepository repository.MatchRepository, expiry models.MatchExpiryPolicy) *MatchService {
 	return &MatchService{
 		eventStore:      eventStore,
 		logger:          logger,
 		matchRepository: matchRepository,
+		expiry:          expiry,
+		now:             time.Now,
+	}
+}
+
+// GetMatches returns one page of the user's matches, newest first, each with the other party
+// of the match and, when matches expire, its expiry, and the cursor of the next page, which is
+// empty on the last page.
+func (m *MatchService) GetMatches(ctx context.Context, user models.User, page models.Page) ([]*models.MatchedUser, string, error) {
+	filter := models.MatchFilter{UserID: user.ID, ExpireAfter: m.expiry.ExpireAfter}
+	matches, nextCursor, err := utils.FetchPage(page, func(after *models.MatchCursor, limit int) ([]*models.MatchedUser, error) {
+		return m.matchRepository.GetMatchesFiltered(ctx, filter, after, limit)
+	}, func(last *models.MatchedUser) models.MatchCursor {
+		return models.MatchCursor{MatchedAt: last.MatchedAt, ID: last.MatchID}
+	})
+	if errors.Is(err, ErrInvalidCursor) {
+		return nil, "", err
+	}
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error(ErrFailedGetMatches)
+		return nil, "", ErrFailedGetMatches
+	}
+	return matches, nextCursor, nil
+}
+
+// Unmatch ends a match on behalf of either participant and publishes a match.unmatched event.
+// The match is kept, no longer matched, so the pair is never shown to each other again.
+func (m *MatchService) Unmatch(ctx context.Context, user models.User, matchID string) (*models.Match, error) {
+	match, err := m.matchRepository.GetMatchById(ctx, matchID)
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error(ErrFailedUnmatch)
+		return nil, ErrFailedUnmatch
+	}
+	if !ownMatch(match, user) || !match.Matched {
+		return nil, ErrMatchNotFound
+	}
+
+	unmatchedAt := m.now()
+	ended, err := m.matchRepository.EndMatch(ctx, match.ID, user.ID, unmatchedAt)
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error(ErrFailedUnmatch)
+		return nil, ErrFailedUnmatch
+	}
+	// The match was ended by the other participant, a block or the sweeper since it was fetched.
+	if !ended {
+		return nil, ErrMatchNotFound
+	}
+	match.Matched = false
+	match.UnmatchedAt = unmatchedAt
+	match.UnmatchedBy = user.ID
+
+	publishEvent(m.eventStore, m.logger, constants.MatchUnmatchedTopic, models.MatchEvent{
+		MatchID:  match.ID,
+		Profiles: match.Profiles,
+		UserID:   user.ID,
+		At:       match.UnmatchedAt,
+	})
+	return match, nil
+}
+
+// ownMatch reports whether the match exists and the user takes part in it. Matches of other users
+// are reported as missing, not as forbidden, so their IDs leak nothing.
+func ownMatch(match *models.Match, user models.User) bool {
+	return match != nil && match.HasProfile(user.ID)
+}
+
+// Extend pushes the expiry of a match back on behalf of one of its participants, which each of them
+// can do once.
+func (m *MatchService) Extend(ctx context.Context, user models.User, matchID string) (*models.MatchExpiry, error) {
+	if !m.expiry.Enabled() {
+		return nil, ErrMatchExpiryDisabled
+	}
+	match, err := m.matchRepository.GetMatchById(ctx, matchID)
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error(ErrFailedExtendMatch)
+		return nil, ErrFailedExtendMatch
+	}
+	if !ownMatch(match, user) || !match.Matched || m.expiry.Expired(*match, m.now()) {
+		return nil, ErrMatchNotFound
+	}
+	if slices.Contains(match.ExtendedBy, user.ID) {
+		return nil, ErrMatchAlreadyExtended
+	}
+
+	extended, err := m.matchRepository.ExtendMatch(ctx, match.ID, user.ID, m.expiry.ExpireAfter, m.expiry.Extension)
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error(ErrFailedExtendMatch)
+		return nil, ErrFailedExtendMatch
+	}
+	// The match changed since it was read: it ended, or the user extended it from another device.
+	if extended == nil {
+		return nil, ErrMatchAlreadyExtended
+	}
+	return &models.MatchExpiry{
+		MatchID:    extended.ID,
+		ExpiresAt:  extended.ExpiresAt(m.expiry.ExpireAfter),
+		ExtendedBy: extended.ExtendedBy,
+	}, nil
+}
+
+// RunExpirySweeper sweeps matches for expiry every sweep interval until the context is done. It
+// returns at once when matches do not expire.
+func (m *MatchService) RunExpirySweeper(ctx context.Context) {
+	if !m.expiry.Enabled() {
+		return
+	}
+	if m.expiry.SweepInterval <= 0 {
+		m.logger.Warn("match expiry sweeper not started: the sweep interval must be positive")
+		return
+	}
+	ticker := time.NewTicker(m.expiry.SweepInterval)
+	defer ticker.Stop()
+	for {
+		if err := m.SweepExpiredMatches(ctx); err != nil && ctx.Err() == nil {
+			m.logger.WithError(err).Error(ErrFailedSweepMatches)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SweepExpiredMatches ends the matches that expired, publishing a match.expired event for each,
+// and publishes a match.expiring_soon event for each match about to expire, once per expiry.
+// Every match is claimed with a conditional update before its event is published, so sweepers
+// running side by side never publish an event twice.
+func (m *MatchService) SweepExpiredMatches(ctx context.Context) error {
+	now := m.now()
+	for {
+		matches, err := m.matchRepository.GetExpiredMatches(ctx, m.expiry.ExpireAfter, now, constants.MatchSweepBatchSize)
+		if err != nil {
+			return err
+		}
+		expired := 0
+		for _, match := range matches {
+			ok, err := m.matchRepository.ExpireMatch(ctx, match.ID, m.expiry.ExpireAfter, now)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			expired++
+			expiresAt := match.ExpiresAt(m.expiry.ExpireAfter)
+			publishEvent(m.eventStore, m.logger, constants.MatchExpiredTopic, models.MatchEvent{
+				MatchID:   match.ID,
+				Profiles:  match.Profiles,
+				At:        now,
+				ExpiresAt: &expiresAt,
+			})
+		}
+		// Stop once a batch is short, or when nothing in it could be claimed, so a match that
+		// keeps failing its update does not keep the sweep going.
+		if len(matches) < constants.MatchSweepBatchSize || expired == 0 {
+			break
+		}
+	}
+
+	if m.expiry.WarnBefore <= 0 {
+		return nil
+	}
+	for {
+		matches, err := m.matchRepository.GetMatchesToRemind(ctx, m.expiry.ExpireAfter, m.expiry.WarnBefore, now, constants.MatchSweepBatchSize)
+		if err != nil {
+			return err
+		}
+		reminded := 0
+		for _, match := range matches {
+			expiresAt := match.ExpiresAt(m.expiry.ExpireAfter)
+			ok, err := m.matchRepository.MarkExpiryReminded(ctx, match.ID, m.expiry.ExpireAfter, expiresAt)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			reminded++
+			publishEvent(m.eventStore, m.logger, constants.MatchExpiringSoonTopic, models.MatchEvent{
+				MatchID:   match.ID,
+				Profiles:  match.Profiles,
+				At:        now,
+				ExpiresAt: &expiresAt,
+			})
+		}
+		if len(matches) < constants.MatchSweepBatchSize || reminded == 0 {
+			break
+		}
 	}
+	return nil
 }
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"api/constants"
+	"api/models"
+	"api/repository"
+	"api/store"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestMatchService_GetMatches_Pagination(t *testing.T) {
+	matchRepo := new(repository.MockMatchRepository)
+	matchService := NewMatchService(store.NewEventStore(logrus.New()), logrus.New(), matchRepo, models.MatchExpiryPolicy{})
+
+	newest := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	matches := []*models.MatchedUser{
+		{MatchID: "m3", MatchedAt: newest, User: &models.User{ID: "carol"}},
+		{MatchID: "m2", MatchedAt: newest.Add(-time.Hour), User: &models.User{ID: "bob"}},
+		{MatchID: "m1", MatchedAt: newest.Add(-2 * time.Hour), User: &models.User{ID: "dave"}},
+	}
+	filter := models.MatchFilter{UserID: "alice"}
+	matchRepo.On("GetMatchesFiltered", mock.Anything, filter, (*models.MatchCursor)(nil), 3).Return(matches, nil)
+
+	page, nextCursor, err := matchService.GetMatches(context.Background(), models.User{ID: "alice"}, models.Page{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, matches[:2], page)
+
+	var cursor models.MatchCursor
+	assert.NoError(t, utils.DecodeCursor(nextCursor, &cursor))
+	assert.Equal(t, "m2", cursor.ID)
+	assert.True(t, cursor.MatchedAt.Equal(matches[1].MatchedAt))
+
+	matchRepo.On("GetMatchesFiltered", mock.Anything, filter, &cursor, 3).Return(matches[2:], nil)
+	page, nextCursor, err = matchService.GetMatches(context.Background(), models.User{ID: "alice"}, models.Page{Limit: 2, Cursor: nextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, matches[2:], page)
+	assert.Empty(t, nextCursor)
+}
+
+func TestMatchService_GetMatches_InvalidCursor(t *testing.T) {
+	matchService := NewMatchService(store.NewEventStore(logrus.New()), logrus.New(), new(repository.MockMatchRepository), models.MatchExpiryPolicy{})
+
+	_, _, err := matchService.GetMatches(context.Background(), models.User{ID: "alice"}, models.Page{Cursor: "not a cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestMatchService_Unmatch(t *testing.T) {
+	unmatchedAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name        string
+		userID      string
+		match       *models.Match
+		endedBefore bool
+		expectedErr error
+	}{
+		{name: "first participant", userID: "alice", match: &models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true}},
+		{name: "second participant", userID: "bob", match: &models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true}},
+		{name: "someone else's match", userID: "carol", match: &models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true}, expectedErr: ErrMatchNotFound},
+		{name: "already unmatched", userID: "alice", match: &models.Match{ID: "m1", Profiles: []string{"alice", "bob"}}, expectedErr: ErrMatchNotFound},
+		{name: "unmatched concurrently", userID: "alice", match: &models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true}, endedBefore: true, expectedErr: ErrMatchNotFound},
+		{name: "unknown match", userID: "alice", expectedErr: ErrMatchNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			eventStore := store.NewEventStore(logrus.New())
+			events := make(chan models.MatchEvent, 1)
+			assert.NoError(t, eventStore.Subscribe(constants.MatchUnmatchedTopic, func(event store.Event) error {
+				var data models.MatchEvent
+				assert.NoError(t, json.Unmarshal(event.Data(), &data))
+				events <- data
+				return nil
+			}))
+
+			matchRepo := new(repository.MockMatchRepository)
+			matchRepo.On("GetMatchById", mock.Anything, "m1").Return(tc.match, nil)
+			matchRepo.On("EndMatch", mock.Anything, "m1", tc.userID, unmatchedAt).Return(!tc.endedBefore, nil).Maybe()
+			matchService := NewMatchService(eventStore, logrus.New(), matchRepo, models.MatchExpiryPolicy{})
+			matchService.now = func() time.Time { return unmatchedAt }
+
+			match, err := matchService.Unmatch(context.Background(), models.User{ID: tc.userID}, "m1")
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Empty(t, events)
+				return
+			}
+			assert.NoError(t, err)
+			assert.False(t, match.Matched)
+			assert.Equal(t, tc.userID, match.UnmatchedBy)
+			assert.Equal(t, unmatchedAt, match.UnmatchedAt)
+			select {
+			case event := <-events:
+				assert.Equal(t, models.MatchEvent{MatchID: "m1", Profiles: []string{"alice", "bob"}, UserID: tc.userID, At: unmatchedAt}, event)
+			case <-time.After(time.Second):
+				t.Fatal("no match.unmatched event published")
+			}
+		})
+	}
+}
+
+var testExpiry = models.MatchExpiryPolicy{
+	ExpireAfter:   7 * 24 * time.Hour,
+	Extension:     24 * time.Hour,
+	WarnBefore:    24 * time.Hour,
+	SweepInterval: time.Minute,
+}
+
+func TestMatchService_Extend(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	// Expires in a day.
+	quiet := &models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true, LastActivityAt: now.Add(-6 * 24 * time.Hour)}
+
+	testCases := []struct {
+		name        string
+		userID      string
+		match       *models.Match
+		policy      models.MatchExpiryPolicy
+		extended    *models.Match
+		expectedErr error
+	}{
+		{
+			name: "first extension", userID: "alice", match: quiet, policy: testExpiry,
+			extended: &models.Match{ID: "m1", Matched: true, LastActivityAt: quiet.LastActivityAt, ExtendedUntil: now.Add(48 * time.Hour), ExtendedBy: []string{"alice"}},
+		},
+		{
+			name: "other participant extends too", userID: "bob", policy: testExpiry,
+			match:    &models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true, LastActivityAt: quiet.LastActivityAt, ExtendedUntil: now.Add(48 * time.Hour), ExtendedBy: []string{"alice"}},
+			extended: &models.Match{ID: "m1", Matched: true, LastActivityAt: quiet.LastActivityAt, ExtendedUntil: now.Add(72 * time.Hour), ExtendedBy: []string{"alice", "bob"}},
+		},
+		{
+			name: "second extension", userID: "alice", policy: testExpiry, expectedErr: ErrMatchAlreadyExtended,
+			match: &models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true, LastActivityAt: quiet.LastActivityAt, ExtendedUntil: now.Add(48 * time.Hour), ExtendedBy: []string{"alice"}},
+		},
+		{name: "extended meanwhile", userID: "alice", match: quiet, policy: testExpiry, expectedErr: ErrMatchAlreadyExtended},
+		{
+			name: "past its expiry", userID: "alice", policy: testExpiry, expectedErr: ErrMatchNotFound,
+			match: &models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true, LastActivityAt: now.Add(-8 * 24 * time.Hour)},
+		},
+		{name: "someone else's match", userID: "carol", match: quiet, policy: testExpiry, expectedErr: ErrMatchNotFound},
+		{name: "expiry off", userID: "alice", match: quiet, expectedErr: ErrMatchExpiryDisabled},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matchRepo := new(repository.MockMatchRepository)
+			matchRepo.On("GetMatchById", mock.Anything, "m1").Return(tc.match, nil)
+			matchRepo.On("ExtendMatch", mock.Anything, "m1", tc.userID, testExpiry.ExpireAfter, testExpiry.Extension).Return(tc.extended, nil).Maybe()
+			matchService := NewMatchService(store.NewEventStore(logrus.New()), logrus.New(), matchRepo, tc.policy)
+			matchService.now = func() time.Time { return now }
+
+			expiry, err := matchService.Extend(context.Background(), models.User{ID: tc.userID}, "m1")
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, &models.MatchExpiry{MatchID: "m1", ExpiresAt: tc.extended.ExtendedUntil, ExtendedBy: tc.extended.ExtendedBy}, expiry)
+		})
+	}
+}
+
+func TestMatchService_SweepExpiredMatches(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	expired := &models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true, LastActivityAt: now.Add(-8 * 24 * time.Hour)}
+	// Another sweeper got to it first, or a message arrived meanwhile.
+	saved := &models.Match{ID: "m2", Profiles: []string{"carol", "dave"}, Matched: true, LastActivityAt: now.Add(-9 * 24 * time.Hour)}
+	expiring := &models.Match{ID: "m3", Profiles: []string{"alice", "erin"}, Matched: true, LastActivityAt: now.Add(-7 * 24 * time.Hour).Add(time.Hour)}
+
+	eventStore := store.NewEventStore(logrus.New())
+	events := make(chan store.Event, 3)
+	for _, topic := range []string{constants.MatchExpiredTopic, constants.MatchExpiringSoonTopic} {
+		assert.NoError(t, eventStore.Subscribe(topic, func(event store.Event) error {
+			events <- event
+			return nil
+		}))
+	}
+
+	matchRepo := new(repository.MockMatchRepository)
+	matchRepo.On("GetExpiredMatches", mock.Anything, testExpiry.ExpireAfter, now, constants.MatchSweepBatchSize).Return([]*models.Match{saved, expired}, nil)
+	matchRepo.On("ExpireMatch", mock.Anything, "m1", testExpiry.ExpireAfter, now).Return(true, nil)
+	matchRepo.On("ExpireMatch", mock.Anything, "m2", testExpiry.ExpireAfter, now).Return(false, nil)
+	matchRepo.On("GetMatchesToRemind", mock.Anything, testExpiry.ExpireAfter, testExpiry.WarnBefore, now, constants.MatchSweepBatchSize).Return([]*models.Match{expiring}, nil)
+	matchRepo.On("MarkExpiryReminded", mock.Anything, "m3", testExpiry.ExpireAfter, now.Add(time.Hour)).Return(true, nil)
+	matchService := NewMatchService(eventStore, logrus.New(), matchRepo, testExpiry)
+	matchService.now = func() time.Time { return now }
+
+	assert.NoError(t, matchService.SweepExpiredMatches(context.Background()))
+
+	received := map[string]models.MatchEvent{}
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-events:
+			var data models.MatchEvent
+			assert.NoError(t, json.Unmarshal(event.Data(), &data))
+			received[event.Topic()] = data
+		case <-time.After(time.Second):
+			t.Fatal("missing expiry event")
+		}
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected %s event for %s", event.Topic(), event.Data())
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	expiredEvent := received[constants.MatchExpiredTopic]
+	assert.Equal(t, "m1", expiredEvent.MatchID)
+	assert.Equal(t, []string{"alice", "bob"}, expiredEvent.Profiles)
+	assert.True(t, expiredEvent.ExpiresAt.Equal(now.Add(-24*time.Hour)))
+	reminder := received[constants.MatchExpiringSoonTopic]
+	assert.Equal(t, "m3", reminder.MatchID)
+	assert.True(t, reminder.ExpiresAt.Equal(now.Add(time.Hour)))
+}
+
+func TestMatchService_SweepExpiredMatches_RemindersOff(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	policy := testExpiry
+	policy.WarnBefore = 0
+
+	matchRepo := new(repository.MockMatchRepository)
+	matchRepo.On("GetExpiredMatches", mock.Anything, policy.ExpireAfter, now, constants.MatchSweepBatchSize).Return([]*models.Match{}, nil)
+	matchService := NewMatchService(store.NewEventStore(logrus.New()), logrus.New(), matchRepo, policy)
+	matchService.now = func() time.Time { return now }
+
+	assert.NoError(t, matchService.SweepExpiredMatches(context.Background()))
+	matchRepo.AssertNotCalled(t, "GetMatchesToRemind", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
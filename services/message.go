@@ -0,0 +1,218 @@
+package services
+
+import (
+	"api/constants"
+	"api/models"
+	"api/repository"
+	"api/store"
+	"api/utils"
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+var (
+	ErrConversationClosed = errors.New("this conversation has ended")
+	ErrFailedSendMessage  = errors.New("failed to send message")
+	ErrFailedListMessages = errors.New("failed to list messages")
+	ErrMessageNotFound    = errors.New("message not found")
+	ErrFailedMarkRead     = errors.New("failed to mark messages read")
+	ErrFailedGetPartner   = errors.New("failed to get the other participant")
+)
+
+// MessageService lets the two participants of a match chat while they are matched, with read
+// receipts and typing indicators.
+type MessageService struct {
+	eventStore        store.EventStore
+	logger            *logrus.Logger
+	messageRepository repository.MessageRepository
+	matchRepository   repository.MatchRepository
+	blockRepository   repository.BlockRepository
+	userRepository    repository.UserRepository
+	expiry            models.MatchExpiryPolicy
+	now               func() time.Time
+}
+
+func NewMessageService(eventStore store.EventStore, logger *logrus.Logger, messageRepository repository.MessageRepository,
+	matchRepository repository.MatchRepository,
+	blockRepository repository.BlockRepository,
+	userRepository repository.UserRepository,
+	expiry models.MatchExpiryPolicy,
+) *MessageService {
+	return &MessageService{
+		eventStore:        eventStore,
+		logger:            logger,
+		messageRepository: messageRepository,
+		matchRepository:   matchRepository,
+		blockRepository:   blockRepository,
+		userRepository:    userRepository,
+		expiry:            expiry,
+		now:               time.Now,
+	}
+}
+
+// Send sends a message to the other participant of a match and publishes a message.sent event.
+func (m *MessageService) Send(ctx context.Context, user models.User, matchID string, payload models.MessagePayload) (*models.Message, error) {
+	match, err := m.openConversation(ctx, user, matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := m.messageRepository.CreateMessage(ctx, &models.Message{
+		ID:       utils.GenerateId(),
+		MatchID:  match.ID,
+		SenderID: user.ID,
+		Body:     payload.Body,
+		SentAt:   m.now(),
+	})
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error(ErrFailedSendMessage)
+		return nil, ErrFailedSendMessage
+	}
+	// The message is stored either way; a stale last activity only affects ordering.
+	if err := m.matchRepository.RecordActivity(ctx, match.ID, message.SentAt); err != nil {
+		m.logger.WithContext(ctx).WithError(err).Warn("failed to record match activity")
+	}
+
+	publishEvent(m.eventStore, m.logger, constants.MessageSentTopic, models.MessageEvent{
+		MessageID:   message.ID,
+		MatchID:     match.ID,
+		SenderID:    user.ID,
+		RecipientID: match.OtherProfile(user.ID),
+		Body:        message.Body,
+		At:          message.SentAt,
+	})
+	return message, nil
+}
+
+// ListMessages returns one page of the messages of a match, newest first, and the cursor of the
+// next page, which is empty on the last page. The messages the user received are marked delivered.
+func (m *MessageService) ListMessages(ctx context.Context, user models.User, matchID string, page models.Page) ([]*models.Message, string, error) {
+	match, err := m.openConversation(ctx, user, matchID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	messages, nextCursor, err := utils.FetchPage(page, func(after *models.MessageCursor, limit int) ([]*models.Message, error) {
+		// Delivery is recorded before the page is read, so the page shows it.
+		if err := m.messageRepository.MarkDelivered(ctx, match.ID, user.ID, m.now()); err != nil {
+			m.logger.WithContext(ctx).WithError(err).Warn("failed to mark messages delivered")
+		}
+		return m.messageRepository.ListMessages(ctx, match.ID, after, limit)
+	}, func(last *models.Message) models.MessageCursor {
+		return models.MessageCursor{SentAt: last.SentAt, ID: last.ID}
+	})
+	if errors.Is(err, ErrInvalidCursor) {
+		return nil, "", err
+	}
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error(ErrFailedListMessages)
+		return nil, "", ErrFailedListMessages
+	}
+	return messages, nextCursor, nil
+}
+
+// MarkRead marks the messages the user received in a match as read, up to and including the
+// given message, or all of them, and publishes a message.read event to the other participant.
+func (m *MessageService) MarkRead(ctx context.Context, user models.User, matchID string, payload models.ReadPayload) (*models.ReadReceipt, error) {
+	match, err := m.openConversation(ctx, user, matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	upTo := now
+	if payload.MessageID != "" {
+		message, err := m.messageRepository.GetMessageById(ctx, payload.MessageID)
+		if err != nil {
+			m.logger.WithContext(ctx).WithError(err).Error(ErrFailedMarkRead)
+			return nil, ErrFailedMarkRead
+		}
+		if message == nil || message.MatchID != match.ID {
+			return nil, ErrMessageNotFound
+		}
+		upTo = message.SentAt
+	}
+
+	count, err := m.messageRepository.MarkRead(ctx, match.ID, user.ID, upTo, now)
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error(ErrFailedMarkRead)
+		return nil, ErrFailedMarkRead
+	}
+	receipt := &models.ReadReceipt{
+		MatchID:  match.ID,
+		ReaderID: user.ID,
+		SenderID: match.OtherProfile(user.ID),
+		UpTo:     upTo,
+		At:       now,
+		Count:    count,
+	}
+	// Reading nothing new tells the sender nothing new.
+	if count > 0 {
+		publishEvent(m.eventStore, m.logger, constants.MessageReadTopic, receipt)
+	}
+	return receipt, nil
+}
+
+// SetTyping tells the other participant of a match that the user started or stopped typing.
+// Nothing is stored.
+func (m *MessageService) SetTyping(ctx context.Context, user models.User, matchID string, typing bool) error {
+	match, err := m.openConversation(ctx, user, matchID)
+	if err != nil {
+		return err
+	}
+	topic := constants.TypingStoppedTopic
+	if typing {
+		topic = constants.TypingStartedTopic
+	}
+	publishEvent(m.eventStore, m.logger, topic, models.TypingEvent{
+		MatchID:     match.ID,
+		UserID:      user.ID,
+		RecipientID: match.OtherProfile(user.ID),
+		At:          m.now(),
+	})
+	return nil
+}
+
+// openConversation returns the match if the user takes part in it and the conversation is still
+// open: the pair is matched, the match has not expired, neither blocked the other and neither
+// deactivated their account.
+func (m *MessageService) openConversation(ctx context.Context, user models.User, matchID string) (*models.Match, error) {
+	match, err := m.matchRepository.GetMatchById(ctx, matchID)
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error("failed to get match")
+		return nil, ErrMatchNotFound
+	}
+	if !ownMatch(match, user) {
+		return nil, ErrMatchNotFound
+	}
+	if !match.Matched {
+		return nil, ErrConversationClosed
+	}
+	if m.expiry.Expired(*match, m.now()) {
+		return nil, ErrConversationClosed
+	}
+	// A block ends the match too, but the match is only updated after the block is stored.
+	blocked, err := m.blockRepository.IsBlocked(ctx, user.ID, match.OtherProfile(user.ID))
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error(ErrFailedCheckBlocks)
+		return nil, ErrFailedCheckBlocks
+	}
+	if blocked {
+		return nil, ErrConversationClosed
+	}
+	// Deactivating an account leaves its matches in place, so that reactivating restores them.
+	if user.IsDeactivated() {
+		return nil, ErrConversationClosed
+	}
+	partner, err := m.userRepository.GetUserById(ctx, match.OtherProfile(user.ID))
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error(ErrFailedGetPartner)
+		return nil, ErrFailedGetPartner
+	}
+	if partner.IsDeactivated() {
+		return nil, ErrConversationClosed
+	}
+	return match, nil
+}
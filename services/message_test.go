@@ -0,0 +1,238 @@
+package services
+
+import (
+	"api/constants"
+	"api/models"
+	"api/repository"
+	"api/store"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+// activeUsers returns a user repository where every user is active.
+func activeUsers() *repository.MockUserRepository {
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("GetUserById", mock.Anything, mock.Anything).Return(&models.User{Status: models.Activated}, nil)
+	return userRepo
+}
+
+func TestMessageService_Send(t *testing.T) {
+	sentAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	matched := &models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true}
+	week := models.MatchExpiryPolicy{ExpireAfter: 7 * 24 * time.Hour}
+	quietFor := func(quiet time.Duration) *models.Match {
+		return &models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true, LastActivityAt: sentAt.Add(-quiet)}
+	}
+
+	testCases := []struct {
+		name               string
+		userID             string
+		match              *models.Match
+		expiry             models.MatchExpiryPolicy
+		blocked            bool
+		senderDeactivated  bool
+		partnerDeactivated bool
+		expectedErr        error
+	}{
+		{name: "first participant", userID: "alice", match: matched},
+		{name: "second participant", userID: "bob", match: matched},
+		{name: "someone else's match", userID: "carol", match: matched, expectedErr: ErrMatchNotFound},
+		{name: "unknown match", userID: "alice", expectedErr: ErrMatchNotFound},
+		{name: "unmatched", userID: "alice", match: &models.Match{ID: "m1", Profiles: []string{"alice", "bob"}}, expectedErr: ErrConversationClosed},
+		{name: "not expired yet", userID: "alice", match: quietFor(6 * 24 * time.Hour), expiry: week},
+		// The sweeper has not ended the match yet, but it is past its expiry all the same.
+		{name: "expired", userID: "alice", match: quietFor(8 * 24 * time.Hour), expiry: week, expectedErr: ErrConversationClosed},
+		{name: "blocked", userID: "alice", match: matched, blocked: true, expectedErr: ErrConversationClosed},
+		{name: "sender deactivated", userID: "alice", match: matched, senderDeactivated: true, expectedErr: ErrConversationClosed},
+		{name: "other participant deactivated", userID: "alice", match: matched, partnerDeactivated: true, expectedErr: ErrConversationClosed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			eventStore := store.NewEventStore(logrus.New())
+			events := make(chan models.MessageEvent, 1)
+			assert.NoError(t, eventStore.Subscribe(constants.MessageSentTopic, func(event store.Event) error {
+				var data models.MessageEvent
+				assert.NoError(t, json.Unmarshal(event.Data(), &data))
+				events <- data
+				return nil
+			}))
+
+			matchRepo := new(repository.MockMatchRepository)
+			matchRepo.On("GetMatchById", mock.Anything, "m1").Return(tc.match, nil)
+			matchRepo.On("RecordActivity", mock.Anything, "m1", sentAt).Return(nil).Maybe()
+			blockRepo := new(repository.MockBlockRepository)
+			blockRepo.On("IsBlocked", mock.Anything, mock.Anything, mock.Anything).Return(tc.blocked, nil)
+			messageRepo := new(repository.MockMessageRepository)
+			messageRepo.On("CreateMessage", mock.Anything, mock.Anything).Return(&models.Message{
+				ID: "msg1", MatchID: "m1", SenderID: tc.userID, Body: "Salaam!", SentAt: sentAt,
+			}, nil).Maybe()
+			sender, partner := models.User{ID: tc.userID, Status: models.Activated}, &models.User{Status: models.Activated}
+			if tc.senderDeactivated {
+				sender.Status, sender.DeactivationReason = models.Deactivated, models.SelfDeactivation
+			}
+			if tc.partnerDeactivated {
+				partner.Status, partner.DeactivationReason = models.Deactivated, models.SelfDeactivation
+			}
+			userRepo := new(repository.MockUserRepository)
+			userRepo.On("GetUserById", mock.Anything, matched.OtherProfile(tc.userID)).Return(partner, nil).Maybe()
+			messageService := NewMessageService(eventStore, logrus.New(), messageRepo, matchRepo, blockRepo, userRepo, tc.expiry)
+			messageService.now = func() time.Time { return sentAt }
+
+			_, err := messageService.Send(context.Background(), sender, "m1", models.MessagePayload{Body: "Salaam!"})
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				messageRepo.AssertNotCalled(t, "CreateMessage", mock.Anything, mock.Anything)
+				matchRepo.AssertNotCalled(t, "RecordActivity", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			messageRepo.AssertCalled(t, "CreateMessage", mock.Anything, mock.MatchedBy(func(message *models.Message) bool {
+				return message.MatchID == "m1" && message.SenderID == tc.userID && message.Body == "Salaam!" && message.SentAt.Equal(sentAt)
+			}))
+			matchRepo.AssertCalled(t, "RecordActivity", mock.Anything, "m1", sentAt)
+			select {
+			case event := <-events:
+				assert.Equal(t, tc.userID, event.SenderID)
+				assert.Equal(t, matched.OtherProfile(tc.userID), event.RecipientID)
+				assert.Equal(t, "msg1", event.MessageID)
+			case <-time.After(time.Second):
+				t.Fatal("no message.sent event published")
+			}
+		})
+	}
+}
+
+func TestMessageService_ListMessages_Pagination(t *testing.T) {
+	sentAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	messages := []*models.Message{
+		{ID: "msg3", MatchID: "m1", SentAt: sentAt},
+		{ID: "msg2", MatchID: "m1", SentAt: sentAt.Add(-time.Minute)},
+		{ID: "msg1", MatchID: "m1", SentAt: sentAt.Add(-2 * time.Minute)},
+	}
+
+	matchRepo := new(repository.MockMatchRepository)
+	matchRepo.On("GetMatchById", mock.Anything, "m1").Return(&models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true}, nil)
+	messageRepo := new(repository.MockMessageRepository)
+	// One more message than the page size is fetched to learn whether there is a next page.
+	messageRepo.On("ListMessages", mock.Anything, "m1", (*models.MessageCursor)(nil), 3).Return(messages, nil)
+	messageRepo.On("MarkDelivered", mock.Anything, "m1", "bob", mock.Anything).Return(nil)
+	messageService := NewMessageService(store.NewEventStore(logrus.New()), logrus.New(), messageRepo, matchRepo, noBlocks(), activeUsers(), models.MatchExpiryPolicy{})
+
+	page, nextCursor, err := messageService.ListMessages(context.Background(), models.User{ID: "bob"}, "m1", models.Page{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, messages[:2], page)
+	// Fetching a conversation delivers the messages the user received.
+	messageRepo.AssertCalled(t, "MarkDelivered", mock.Anything, "m1", "bob", mock.Anything)
+
+	var cursor models.MessageCursor
+	assert.NoError(t, utils.DecodeCursor(nextCursor, &cursor))
+	assert.Equal(t, models.MessageCursor{SentAt: messages[1].SentAt, ID: "msg2"}, cursor)
+
+	_, _, err = messageService.ListMessages(context.Background(), models.User{ID: "bob"}, "m1", models.Page{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestMessageService_MarkRead(t *testing.T) {
+	readAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	sentAt := readAt.Add(-time.Hour)
+
+	testCases := []struct {
+		name          string
+		payload       models.ReadPayload
+		message       *models.Message
+		marked        int64
+		expectedUpTo  time.Time
+		expectedErr   error
+		expectedEvent bool
+	}{
+		{name: "everything", marked: 3, expectedUpTo: readAt, expectedEvent: true},
+		{name: "up to a message", payload: models.ReadPayload{MessageID: "msg1"}, message: &models.Message{ID: "msg1", MatchID: "m1", SentAt: sentAt}, marked: 1, expectedUpTo: sentAt, expectedEvent: true},
+		{name: "nothing new", expectedUpTo: readAt},
+		{name: "message of another match", payload: models.ReadPayload{MessageID: "msg1"}, message: &models.Message{ID: "msg1", MatchID: "m2"}, expectedErr: ErrMessageNotFound},
+		{name: "unknown message", payload: models.ReadPayload{MessageID: "msg1"}, expectedErr: ErrMessageNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			eventStore := store.NewEventStore(logrus.New())
+			events := make(chan models.ReadReceipt, 1)
+			assert.NoError(t, eventStore.Subscribe(constants.MessageReadTopic, func(event store.Event) error {
+				var data models.ReadReceipt
+				assert.NoError(t, json.Unmarshal(event.Data(), &data))
+				events <- data
+				return nil
+			}))
+
+			matchRepo := new(repository.MockMatchRepository)
+			matchRepo.On("GetMatchById", mock.Anything, "m1").Return(&models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true}, nil)
+			messageRepo := new(repository.MockMessageRepository)
+			messageRepo.On("GetMessageById", mock.Anything, "msg1").Return(tc.message, nil).Maybe()
+			messageRepo.On("MarkRead", mock.Anything, "m1", "bob", tc.expectedUpTo, readAt).Return(tc.marked, nil).Maybe()
+			messageService := NewMessageService(eventStore, logrus.New(), messageRepo, matchRepo, noBlocks(), activeUsers(), models.MatchExpiryPolicy{})
+			messageService.now = func() time.Time { return readAt }
+
+			receipt, err := messageService.MarkRead(context.Background(), models.User{ID: "bob"}, "m1", tc.payload)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				messageRepo.AssertNotCalled(t, "MarkRead", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			expected := models.ReadReceipt{MatchID: "m1", ReaderID: "bob", SenderID: "alice", UpTo: tc.expectedUpTo, At: readAt, Count: tc.marked}
+			assert.Equal(t, expected, *receipt)
+			select {
+			case event := <-events:
+				assert.True(t, tc.expectedEvent, "message.read published when nothing was read")
+				assert.Equal(t, expected, event)
+			case <-time.After(100 * time.Millisecond):
+				assert.False(t, tc.expectedEvent, "no message.read event published")
+			}
+		})
+	}
+}
+
+func TestMessageService_SetTyping(t *testing.T) {
+	eventStore := store.NewEventStore(logrus.New())
+	events := make(chan store.Event, 2)
+	for _, topic := range []string{constants.TypingStartedTopic, constants.TypingStoppedTopic} {
+		assert.NoError(t, eventStore.Subscribe(topic, func(event store.Event) error {
+			events <- event
+			return nil
+		}))
+	}
+
+	matchRepo := new(repository.MockMatchRepository)
+	matchRepo.On("GetMatchById", mock.Anything, "m1").Return(&models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true}, nil)
+	messageService := NewMessageService(eventStore, logrus.New(), new(repository.MockMessageRepository), matchRepo, noBlocks(), activeUsers(), models.MatchExpiryPolicy{})
+
+	for _, typing := range []bool{true, false} {
+		assert.NoError(t, messageService.SetTyping(context.Background(), models.User{ID: "alice"}, "m1", typing))
+		select {
+		case event := <-events:
+			expectedTopic := constants.TypingStoppedTopic
+			if typing {
+				expectedTopic = constants.TypingStartedTopic
+			}
+			assert.Equal(t, expectedTopic, event.Topic())
+			var data models.TypingEvent
+			assert.NoError(t, json.Unmarshal(event.Data(), &data))
+			// Only the other participant is told.
+			assert.Equal(t, "bob", data.RecipientID)
+			assert.Equal(t, "alice", data.UserID)
+		case <-time.After(time.Second):
+			t.Fatal("no typing event published")
+		}
+	}
+
+	err := messageService.SetTyping(context.Background(), models.User{ID: "carol"}, "m1", true)
+	assert.ErrorIs(t, err, ErrMatchNotFound)
+}
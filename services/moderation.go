@@ -0,0 +1,235 @@
+package services
+
+import (
+	"api/models"
+	"api/repository"
+	"api/utils"
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+var (
+	ErrReportNotFound      = errors.New("report not found")
+	ErrReportNotOpen       = errors.New("the report is not open")
+	ErrReportNotClaimed    = errors.New("the report is not under review by you")
+	ErrFailedListReports   = errors.New("failed to list reports")
+	ErrFailedClaimReport   = errors.New("failed to claim report")
+	ErrFailedResolveReport = errors.New("failed to resolve report")
+	ErrFailedGetAuditTrail = errors.New("failed to get audit trail")
+	ErrCannotModerateSelf  = errors.New("you cannot moderate a report about yourself")
+	ErrCannotModerateStaff = errors.New("only admins can moderate moderators and admins")
+)
+
+// ModerationService works the queue of reports: moderators claim open reports, review them, and
+// either dismiss them or action them by warning, suspending or deactivating the reported user.
+// Every step is written to the audit trail.
+type ModerationService struct {
+	logger           *logrus.Logger
+	reportRepository repository.ReportRepository
+	auditRepository  repository.AuditRepository
+	userRepository   repository.UserRepository
+	now              func() time.Time
+}
+
+func NewModerationService(logger *logrus.Logger, reportRepository repository.ReportRepository,
+	auditRepository repository.AuditRepository, userRepository repository.UserRepository) *ModerationService {
+	return &ModerationService{
+		logger:           logger,
+		reportRepository: reportRepository,
+		auditRepository:  auditRepository,
+		userRepository:   userRepository,
+		now:              time.Now,
+	}
+}
+
+// ListReports returns one page of the reports that match the filter, oldest first, and the
+// cursor of the next page, which is empty on the last page.
+func (m *ModerationService) ListReports(ctx context.Context, filter models.ReportFilter, page models.Page) ([]*models.Report, string, error) {
+	reports, nextCursor, err := utils.FetchPage(page, func(after *models.ReportCursor, limit int) ([]*models.Report, error) {
+		return m.reportRepository.ListReports(ctx, filter, after, limit)
+	}, func(last *models.Report) models.ReportCursor {
+		return models.ReportCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	})
+	if errors.Is(err, ErrInvalidCursor) {
+		return nil, "", err
+	}
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error(ErrFailedListReports)
+		return nil, "", ErrFailedListReports
+	}
+	return reports, nextCursor, nil
+}
+
+// ClaimReport puts an open report under review by the moderator. Only one moderator can claim
+// a report, and never one about themselves or, unless they are an admin, about another moderator.
+func (m *ModerationService) ClaimReport(ctx context.Context, moderator models.User, reportID string) (*models.Report, error) {
+	existing, err := m.getReport(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.checkCanModerate(ctx, moderator, existing.ReportedID); err != nil {
+		return nil, err
+	}
+
+	report, err := m.reportRepository.ClaimReport(ctx, reportID, moderator.ID, m.now())
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error(ErrFailedClaimReport)
+		return nil, ErrFailedClaimReport
+	}
+	if report == nil {
+		return nil, ErrReportNotOpen
+	}
+
+	m.audit(ctx, &models.AuditEntry{
+		ReportID:     report.ID,
+		ModeratorID:  moderator.ID,
+		TargetUserID: report.ReportedID,
+		Event:        models.ReportClaimedEvent,
+		FromState:    models.OpenReport,
+		ToState:      models.ReviewingReport,
+	})
+	return report, nil
+}
+
+// ResolveReport closes a report the moderator is reviewing. An actioned report then applies its
+// action to the reported user.
+func (m *ModerationService) ResolveReport(ctx context.Context, moderator models.User, reportID string, resolution models.ReportResolution) (*models.Report, error) {
+	report, err := m.getReport(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if report.State != models.ReviewingReport || report.ClaimedBy != moderator.ID {
+		return nil, ErrReportNotClaimed
+	}
+	if resolution.State == models.ActionedReport {
+		if err := m.checkCanModerate(ctx, moderator, report.ReportedID); err != nil {
+			return nil, err
+		}
+	}
+
+	// The report is closed first: when two requests race to resolve it, only the one that closed
+	// it applies its action.
+	resolved, err := m.reportRepository.ResolveReport(ctx, report.ID, moderator.ID, resolution, m.now())
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error(ErrFailedResolveReport)
+		return nil, ErrFailedResolveReport
+	}
+	if resolved == nil {
+		return nil, ErrReportNotClaimed
+	}
+
+	var moderateErr error
+	if resolution.State == models.ActionedReport {
+		moderateErr = m.moderateUser(ctx, moderator, resolved, resolution)
+	}
+	m.audit(ctx, &models.AuditEntry{
+		ReportID:     resolved.ID,
+		ModeratorID:  moderator.ID,
+		TargetUserID: resolved.ReportedID,
+		Event:        models.ReportResolvedEvent,
+		FromState:    models.ReviewingReport,
+		ToState:      resolved.State,
+		Action:       resolved.Action,
+		Note:         resolved.Note,
+	})
+	// The report stays resolved; the failed action is in the log and the audit trail.
+	if moderateErr != nil {
+		return nil, moderateErr
+	}
+	return resolved, nil
+}
+
+// checkCanModerate reports whether the moderator may act on reports about the given user: never
+// about themselves, and only admins about moderators and admins.
+func (m *ModerationService) checkCanModerate(ctx context.Context, moderator models.User, reportedID string) error {
+	if reportedID == moderator.ID {
+		return ErrCannotModerateSelf
+	}
+	if moderator.Role.OrDefault() == models.AdminRole {
+		return nil
+	}
+	reported, err := m.userRepository.GetUserById(ctx, reportedID)
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).WithField("user", reportedID).Error("failed to get reported user by ID")
+		return ErrUserNotFound
+	}
+	if reported.Role.OrDefault() != models.UserRole {
+		return ErrCannotModerateStaff
+	}
+	return nil
+}
+
+// moderateUser applies the action of the resolution to the reported user. The action is written
+// to the audit trail before it is applied, so no action is ever taken without a record of it.
+func (m *ModerationService) moderateUser(ctx context.Context, moderator models.User, report *models.Report, resolution models.ReportResolution) error {
+	now := m.now()
+	var update models.UserUpdate
+	switch resolution.Action {
+	case models.WarnAction:
+		update.WarnedAt = &now
+	case models.SuspendAction:
+		until := now.AddDate(0, 0, resolution.SuspendDays)
+		update.SuspendedUntil = &until
+	case models.DeactivateAction:
+		status, reason := models.Deactivated, models.ModerationDeactivation
+		update.Status = &status
+		update.DeactivationReason = &reason
+	}
+
+	err := m.auditRepository.CreateAuditEntry(ctx, &models.AuditEntry{
+		ID:           utils.GenerateId(),
+		ReportID:     report.ID,
+		ModeratorID:  moderator.ID,
+		TargetUserID: report.ReportedID,
+		Event:        models.UserModeratedEvent,
+		Action:       resolution.Action,
+		Note:         resolution.Note,
+		CreatedAt:    now,
+	})
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error("failed to audit moderation action")
+		return ErrFailedResolveReport
+	}
+	if _, err := m.userRepository.UpdateUser(ctx, report.ReportedID, update); err != nil {
+		m.logger.WithContext(ctx).WithError(err).WithField("user", report.ReportedID).Error("failed to apply moderation action")
+		return ErrFailedResolveReport
+	}
+	return nil
+}
+
+// GetAuditTrail returns every moderation step taken on a report, oldest first.
+func (m *ModerationService) GetAuditTrail(ctx context.Context, reportID string) ([]*models.AuditEntry, error) {
+	if _, err := m.getReport(ctx, reportID); err != nil {
+		return nil, err
+	}
+	entries, err := m.auditRepository.ListAuditEntries(ctx, reportID)
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error(ErrFailedGetAuditTrail)
+		return nil, ErrFailedGetAuditTrail
+	}
+	return entries, nil
+}
+
+func (m *ModerationService) getReport(ctx context.Context, reportID string) (*models.Report, error) {
+	report, err := m.reportRepository.GetReportById(ctx, reportID)
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Error("failed to get report by ID")
+		return nil, ErrReportNotFound
+	}
+	if report == nil {
+		return nil, ErrReportNotFound
+	}
+	return report, nil
+}
+
+// audit records a report state change that has already happened. The change stands even when
+// it cannot be recorded, so a failure is logged with the whole entry rather than returned.
+func (m *ModerationService) audit(ctx context.Context, entry *models.AuditEntry) {
+	entry.ID = utils.GenerateId()
+	entry.CreatedAt = m.now()
+	if err := m.auditRepository.CreateAuditEntry(ctx, entry); err != nil {
+		m.logger.WithContext(ctx).WithError(err).WithField("entry", entry).Error("failed to write moderation audit entry")
+	}
+}
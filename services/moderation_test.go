@@ -0,0 +1,181 @@
+package services
+
+import (
+	"api/models"
+	"api/repository"
+	"context"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestModerationService_ClaimReport(t *testing.T) {
+	claimedAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	moderator := models.User{ID: "mod", Role: models.ModeratorRole}
+	admin := models.User{ID: "mod", Role: models.AdminRole}
+	claimed := &models.Report{ID: "r1", ReportedID: "bob", State: models.ReviewingReport, ClaimedBy: "mod"}
+
+	testCases := []struct {
+		name        string
+		moderator   models.User
+		existing    *models.Report
+		claimed     *models.Report
+		expectedErr error
+	}{
+		{name: "open report", moderator: moderator, existing: &models.Report{ID: "r1", ReportedID: "bob", State: models.OpenReport}, claimed: claimed},
+		{name: "claimed by someone else", moderator: moderator, existing: &models.Report{ID: "r1", ReportedID: "bob", State: models.ReviewingReport, ClaimedBy: "other"}, expectedErr: ErrReportNotOpen},
+		{name: "unknown report", moderator: moderator, expectedErr: ErrReportNotFound},
+		{name: "report about yourself", moderator: moderator, existing: &models.Report{ID: "r1", ReportedID: "mod", State: models.OpenReport}, expectedErr: ErrCannotModerateSelf},
+		{name: "report about a moderator", moderator: moderator, existing: &models.Report{ID: "r1", ReportedID: "carol", State: models.OpenReport}, expectedErr: ErrCannotModerateStaff},
+		{name: "report about a moderator by an admin", moderator: admin, existing: &models.Report{ID: "r1", ReportedID: "carol", State: models.OpenReport}, claimed: claimed},
+		{name: "report about yourself as an admin", moderator: admin, existing: &models.Report{ID: "r1", ReportedID: "mod", State: models.OpenReport}, expectedErr: ErrCannotModerateSelf},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reportRepo := new(repository.MockReportRepository)
+			reportRepo.On("GetReportById", mock.Anything, "r1").Return(tc.existing, nil)
+			reportRepo.On("ClaimReport", mock.Anything, "r1", "mod", claimedAt).Return(tc.claimed, nil).Maybe()
+			userRepo := new(repository.MockUserRepository)
+			userRepo.On("GetUserById", mock.Anything, "bob").Return(&models.User{ID: "bob"}, nil).Maybe()
+			userRepo.On("GetUserById", mock.Anything, "carol").Return(&models.User{ID: "carol", Role: models.ModeratorRole}, nil).Maybe()
+			auditRepo := new(repository.MockAuditRepository)
+			auditRepo.On("CreateAuditEntry", mock.Anything, mock.Anything).Return(nil).Maybe()
+			moderationService := NewModerationService(logrus.New(), reportRepo, auditRepo, userRepo)
+			moderationService.now = func() time.Time { return claimedAt }
+
+			report, err := moderationService.ClaimReport(context.Background(), tc.moderator, "r1")
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				auditRepo.AssertNotCalled(t, "CreateAuditEntry", mock.Anything, mock.Anything)
+				if tc.existing == nil || tc.existing.State == models.OpenReport {
+					reportRepo.AssertNotCalled(t, "ClaimReport", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.claimed, report)
+			auditRepo.AssertCalled(t, "CreateAuditEntry", mock.Anything, mock.MatchedBy(func(entry *models.AuditEntry) bool {
+				return entry.Event == models.ReportClaimedEvent && entry.ModeratorID == "mod" && entry.TargetUserID == "bob" &&
+					entry.FromState == models.OpenReport && entry.ToState == models.ReviewingReport && entry.CreatedAt.Equal(claimedAt)
+			}))
+		})
+	}
+}
+
+func TestModerationService_ResolveReport(t *testing.T) {
+	resolvedAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	suspendedUntil := resolvedAt.AddDate(0, 0, 7)
+	deactivated, moderation := models.Deactivated, models.ModerationDeactivation
+	reviewing := &models.Report{ID: "r1", ReportedID: "bob", State: models.ReviewingReport, ClaimedBy: "mod"}
+
+	testCases := []struct {
+		name        string
+		report      *models.Report
+		resolution  models.ReportResolution
+		update      *models.UserUpdate
+		lostRace    bool
+		expectedErr error
+	}{
+		{
+			name:       "warn",
+			report:     reviewing,
+			resolution: models.ReportResolution{State: models.ActionedReport, Action: models.WarnAction},
+			update:     &models.UserUpdate{WarnedAt: &resolvedAt},
+		},
+		{
+			name:       "suspend",
+			report:     reviewing,
+			resolution: models.ReportResolution{State: models.ActionedReport, Action: models.SuspendAction, SuspendDays: 7},
+			update:     &models.UserUpdate{SuspendedUntil: &suspendedUntil},
+		},
+		{
+			name:       "deactivate",
+			report:     reviewing,
+			resolution: models.ReportResolution{State: models.ActionedReport, Action: models.DeactivateAction},
+			update:     &models.UserUpdate{Status: &deactivated, DeactivationReason: &moderation},
+		},
+		{
+			name:       "dismiss",
+			report:     reviewing,
+			resolution: models.ReportResolution{State: models.DismissedReport, Note: "not a fake profile"},
+		},
+		{
+			name:        "claimed by someone else",
+			report:      &models.Report{ID: "r1", ReportedID: "bob", State: models.ReviewingReport, ClaimedBy: "other"},
+			resolution:  models.ReportResolution{State: models.DismissedReport},
+			expectedErr: ErrReportNotClaimed,
+		},
+		{
+			name:        "action against yourself",
+			report:      &models.Report{ID: "r1", ReportedID: "mod", State: models.ReviewingReport, ClaimedBy: "mod"},
+			resolution:  models.ReportResolution{State: models.ActionedReport, Action: models.WarnAction},
+			expectedErr: ErrCannotModerateSelf,
+		},
+		{
+			name:        "action against a moderator",
+			report:      &models.Report{ID: "r1", ReportedID: "carol", State: models.ReviewingReport, ClaimedBy: "mod"},
+			resolution:  models.ReportResolution{State: models.ActionedReport, Action: models.WarnAction},
+			expectedErr: ErrCannotModerateStaff,
+		},
+		{
+			name:        "resolved concurrently",
+			report:      reviewing,
+			resolution:  models.ReportResolution{State: models.ActionedReport, Action: models.WarnAction},
+			lostRace:    true,
+			expectedErr: ErrReportNotClaimed,
+		},
+		{
+			name:        "not claimed",
+			report:      &models.Report{ID: "r1", ReportedID: "bob", State: models.OpenReport},
+			resolution:  models.ReportResolution{State: models.DismissedReport},
+			expectedErr: ErrReportNotClaimed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var steps []string
+			reportRepo := new(repository.MockReportRepository)
+			reportRepo.On("GetReportById", mock.Anything, "r1").Return(tc.report, nil)
+			resolved := &models.Report{ID: "r1", ReportedID: "bob", State: tc.resolution.State, Action: tc.resolution.Action}
+			if tc.lostRace {
+				// Another request closed the report between the read and the transition.
+				resolved = nil
+			}
+			reportRepo.On("ResolveReport", mock.Anything, "r1", "mod", tc.resolution, resolvedAt).Return(resolved, nil).
+				Run(func(mock.Arguments) { steps = append(steps, "resolve report") }).Maybe()
+			auditRepo := new(repository.MockAuditRepository)
+			auditRepo.On("CreateAuditEntry", mock.Anything, mock.Anything).Return(nil).
+				Run(func(args mock.Arguments) { steps = append(steps, string(args.Get(1).(*models.AuditEntry).Event)) }).Maybe()
+			userRepo := new(repository.MockUserRepository)
+			userRepo.On("GetUserById", mock.Anything, "bob").Return(&models.User{ID: "bob"}, nil).Maybe()
+			userRepo.On("GetUserById", mock.Anything, "carol").Return(&models.User{ID: "carol", Role: models.ModeratorRole}, nil).Maybe()
+			userRepo.On("UpdateUser", mock.Anything, "bob", mock.Anything).Return(&models.User{ID: "bob"}, nil).
+				Run(func(mock.Arguments) { steps = append(steps, "update user") }).Maybe()
+			moderationService := NewModerationService(logrus.New(), reportRepo, auditRepo, userRepo)
+			moderationService.now = func() time.Time { return resolvedAt }
+
+			report, err := moderationService.ResolveReport(context.Background(), models.User{ID: "mod", Role: models.ModeratorRole}, "r1", tc.resolution)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				// Only the transition may have been tried; nothing was applied or audited.
+				assert.Subset(t, []string{"resolve report"}, steps)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, resolved, report)
+			if tc.update == nil {
+				assert.Equal(t, []string{"resolve report", string(models.ReportResolvedEvent)}, steps)
+				return
+			}
+			// The report is closed before the action, and the action is audited before it is applied.
+			assert.Equal(t, []string{"resolve report", string(models.UserModeratedEvent), "update user", string(models.ReportResolvedEvent)}, steps)
+			userRepo.AssertCalled(t, "UpdateUser", mock.Anything, "bob", *tc.update)
+		})
+	}
+}
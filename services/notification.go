@@ -0,0 +1,288 @@
+package services
+
+import (
+	"api/constants"
+	"api/models"
+	"api/notifiers"
+	"api/repository"
+	"api/store"
+	"api/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var (
+	ErrFailedListNotifications     = errors.New("failed to list notifications")
+	ErrFailedMarkNotificationsRead = errors.New("failed to mark notifications read")
+)
+
+// notificationRecipient is one user an event notifies.
+type notificationRecipient struct {
+	UserID string
+	// AboutUserID is the user the notification is about, whose name the templates show. It is
+	// empty when who it is about is kept from the recipient.
+	AboutUserID string
+	Data        map[string]string
+	Preview     string
+}
+
+// notificationContext is what notification templates are rendered with.
+type notificationContext struct {
+	Name    string
+	Preview string
+}
+
+// notificationKind renders the notifications of one topic.
+type notificationKind struct {
+	title      *template.Template
+	body       *template.Template
+	recipients func(data []byte) ([]notificationRecipient, error)
+}
+
+func newNotificationKind(title, body string, recipients func(data []byte) ([]notificationRecipient, error)) notificationKind {
+	return notificationKind{
+		title:      template.Must(template.New("title").Parse(title)),
+		body:       template.Must(template.New("body").Parse(body)),
+		recipients: recipients,
+	}
+}
+
+// notificationKinds are the topics the notification service turns into notifications.
+var notificationKinds = map[string]notificationKind{
+	constants.MatchCreatedTopic: newNotificationKind(
+		"It's a match!", "You and {{.Name}} liked each other. Say salaam!", matchNotificationRecipients),
+	constants.MatchExpiringSoonTopic: newNotificationKind(
+		"Your match with {{.Name}} is about to expire", "Send {{.Name}} a message, or extend the match, before it's gone.", matchNotificationRecipients),
+	constants.MatchExpiredTopic: newNotificationKind(
+		"Your match with {{.Name}} has expired", "Keep swiping to find someone new.", matchNotificationRecipients),
+	constants.MessageSentTopic: newNotificationKind(
+		"{{.Name}}", "{{.Preview}}", func(data []byte) ([]notificationRecipient, error) {
+			var event models.MessageEvent
+			err := json.Unmarshal(data, &event)
+			return []notificationRecipient{{
+				UserID:      event.RecipientID,
+				AboutUserID: event.SenderID,
+				Data:        map[string]string{"match_id": event.MatchID, "message_id": event.MessageID},
+				Preview:     notificationPreview(event.Body),
+			}}, err
+		}),
+	// Plain likes stay anonymous; the user finds out who in their received likes.
+	constants.SwipeLikedTopic: newNotificationKind(
+		"Someone likes you", "Like them back to match.", func(data []byte) ([]notificationRecipient, error) {
+			var event models.SwipeEvent
+			err := json.Unmarshal(data, &event)
+			return []notificationRecipient{{UserID: event.ProspectID}}, err
+		}),
+	constants.SwipeSuperLikedTopic: newNotificationKind(
+		"{{.Name}} super-liked you!", "Like them back to match.", func(data []byte) ([]notificationRecipient, error) {
+			var event models.SwipeEvent
+			err := json.Unmarshal(data, &event)
+			return []notificationRecipient{{
+				UserID:      event.ProspectID,
+				AboutUserID: event.UserID,
+				Data:        map[string]string{"user_id": event.UserID},
+			}}, err
+		}),
+}
+
+// matchNotificationRecipients notifies both participants of a match about each other.
+func matchNotificationRecipients(data []byte) ([]notificationRecipient, error) {
+	var event models.MatchEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+	match := models.Match{Profiles: event.Profiles}
+	var recipients []notificationRecipient
+	for _, profile := range event.Profiles {
+		recipients = append(recipients, notificationRecipient{
+			UserID:      profile,
+			AboutUserID: match.OtherProfile(profile),
+			Data:        map[string]string{"match_id": event.MatchID},
+		})
+	}
+	return recipients, nil
+}
+
+// notificationPreview shortens a message to what fits in a notification.
+func notificationPreview(body string) string {
+	runes := []rune(strings.TrimSpace(body))
+	if len(runes) <= constants.NotificationPreviewLength {
+		return string(runes)
+	}
+	return strings.TrimSpace(string(runes[:constants.NotificationPreviewLength])) + "…"
+}
+
+// NotificationService turns match, message and like events into notifications. Every notification
+// is kept in the user's in-app inbox and delivered through each configured notifier.
+type NotificationService struct {
+	logger                 *logrus.Logger
+	notificationRepository repository.NotificationRepository
+	userRepository         repository.UserRepository
+	channels               []*notificationChannel
+	now                    func() time.Time
+}
+
+// notificationChannel is a notifier with its own queue of notifications, delivered in order by a
+// single goroutine, so a slow channel holds up neither the inbox nor the other channels.
+type notificationChannel struct {
+	notifier notifiers.Notifier
+	queue    chan notificationDelivery
+}
+
+// notificationDelivery is a notification kept in the inbox and waiting to be delivered.
+type notificationDelivery struct {
+	user         models.User
+	notification models.Notification
+}
+
+// NewNotificationService creates a notification service subscribed to every topic it notifies of.
+func NewNotificationService(eventStore store.EventStore, logger *logrus.Logger, notificationRepository repository.NotificationRepository,
+	userRepository repository.UserRepository,
+	channels []notifiers.Notifier,
+) (*NotificationService, error) {
+	n := &NotificationService{
+		logger:                 logger,
+		notificationRepository: notificationRepository,
+		userRepository:         userRepository,
+		now:                    time.Now,
+	}
+	for _, notifier := range channels {
+		channel := &notificationChannel{
+			notifier: notifier,
+			queue:    make(chan notificationDelivery, constants.NotificationQueueSize),
+		}
+		go n.deliver(channel)
+		n.channels = append(n.channels, channel)
+	}
+	for topic := range notificationKinds {
+		if err := eventStore.Subscribe(topic, n.handle); err != nil {
+			return nil, fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+		}
+	}
+	return n, nil
+}
+
+// ListNotifications returns one page of the user's notifications, newest first, and the cursor of
+// the next page, which is empty on the last page.
+func (n *NotificationService) ListNotifications(ctx context.Context, user models.User, page models.Page) ([]*models.Notification, string, error) {
+	notifications, nextCursor, err := utils.FetchPage(page, func(after *models.NotificationCursor, limit int) ([]*models.Notification, error) {
+		return n.notificationRepository.ListNotifications(ctx, user.ID, after, limit)
+	}, func(last *models.Notification) models.NotificationCursor {
+		return models.NotificationCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	})
+	if errors.Is(err, ErrInvalidCursor) {
+		return nil, "", err
+	}
+	if err != nil {
+		n.logger.WithContext(ctx).WithError(err).Error(ErrFailedListNotifications)
+		return nil, "", ErrFailedListNotifications
+	}
+	return notifications, nextCursor, nil
+}
+
+// MarkRead marks the given notifications of the user, or all of them, as read.
+func (n *NotificationService) MarkRead(ctx context.Context, user models.User, payload models.ReadNotificationsPayload) (*models.NotificationsRead, error) {
+	now := n.now()
+	count, err := n.notificationRepository.MarkNotificationsRead(ctx, user.ID, payload.IDs, now)
+	if err != nil {
+		n.logger.WithContext(ctx).WithError(err).Error(ErrFailedMarkNotificationsRead)
+		return nil, ErrFailedMarkNotificationsRead
+	}
+	return &models.NotificationsRead{Count: count, At: now}, nil
+}
+
+// handle notifies the recipients of an event. A recipient that cannot be notified is logged and
+// does not hold up the others.
+func (n *NotificationService) handle(event store.Event) error {
+	kind := notificationKinds[event.Topic()]
+	recipients, err := kind.recipients(event.Data())
+	if err != nil {
+		return fmt.Errorf("failed to read event: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.NotificationTimeout)
+	defer cancel()
+	for _, recipient := range recipients {
+		if err := n.notify(ctx, event.Topic(), kind, recipient); err != nil {
+			n.logger.WithError(err).WithField("user", recipient.UserID).Errorf("failed to notify of %s", event.Topic())
+		}
+	}
+	return nil
+}
+
+// notify renders a notification, keeps it in the recipient's inbox and queues it for delivery.
+// Deactivated users are not notified.
+func (n *NotificationService) notify(ctx context.Context, topic string, kind notificationKind, recipient notificationRecipient) error {
+	user, err := n.userRepository.GetUserById(ctx, recipient.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.IsDeactivated() {
+		return nil
+	}
+
+	data := notificationContext{Name: "Someone", Preview: recipient.Preview}
+	if recipient.AboutUserID != "" {
+		// A missing name makes a vaguer notification, not a lost one.
+		about, err := n.userRepository.GetUserById(ctx, recipient.AboutUserID)
+		if err != nil {
+			n.logger.WithError(err).Warn("failed to get the user a notification is about")
+		} else if about != nil && about.Name != "" {
+			data.Name = about.Name
+		}
+	}
+	var title, body strings.Builder
+	if err := kind.title.Execute(&title, data); err != nil {
+		return err
+	}
+	if err := kind.body.Execute(&body, data); err != nil {
+		return err
+	}
+
+	notification, err := n.notificationRepository.CreateNotification(ctx, &models.Notification{
+		ID:        utils.GenerateId(),
+		UserID:    user.ID,
+		Type:      topic,
+		Title:     title.String(),
+		Body:      body.String(),
+		Data:      recipient.Data,
+		CreatedAt: n.now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// The notification is in the inbox either way; a channel that fell behind only misses it.
+	for _, channel := range n.channels {
+		select {
+		case channel.queue <- notificationDelivery{user: *user, notification: *notification}:
+		default:
+			n.logger.WithFields(logrus.Fields{
+				"user":    user.ID,
+				"channel": channel.notifier.Name(),
+			}).Warn("dropping notification for a channel that fell behind")
+		}
+	}
+	return nil
+}
+
+// deliver delivers the notifications queued for the channel, one at a time. A failed delivery is
+// only logged.
+func (n *NotificationService) deliver(channel *notificationChannel) {
+	for delivery := range channel.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), constants.NotificationDeliveryTimeout)
+		if err := channel.notifier.Notify(ctx, delivery.user, delivery.notification); err != nil {
+			n.logger.WithError(err).WithFields(logrus.Fields{
+				"user":    delivery.user.ID,
+				"channel": channel.notifier.Name(),
+			}).Warn("failed to deliver notification")
+		}
+		cancel()
+	}
+}
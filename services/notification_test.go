@@ -0,0 +1,254 @@
+package services
+
+import (
+	"api/constants"
+	"api/models"
+	"api/notifiers"
+	"api/repository"
+	"api/store"
+	"api/utils"
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryNotificationRepository keeps the notifications it stores in memory.
+type memoryNotificationRepository struct {
+	repository.MockNotificationRepository
+	mu            sync.Mutex
+	notifications []*models.Notification
+}
+
+func (r *memoryNotificationRepository) CreateNotification(_ context.Context, notification *models.Notification) (*models.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications = append(r.notifications, notification)
+	return notification, nil
+}
+
+func (r *memoryNotificationRepository) stored() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.notifications)
+}
+
+// recordingNotifier passes on the notifications it is asked to deliver.
+type recordingNotifier struct {
+	delivered chan models.Notification
+	err       error
+}
+
+func (r *recordingNotifier) Name() string {
+	return "recording"
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, user models.User, notification models.Notification) error {
+	r.delivered <- notification
+	return r.err
+}
+
+func TestNotificationService_NotifiesOfEvents(t *testing.T) {
+	longMessage := strings.Repeat("salaam ", 30)
+
+	testCases := []struct {
+		name     string
+		topic    string
+		data     interface{}
+		expected []models.Notification
+	}{
+		{
+			name:  "match created",
+			topic: constants.MatchCreatedTopic,
+			data:  models.MatchEvent{MatchID: "m1", Profiles: []string{"alice", "bob"}},
+			expected: []models.Notification{
+				{UserID: "alice", Title: "It's a match!", Body: "You and Bilal liked each other. Say salaam!", Data: map[string]string{"match_id": "m1"}},
+				{UserID: "bob", Title: "It's a match!", Body: "You and Amina liked each other. Say salaam!", Data: map[string]string{"match_id": "m1"}},
+			},
+		},
+		{
+			name:  "match expiring soon",
+			topic: constants.MatchExpiringSoonTopic,
+			data:  models.MatchEvent{MatchID: "m1", Profiles: []string{"alice", "bob"}},
+			expected: []models.Notification{
+				{UserID: "alice", Title: "Your match with Bilal is about to expire", Body: "Send Bilal a message, or extend the match, before it's gone.", Data: map[string]string{"match_id": "m1"}},
+				{UserID: "bob", Title: "Your match with Amina is about to expire", Body: "Send Amina a message, or extend the match, before it's gone.", Data: map[string]string{"match_id": "m1"}},
+			},
+		},
+		{
+			name:  "message sent",
+			topic: constants.MessageSentTopic,
+			data:  models.MessageEvent{MessageID: "msg1", MatchID: "m1", SenderID: "bob", RecipientID: "alice", Body: longMessage},
+			expected: []models.Notification{
+				{UserID: "alice", Title: "Bilal", Body: longMessage[:constants.NotificationPreviewLength] + "…", Data: map[string]string{"match_id": "m1", "message_id": "msg1"}},
+			},
+		},
+		{
+			name:  "liked, anonymously",
+			topic: constants.SwipeLikedTopic,
+			data:  models.SwipeEvent{SwipeID: "s1", UserID: "bob", ProspectID: "alice"},
+			expected: []models.Notification{
+				{UserID: "alice", Title: "Someone likes you", Body: "Like them back to match."},
+			},
+		},
+		{
+			name:  "super-liked",
+			topic: constants.SwipeSuperLikedTopic,
+			data:  models.SwipeEvent{SwipeID: "s1", UserID: "bob", ProspectID: "alice"},
+			expected: []models.Notification{
+				{UserID: "alice", Title: "Bilal super-liked you!", Body: "Like them back to match.", Data: map[string]string{"user_id": "bob"}},
+			},
+		},
+		{
+			name:  "deactivated users are left alone",
+			topic: constants.SwipeSuperLikedTopic,
+			data:  models.SwipeEvent{SwipeID: "s1", UserID: "bob", ProspectID: "carol"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			createdAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+			eventStore := store.NewEventStore(logrus.New())
+			userRepo := new(repository.MockUserRepository)
+			userRepo.On("GetUserById", mock.Anything, "alice").Return(&models.User{ID: "alice", Name: "Amina"}, nil)
+			userRepo.On("GetUserById", mock.Anything, "bob").Return(&models.User{ID: "bob", Name: "Bilal"}, nil)
+			userRepo.On("GetUserById", mock.Anything, "carol").Return(&models.User{ID: "carol", Status: models.Deactivated}, nil)
+			notificationRepo := &memoryNotificationRepository{}
+			notifier := &recordingNotifier{delivered: make(chan models.Notification, 2)}
+			notificationService, err := NewNotificationService(eventStore, logrus.New(), notificationRepo, userRepo, []notifiers.Notifier{notifier})
+			assert.NoError(t, err)
+			notificationService.now = func() time.Time { return createdAt }
+
+			publishTestEvent(t, eventStore, tc.topic, tc.data)
+
+			var delivered []models.Notification
+			for range tc.expected {
+				select {
+				case notification := <-notifier.delivered:
+					assert.NotEmpty(t, notification.ID)
+					notification.ID = ""
+					delivered = append(delivered, notification)
+				case <-time.After(time.Second):
+					t.Fatalf("got %d of %d notifications", len(delivered), len(tc.expected))
+				}
+			}
+			select {
+			case notification := <-notifier.delivered:
+				t.Fatalf("unexpected notification for %s", notification.UserID)
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			for i := range tc.expected {
+				tc.expected[i].Type = tc.topic
+				tc.expected[i].CreatedAt = createdAt
+			}
+			assert.ElementsMatch(t, tc.expected, delivered)
+			// Every delivered notification is in the inbox too.
+			assert.Equal(t, len(tc.expected), notificationRepo.stored())
+		})
+	}
+}
+
+func TestNotificationService_KeepsNotificationsWhenDeliveryFails(t *testing.T) {
+	eventStore := store.NewEventStore(logrus.New())
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("GetUserById", mock.Anything, mock.Anything).Return(&models.User{ID: "alice"}, nil)
+	notificationRepo := &memoryNotificationRepository{}
+	failing := &recordingNotifier{delivered: make(chan models.Notification, 1), err: errors.New("push provider down")}
+	working := &recordingNotifier{delivered: make(chan models.Notification, 1)}
+	_, err := NewNotificationService(eventStore, logrus.New(), notificationRepo, userRepo, []notifiers.Notifier{failing, working})
+	assert.NoError(t, err)
+
+	publishTestEvent(t, eventStore, constants.SwipeLikedTopic, models.SwipeEvent{ProspectID: "alice"})
+
+	for _, notifier := range []*recordingNotifier{failing, working} {
+		select {
+		case <-notifier.delivered:
+		case <-time.After(time.Second):
+			t.Fatal("a channel was skipped after another one failed")
+		}
+	}
+	assert.Equal(t, 1, notificationRepo.stored())
+}
+
+// stuckNotifier never finishes a delivery until it is released.
+type stuckNotifier struct {
+	release chan struct{}
+}
+
+func (s *stuckNotifier) Name() string {
+	return "stuck"
+}
+
+func (s *stuckNotifier) Notify(ctx context.Context, user models.User, notification models.Notification) error {
+	<-s.release
+	return nil
+}
+
+func TestNotificationService_SlowChannelDoesNotHoldUpTheInbox(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	eventStore := store.NewEventStore(logger)
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("GetUserById", mock.Anything, mock.Anything).Return(&models.User{ID: "alice"}, nil)
+	notificationRepo := &memoryNotificationRepository{}
+	stuck := &stuckNotifier{release: make(chan struct{})}
+	defer close(stuck.release)
+	_, err := NewNotificationService(eventStore, logger, notificationRepo, userRepo, []notifiers.Notifier{stuck})
+	assert.NoError(t, err)
+
+	// Far more events than either queue holds: every one still reaches the inbox.
+	events := constants.SubscriberBufferSize + constants.NotificationQueueSize
+	for i := 1; i <= events; i++ {
+		publishTestEvent(t, eventStore, constants.SwipeLikedTopic, models.SwipeEvent{ProspectID: "alice"})
+		assert.Eventually(t, func() bool { return notificationRepo.stored() == i }, time.Second, time.Millisecond,
+			"the inbox got %d of %d notifications", notificationRepo.stored(), i)
+	}
+}
+
+func TestNotificationService_ListNotifications_Pagination(t *testing.T) {
+	createdAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	notifications := []*models.Notification{
+		{ID: "n3", UserID: "alice", CreatedAt: createdAt},
+		{ID: "n2", UserID: "alice", CreatedAt: createdAt.Add(-time.Minute)},
+		{ID: "n1", UserID: "alice", CreatedAt: createdAt.Add(-2 * time.Minute)},
+	}
+	notificationRepo := new(repository.MockNotificationRepository)
+	notificationRepo.On("ListNotifications", mock.Anything, "alice", (*models.NotificationCursor)(nil), 3).Return(notifications, nil)
+	notificationService, err := NewNotificationService(store.NewEventStore(logrus.New()), logrus.New(), notificationRepo, new(repository.MockUserRepository), nil)
+	assert.NoError(t, err)
+
+	page, nextCursor, err := notificationService.ListNotifications(context.Background(), models.User{ID: "alice"}, models.Page{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, notifications[:2], page)
+	var cursor models.NotificationCursor
+	assert.NoError(t, utils.DecodeCursor(nextCursor, &cursor))
+	assert.Equal(t, models.NotificationCursor{CreatedAt: notifications[1].CreatedAt, ID: "n2"}, cursor)
+
+	_, _, err = notificationService.ListNotifications(context.Background(), models.User{ID: "alice"}, models.Page{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestNotificationService_MarkRead(t *testing.T) {
+	readAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	notificationRepo := new(repository.MockNotificationRepository)
+	notificationRepo.On("MarkNotificationsRead", mock.Anything, "alice", []string{"n1", "n2"}, readAt).Return(int64(2), nil)
+	notificationRepo.On("MarkNotificationsRead", mock.Anything, "alice", []string(nil), readAt).Return(int64(0), errors.New("connection reset"))
+	notificationService, err := NewNotificationService(store.NewEventStore(logrus.New()), logrus.New(), notificationRepo, new(repository.MockUserRepository), nil)
+	assert.NoError(t, err)
+	notificationService.now = func() time.Time { return readAt }
+
+	read, err := notificationService.MarkRead(context.Background(), models.User{ID: "alice"}, models.ReadNotificationsPayload{IDs: []string{"n1", "n2"}})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.NotificationsRead{Count: 2, At: readAt}, read)
+
+	_, err = notificationService.MarkRead(context.Background(), models.User{ID: "alice"}, models.ReadNotificationsPayload{})
+	assert.ErrorIs(t, err, ErrFailedMarkNotificationsRead)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"api/constants"
+	"api/models"
+	"api/repository"
+	"api/store"
+	"api/utils"
+	"context"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrCannotBlockSelf  = errors.New("you cannot block yourself")
+	ErrCannotReportSelf = errors.New("you cannot report yourself")
+	ErrFailedBlockUser  = errors.New("failed to block user")
+	ErrFailedReportUser = errors.New("failed to report user")
+)
+
+// SafetyService lets users block and report each other.
+type SafetyService struct {
+	eventStore       store.EventStore
+	logger           *logrus.Logger
+	blockRepository  repository.BlockRepository
+	reportRepository repository.ReportRepository
+	matchRepository  repository.MatchRepository
+	userRepository   repository.UserRepository
+	now              func() time.Time
+}
+
+func NewSafetyService(eventStore store.EventStore, logger *logrus.Logger, blockRepository repository.BlockRepository,
+	reportRepository repository.ReportRepository, matchRepository repository.MatchRepository, userRepository repository.UserRepository) *SafetyService {
+	return &SafetyService{
+		eventStore:       eventStore,
+		logger:           logger,
+		blockRepository:  blockRepository,
+		reportRepository: reportRepository,
+		matchRepository:  matchRepository,
+		userRepository:   userRepository,
+		now:              time.Now,
+	}
+}
+
+// Block stops the two users from seeing, swiping on or matching with each other, and ends their
+// match, if any, publishing a match.unmatched event. Blocking a user again changes nothing, so a
+// failed block can safely be retried.
+func (s *SafetyService) Block(ctx context.Context, user models.User, blockedID string) (*models.Block, error) {
+	if blockedID == user.ID {
+		return nil, ErrCannotBlockSelf
+	}
+	if _, err := s.userRepository.GetUserById(ctx, blockedID); err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("failed to get blocked user by ID")
+		return nil, ErrUserNotFound
+	}
+
+	block, err := s.blockRepository.CreateBlock(ctx, &models.Block{
+		ID:        utils.GenerateId(),
+		BlockerID: user.ID,
+		BlockedID: blockedID,
+		CreatedAt: s.now(),
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error(ErrFailedBlockUser)
+		return nil, ErrFailedBlockUser
+	}
+
+	if err := dissolveMatch(ctx, s.eventStore, s.logger, s.matchRepository, user.ID, blockedID, s.now()); err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("failed to end the match of blocked user")
+		return nil, ErrFailedBlockUser
+	}
+	return block, nil
+}
+
+// dissolveMatch ends the current match between the user and the blocked user, if any, and
+// publishes a match.unmatched event. The match is kept, no longer matched, like an unmatch by the
+// user.
+func dissolveMatch(ctx context.Context, eventStore store.EventStore, logger *logrus.Logger, matchRepository repository.MatchRepository,
+	userID, blockedID string, unmatchedAt time.Time) error {
+	match, err := matchRepository.GetMatchByProfiles(ctx, []string{userID, blockedID})
+	if err != nil {
+		return err
+	}
+	if match == nil || !match.Matched {
+		return nil
+	}
+
+	ended, err := matchRepository.EndMatch(ctx, match.ID, userID, unmatchedAt)
+	if err != nil {
+		return err
+	}
+	// Ended concurrently by someone else, who published the event.
+	if !ended {
+		return nil
+	}
+	publishEvent(eventStore, logger, constants.MatchUnmatchedTopic, models.MatchEvent{
+		MatchID:  match.ID,
+		Profiles: match.Profiles,
+		UserID:   userID,
+		At:       unmatchedAt,
+	})
+	return nil
+}
+
+// Report files a report of another user. It goes into the moderation queue as an open report.
+func (s *SafetyService) Report(ctx context.Context, user models.User, reportedID string, payload models.ReportPayload) (*models.Report, error) {
+	if reportedID == user.ID {
+		return nil, ErrCannotReportSelf
+	}
+	if _, err := s.userRepository.GetUserById(ctx, reportedID); err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error("failed to get reported user by ID")
+		return nil, ErrUserNotFound
+	}
+
+	report, err := s.reportRepository.CreateReport(ctx, &models.Report{
+		ID:         utils.GenerateId(),
+		ReporterID: user.ID,
+		ReportedID: reportedID,
+		Reason:     payload.Reason,
+		Details:    payload.Details,
+		State:      models.OpenReport,
+		CreatedAt:  s.now(),
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error(ErrFailedReportUser)
+		return nil, ErrFailedReportUser
+	}
+	return report, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"api/constants"
+	"api/models"
+	"api/repository"
+	"api/store"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestSafetyService_Block(t *testing.T) {
+	blockedAt := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name        string
+		blockedID   string
+		match       *models.Match
+		ended       bool
+		endedBefore bool
+		expectedErr error
+	}{
+		{name: "no match", blockedID: "bob"},
+		{name: "current match is ended", blockedID: "bob", match: &models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true}, ended: true},
+		{name: "ended match is left alone", blockedID: "bob", match: &models.Match{ID: "m1", Profiles: []string{"alice", "bob"}}},
+		{name: "match ended concurrently", blockedID: "bob", match: &models.Match{ID: "m1", Profiles: []string{"alice", "bob"}, Matched: true}, endedBefore: true},
+		{name: "yourself", blockedID: "alice", expectedErr: ErrCannotBlockSelf},
+		{name: "unknown user", blockedID: "nobody", expectedErr: ErrUserNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(repository.MockUserRepository)
+			userRepo.On("GetUserById", mock.Anything, "bob").Return(&models.User{ID: "bob"}, nil).Maybe()
+			userRepo.On("GetUserById", mock.Anything, "nobody").Return((*models.User)(nil), errors.New("no documents")).Maybe()
+			blockRepo := new(repository.MockBlockRepository)
+			blockRepo.On("CreateBlock", mock.Anything, mock.Anything).Return(&models.Block{BlockerID: "alice", BlockedID: tc.blockedID}, nil).Maybe()
+			matchRepo := new(repository.MockMatchRepository)
+			matchRepo.On("GetMatchByProfiles", mock.Anything, []string{"alice", tc.blockedID}).Return(tc.match, nil).Maybe()
+			matchRepo.On("EndMatch", mock.Anything, "m1", "alice", blockedAt).Return(!tc.endedBefore, nil).Maybe()
+			eventStore := store.NewEventStore(logrus.New())
+			events := make(chan models.MatchEvent, 1)
+			assert.NoError(t, eventStore.Subscribe(constants.MatchUnmatchedTopic, func(event store.Event) error {
+				var data models.MatchEvent
+				assert.NoError(t, json.Unmarshal(event.Data(), &data))
+				events <- data
+				return nil
+			}))
+
+			safetyService := NewSafetyService(eventStore, logrus.New(), blockRepo,
+				new(repository.MockReportRepository), matchRepo, userRepo)
+			safetyService.now = func() time.Time { return blockedAt }
+
+			block, err := safetyService.Block(context.Background(), models.User{ID: "alice"}, tc.blockedID)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				blockRepo.AssertNotCalled(t, "CreateBlock", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.blockedID, block.BlockedID)
+			if !tc.ended {
+				if !tc.endedBefore {
+					matchRepo.AssertNotCalled(t, "EndMatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				}
+				select {
+				case event := <-events:
+					t.Fatalf("unexpected match.unmatched event %+v", event)
+				case <-time.After(50 * time.Millisecond):
+				}
+				return
+			}
+			// The match is ended as if the blocker unmatched.
+			select {
+			case event := <-events:
+				assert.Equal(t, models.MatchEvent{MatchID: "m1", Profiles: []string{"alice", "bob"}, UserID: "alice", At: blockedAt}, event)
+			case <-time.After(time.Second):
+				t.Fatal("no match.unmatched event published")
+			}
+		})
+	}
+}
+
+func TestSafetyService_Report(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("GetUserById", mock.Anything, "bob").Return(&models.User{ID: "bob"}, nil)
+	reportRepo := new(repository.MockReportRepository)
+	reportRepo.On("CreateReport", mock.Anything, mock.Anything).Return(&models.Report{}, nil)
+	safetyService := NewSafetyService(store.NewEventStore(logrus.New()), logrus.New(), new(repository.MockBlockRepository),
+		reportRepo, new(repository.MockMatchRepository), userRepo)
+
+	payload := models.ReportPayload{Reason: models.FakeProfileReason, Details: "photos of a celebrity"}
+	_, err := safetyService.Report(context.Background(), models.User{ID: "alice"}, "alice", payload)
+	assert.ErrorIs(t, err, ErrCannotReportSelf)
+
+	_, err = safetyService.Report(context.Background(), models.User{ID: "alice"}, "bob", payload)
+	assert.NoError(t, err)
+	reportRepo.AssertCalled(t, "CreateReport", mock.Anything, mock.MatchedBy(func(report *models.Report) bool {
+		return report.ReporterID == "alice" && report.ReportedID == "bob" && report.Reason == models.FakeProfileReason &&
+			report.Details == "photos of a celebrity" && report.State == models.OpenReport
+	}))
+}
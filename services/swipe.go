@@ -1,13 +1,16 @@
 package services
 
 import (
+	"api/constants"
 	"api/models"
 	"api/repository"
 	"api/store"
 	"api/utils"
 	"context"
 	"errors"
+	"fmt"
 	"github.com/sirupsen/logrus"
+	"time"
 )
 
 var (
@@ -16,19 +19,49 @@ var (
 	ErrFailedCreateSwipe         = errors.New("failed to create swipe")
 	ErrFailedCreateMatch         = errors.New("failed to create match")
 	ErrFailedGenerateID          = errors.New("failed to generate ID")
+	ErrAlreadySwiped             = errors.New("prospect already swiped")
+	ErrFailedCountSwipe          = errors.New("failed to count swipe")
+	ErrFailedUndoSwipe           = errors.New("failed to undo swipe")
+	ErrNothingToUndo             = errors.New("no swipe to undo")
+	ErrUndoWindowPassed          = errors.New("the last swipe can no longer be undone")
+	ErrCannotUndoUnmatched       = errors.New("the last swipe made a match that has ended and cannot be undone")
+	ErrCannotUndoActiveMatch     = errors.New("the last swipe made a match that has seen activity and cannot be undone")
+	ErrFailedGetReceivedLikes    = errors.New("failed to get received likes")
+	ErrFailedListSwipes          = errors.New("failed to list swipes")
+	ErrFailedCheckBlocks         = errors.New("failed to check blocks")
+	ErrProspectBlocked           = errors.New("you cannot swipe on this user")
+	ErrSwiperDeactivated         = errors.New("reactivate your account to swipe")
+	ErrCannotSwipeSelf           = errors.New("you cannot swipe on yourself")
+	ErrLikeNotFound              = errors.New("this user has not liked you")
 )
 
+// QuotaExceededError is returned when a swipe or rewind is over the user's daily quota.
+type QuotaExceededError struct {
+	Kind     models.SwipeCounter
+	Limit    int
+	ResetsAt time.Time
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("daily %s quota of %d reached, resets at %s", e.Kind, e.Limit, e.ResetsAt.Format(time.RFC3339))
+}
+
 type SwipeService struct {
 	eventStore      store.EventStore
 	logger          *logrus.Logger
 	swipeRepository repository.SwipesRepository
 	matchRepository repository.MatchRepository
 	userRepository  repository.UserRepository
+	blockRepository repository.BlockRepository
+	limits          models.SwipeLimits
+	now             func() time.Time
 }
 
 func NewSwipeService(eventStore store.EventStore, logger *logrus.Logger, swipeRepository repository.SwipesRepository,
 	matchRepository repository.MatchRepository,
 	userRepository repository.UserRepository,
+	blockRepository repository.BlockRepository,
+	limits models.SwipeLimits,
 ) *SwipeService {
 	return &SwipeService{
 		eventStore:      eventStore,
@@ -36,60 +69,409 @@ func NewSwipeService(eventStore store.EventStore, logger *logrus.Logger, swipeRe
 		swipeRepository: swipeRepository,
 		matchRepository: matchRepository,
 		userRepository:  userRepository,
+		blockRepository: blockRepository,
+		limits:          limits,
+		now:             time.Now,
 	}
 }
 
-// Swipe swipes a user through a prospect profile for a possible match.
-func (s *SwipeService) Swipe(ctx context.Context, userID string, payload models.SwipePayload) (*models.SwipeResponse, error) {
-	_, err := s.userRepository.GetUserById(ctx, payload.ProspectID)
+// Swipe swipes a user through a prospect profile for a possible match. Every swipe counts
+// towards the user's daily like, pass or super-like quota. A super-like publishes a
+// swipe.super_liked event, a new match a match.created event, and any other like a swipe.liked
+// event.
+func (s *SwipeService) Swipe(ctx context.Context, user models.User, payload models.SwipePayload) (*models.SwipeResponse, error) {
+	userID := user.ID
+	if payload.ProspectID == userID {
+		return nil, ErrCannotSwipeSelf
+	}
+	if user.IsDeactivated() {
+		return nil, ErrSwiperDeactivated
+	}
+	prospect, err := s.userRepository.GetUserById(ctx, payload.ProspectID)
 	if err != nil {
 		s.logger.WithError(err).Error(ErrFailedGetProspectUser)
 		return nil, ErrFailedGetProspectUser
 	}
+	// Deactivated users are hidden, so they cannot be swiped on by ID either.
+	if prospect.IsDeactivated() {
+		return nil, ErrProspectBlocked
+	}
+	blocked, err := s.blockRepository.IsBlocked(ctx, userID, payload.ProspectID)
+	if err != nil {
+		s.logger.WithError(err).Error(ErrFailedCheckBlocks)
+		return nil, ErrFailedCheckBlocks
+	}
+	if blocked {
+		return nil, ErrProspectBlocked
+	}
 
-	checkIfImProspectSwipe, err := s.swipeRepository.GetSwipeByUserAndProspect(ctx, payload.ProspectID, userID)
+	kind := payload.SwipeKind()
+	counter := swipeCounter(kind)
+	day, err := s.useQuota(ctx, user, counter)
 	if err != nil {
-		s.logger.WithError(err).Error(ErrFailedCheckProspectSwiped)
-		return nil, ErrFailedCheckProspectSwiped
+		return nil, err
 	}
 
 	swipe := &models.Swipe{
 		ID:         utils.GenerateId(),
 		UserID:     userID,
 		ProspectID: payload.ProspectID,
-		Interested: payload.Interested,
+		Interested: kind.Interested(),
+		Kind:       kind,
+		SwipeTime:  s.now(),
+		QuotaDay:   day,
 	}
 
 	swipe, err = s.swipeRepository.CreateSwipe(ctx, swipe)
 	if err != nil {
+		// The swipe was not stored, so it must not use up the quota.
+		s.giveBackQuota(ctx, userID, day, counter)
+		if errors.Is(err, repository.ErrDuplicateFound) {
+			return nil, ErrAlreadySwiped
+		}
 		s.logger.WithError(err).Error(ErrFailedCreateSwipe)
 		return nil, ErrFailedCreateSwipe
 	}
 
+	if !kind.Interested() {
+		return &models.SwipeResponse{
+			Matched: false,
+		}, nil
+	}
+
+	// The prospect's swipe is read only after ours is stored: when both swipe at the same moment,
+	// at least one of them sees the other. If both do, CreateMatch returns the same match to both.
+	checkIfImProspectSwipe, err := s.swipeRepository.GetSwipeByUserAndProspect(ctx, payload.ProspectID, userID)
+	if err != nil {
+		s.logger.WithError(err).Error(ErrFailedCheckProspectSwiped)
+		s.forgetSwipe(ctx, swipe, day, counter)
+		return nil, ErrFailedCheckProspectSwiped
+	}
+
 	var matchUser *models.Match
 
-	if checkIfImProspectSwipe != nil && checkIfImProspectSwipe.Interested && swipe.Interested {
+	if checkIfImProspectSwipe != nil && checkIfImProspectSwipe.Interested {
+		matchedAt := s.now()
 		match := &models.Match{
-			ID:       utils.GenerateId(),
-			Profiles: []string{userID, payload.ProspectID},
-			Matched:  true,
+			ID:             utils.GenerateId(),
+			Profiles:       []string{userID, payload.ProspectID},
+			Matched:        true,
+			MatchedAt:      matchedAt,
+			LastActivityAt: matchedAt,
 		}
 
 		matchUser, err = s.matchRepository.CreateMatch(ctx, match)
 		if err != nil {
 			s.logger.WithError(err).Error(ErrFailedCreateMatch)
+			s.forgetSwipe(ctx, swipe, day, counter)
 			return nil, ErrFailedCreateMatch
 		}
+		// A block that landed since the check above may have looked for the match before it was
+		// stored, so the block is checked again now that it is, and the match dissolved like
+		// Block does.
+		if err := s.dissolveBlockedMatch(ctx, userID, payload.ProspectID); err != nil {
+			s.forgetSwipe(ctx, swipe, day, counter)
+			return nil, err
+		}
+		// When both swipers race, CreateMatch returns the same match to both; only the one whose
+		// match was stored announces it.
+		if matchUser.ID == match.ID {
+			publishEvent(s.eventStore, s.logger, constants.MatchCreatedTopic, models.MatchEvent{
+				MatchID:  matchUser.ID,
+				Profiles: matchUser.Profiles,
+				At:       matchUser.MatchedAt,
+			})
+		}
+	}
+
+	// The swipe is final now, so it is announced; a like that made a match is announced as the match.
+	if kind == models.SuperLikeSwipe {
+		publishEvent(s.eventStore, s.logger, constants.SwipeSuperLikedTopic, models.SwipeEvent{
+			SwipeID:    swipe.ID,
+			UserID:     userID,
+			ProspectID: payload.ProspectID,
+			At:         swipe.SwipeTime,
+		})
 	}
 
 	if matchUser == nil {
+		if kind == models.LikeSwipe {
+			publishEvent(s.eventStore, s.logger, constants.SwipeLikedTopic, models.SwipeEvent{
+				SwipeID:    swipe.ID,
+				UserID:     userID,
+				ProspectID: payload.ProspectID,
+				At:         swipe.SwipeTime,
+			})
+		}
 		return &models.SwipeResponse{
 			Matched: false,
 		}, nil
 	}
 
 	return &models.SwipeResponse{
-		Matched: true,
-		MatchID: matchUser.ID,
+		Matched:            true,
+		MatchID:            matchUser.ID,
+		ProspectSuperLiked: checkIfImProspectSwipe.IsSuperLike(),
 	}, nil
 }
+
+// Undo reverts the user's most recent swipe, if it is within the rewind window, and rolls back
+// the match it made. The prospect shows up in discover again. Rewinds have their own daily quota,
+// and the undone swipe no longer counts towards the like or pass quota.
+func (s *SwipeService) Undo(ctx context.Context, user models.User) (*models.Swipe, error) {
+	swipe, err := s.swipeRepository.GetLatestSwipe(ctx, user.ID)
+	if err != nil {
+		s.logger.WithError(err).Error(ErrFailedUndoSwipe)
+		return nil, ErrFailedUndoSwipe
+	}
+	if swipe == nil {
+		return nil, ErrNothingToUndo
+	}
+	if s.now().Sub(swipe.SwipeTime) > s.limits.RewindWindow {
+		return nil, ErrUndoWindowPassed
+	}
+
+	var match *models.Match
+	if swipe.Interested {
+		match, err = s.matchRepository.GetMatchByProfiles(ctx, []string{user.ID, swipe.ProspectID})
+		if err != nil {
+			s.logger.WithError(err).Error(ErrFailedUndoSwipe)
+			return nil, ErrFailedUndoSwipe
+		}
+		// An unmatched pair must never meet again, so the like that made the match stays.
+		if match != nil && !match.Matched {
+			return nil, ErrCannotUndoUnmatched
+		}
+		// Neither is one whose match was used already: deleting it would lose the conversation.
+		if match != nil && match.HasActivity() {
+			return nil, ErrCannotUndoActiveMatch
+		}
+	}
+
+	day, err := s.useQuota(ctx, user, models.RewindsCounter)
+	if err != nil {
+		return nil, err
+	}
+
+	// The match goes first, and only while it is still unused: a match that got a message in the
+	// meantime is kept, and so is the swipe that made it.
+	if match != nil {
+		deleted, err := s.matchRepository.DeleteQuietMatch(ctx, match.ID)
+		if err != nil {
+			s.giveBackQuota(ctx, user.ID, day, models.RewindsCounter)
+			s.logger.WithError(err).WithField("match", match.ID).Error(ErrFailedUndoSwipe)
+			return nil, ErrFailedUndoSwipe
+		}
+		if !deleted {
+			s.giveBackQuota(ctx, user.ID, day, models.RewindsCounter)
+			return nil, ErrCannotUndoActiveMatch
+		}
+		publishEvent(s.eventStore, s.logger, constants.MatchUnmatchedTopic, models.MatchEvent{
+			MatchID:  match.ID,
+			Profiles: match.Profiles,
+			UserID:   user.ID,
+			At:       s.now(),
+		})
+	}
+	// When two rewinds race, only one of them deletes the swipe and goes on. A failure leaves the
+	// like without its match, which a retry undoes.
+	if err := s.swipeRepository.DeleteSwipe(ctx, swipe.ID); err != nil {
+		s.giveBackQuota(ctx, user.ID, day, models.RewindsCounter)
+		s.logger.WithError(err).Error(ErrFailedUndoSwipe)
+		return nil, ErrFailedUndoSwipe
+	}
+
+	s.giveBackQuota(ctx, user.ID, s.swipeQuotaDay(user, swipe), swipeCounter(swipe.SwipeKind()))
+	return swipe, nil
+}
+
+// swipeQuotaDay returns the day of the swiper's usage the swipe was counted in. Swipes stored
+// before the day was kept on them were counted in the swiper's local date at the time.
+func (s *SwipeService) swipeQuotaDay(user models.User, swipe *models.Swipe) string {
+	if swipe.QuotaDay != "" {
+		return swipe.QuotaDay
+	}
+	day, _ := s.quotaDayAt(user, swipe.SwipeTime)
+	return day
+}
+
+// ListSwipes returns one page of the user's own swipes that match the filter, newest first, and
+// the cursor of the next page, which is empty on the last page. Only the user's swipes are listed,
+// whatever UserID the filter holds.
+func (s *SwipeService) ListSwipes(ctx context.Context, user models.User, filter models.SwipeFilter, page models.Page) ([]*models.Swipe, string, error) {
+	filter.UserID = user.ID
+	swipes, nextCursor, err := utils.FetchPage(page, func(after *models.SwipeCursor, limit int) ([]*models.Swipe, error) {
+		return s.swipeRepository.ListSwipes(ctx, filter, after, limit)
+	}, func(last *models.Swipe) models.SwipeCursor {
+		return models.SwipeCursor{SwipeTime: last.SwipeTime, ID: last.ID}
+	})
+	if errors.Is(err, ErrInvalidCursor) {
+		return nil, "", err
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error(ErrFailedListSwipes)
+		return nil, "", ErrFailedListSwipes
+	}
+	return swipes, nextCursor, nil
+}
+
+// GetReceivedLikes returns one page of the likes the user received and has not answered yet,
+// newest first, and the cursor of the next page, which is empty on the last page.
+func (s *SwipeService) GetReceivedLikes(ctx context.Context, user models.User, page models.Page) ([]*models.ReceivedLike, string, error) {
+	likes, nextCursor, err := utils.FetchPage(page, func(after *models.ReceivedLikeCursor, limit int) ([]*models.ReceivedLike, error) {
+		return s.swipeRepository.GetReceivedLikes(ctx, user.ID, after, limit)
+	}, func(last *models.ReceivedLike) models.ReceivedLikeCursor {
+		return models.ReceivedLikeCursor{LikedAt: last.LikedAt, ID: last.SwipeID}
+	})
+	if errors.Is(err, ErrInvalidCursor) {
+		return nil, "", err
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).WithError(err).Error(ErrFailedGetReceivedLikes)
+		return nil, "", ErrFailedGetReceivedLikes
+	}
+	return likes, nextCursor, nil
+}
+
+// LikeBack likes the user who liked the given user. It is an ordinary like, so it makes a match
+// and counts towards the daily like quota. It fails with ErrLikeNotFound unless the liker liked
+// the user.
+func (s *SwipeService) LikeBack(ctx context.Context, user models.User, likerID string) (*models.SwipeResponse, error) {
+	if likerID == user.ID {
+		return nil, ErrCannotSwipeSelf
+	}
+	like, err := s.swipeRepository.GetSwipeByUserAndProspect(ctx, likerID, user.ID)
+	if err != nil {
+		s.logger.WithError(err).Error(ErrFailedCheckProspectSwiped)
+		return nil, ErrFailedCheckProspectSwiped
+	}
+	if like == nil || !like.Interested {
+		return nil, ErrLikeNotFound
+	}
+	return s.Swipe(ctx, user, models.SwipePayload{ProspectID: likerID, Kind: models.LikeSwipe, Interested: true})
+}
+
+// GetQuota reports the user's remaining swipes for the current day.
+func (s *SwipeService) GetQuota(user models.User) *models.SwipeQuota {
+	usage := s.quotaPeriod(user)
+
+	likeLimit := s.quotaLimit(user, models.LikesCounter)
+	return &models.SwipeQuota{
+		LikeLimit:           likeLimit,
+		LikesRemaining:      max(likeLimit-usage.Likes, 0),
+		PassLimit:           s.limits.DailyPasses,
+		PassesRemaining:     max(s.limits.DailyPasses-usage.Passes, 0),
+		RewindLimit:         s.limits.DailyRewinds,
+		RewindsRemaining:    max(s.limits.DailyRewinds-usage.Rewinds, 0),
+		SuperLikeLimit:      s.limits.DailySuperLikes,
+		SuperLikesRemaining: max(s.limits.DailySuperLikes-usage.SuperLikes, 0),
+		ResetsAt:            usage.ResetsAt,
+	}
+}
+
+// useQuota counts one use of the given daily quota and returns the day of the usage it was
+// counted in. It fails with a QuotaExceededError when the quota is used up.
+func (s *SwipeService) useQuota(ctx context.Context, user models.User, counter models.SwipeCounter) (string, error) {
+	now := s.now()
+	day, resetsAt := s.quotaDayAt(user, now)
+	limit := s.quotaLimit(user, counter)
+	counted, err := s.userRepository.IncrementSwipeUsage(ctx, user.ID, models.SwipeUsage{Day: day, ResetsAt: resetsAt}, now, counter, limit)
+	if err != nil {
+		s.logger.WithError(err).Error(ErrFailedCountSwipe)
+		return "", ErrFailedCountSwipe
+	}
+	if counted == nil {
+		return "", &QuotaExceededError{Kind: counter, Limit: limit, ResetsAt: s.quotaPeriod(user).ResetsAt}
+	}
+	// The usage may have started on another local date, before the user changed time zone.
+	if counted.SwipeUsage != nil {
+		day = counted.SwipeUsage.Day
+	}
+	return day, nil
+}
+
+// dissolveBlockedMatch ends the match between the user and the prospect if either blocked the
+// other, and then returns ErrProspectBlocked. The match is stored already, so a failed check lets
+// it stand, as it would have had the block come a moment later.
+func (s *SwipeService) dissolveBlockedMatch(ctx context.Context, userID, prospectID string) error {
+	blocked, err := s.blockRepository.IsBlocked(ctx, userID, prospectID)
+	if err != nil {
+		s.logger.WithError(err).Warn(ErrFailedCheckBlocks)
+		return nil
+	}
+	if !blocked {
+		return nil
+	}
+	if err := dissolveMatch(ctx, s.eventStore, s.logger, s.matchRepository, userID, prospectID, s.now()); err != nil {
+		s.logger.WithError(err).Error("failed to end the match of blocked user")
+	}
+	return ErrProspectBlocked
+}
+
+// forgetSwipe deletes a stored swipe whose match could not be checked or made, and gives back its
+// quota. Without it the swipe would stay without its match, and retrying would find it swiped.
+func (s *SwipeService) forgetSwipe(ctx context.Context, swipe *models.Swipe, day string, counter models.SwipeCounter) {
+	if err := s.swipeRepository.DeleteSwipe(ctx, swipe.ID); err != nil {
+		s.logger.WithError(err).WithField("swipe", swipe.ID).Error("failed to delete a swipe that made no match")
+		return
+	}
+	s.giveBackQuota(ctx, swipe.UserID, day, counter)
+}
+
+// giveBackQuota returns a use of the given daily quota counted on day.
+func (s *SwipeService) giveBackQuota(ctx context.Context, userID, day string, counter models.SwipeCounter) {
+	if err := s.userRepository.DecrementSwipeUsage(ctx, userID, day, counter); err != nil {
+		s.logger.WithError(err).WithField("counter", counter).Warn("failed to give back swipe quota")
+	}
+}
+
+// quotaLimit returns the user's limit of the given daily quota. The user's own swipe budget,
+// when set, replaces the default like limit.
+func (s *SwipeService) quotaLimit(user models.User, counter models.SwipeCounter) int {
+	switch counter {
+	case models.PassesCounter:
+		return s.limits.DailyPasses
+	case models.RewindsCounter:
+		return s.limits.DailyRewinds
+	case models.SuperLikesCounter:
+		return s.limits.DailySuperLikes
+	}
+	if user.DailySwipeBudget > 0 {
+		return user.DailySwipeBudget
+	}
+	return s.limits.DailyLikes
+}
+
+// swipeCounter returns the daily quota a swipe of the given kind counts towards.
+func swipeCounter(kind models.SwipeKind) models.SwipeCounter {
+	switch kind {
+	case models.SuperLikeSwipe:
+		return models.SuperLikesCounter
+	case models.LikeSwipe:
+		return models.LikesCounter
+	}
+	return models.PassesCounter
+}
+
+// quotaPeriod returns the user's current swipe usage: the stored one until it resets, then an
+// unused one of their current local date. A time zone change only takes effect from the reset.
+func (s *SwipeService) quotaPeriod(user models.User) models.SwipeUsage {
+	now := s.now()
+	if user.SwipeUsage != nil && user.SwipeUsage.ResetsAt.After(now) {
+		return *user.SwipeUsage
+	}
+	day, resetsAt := s.quotaDayAt(user, now)
+	return models.SwipeUsage{Day: day, ResetsAt: resetsAt}
+}
+
+// quotaDayAt returns the user's local date at the given time and the local midnight that ends it.
+// Users without a valid time zone use UTC.
+func (s *SwipeService) quotaDayAt(user models.User, at time.Time) (string, time.Time) {
+	location, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	local := at.In(location)
+	year, month, day := local.Date()
+	return local.Format(time.DateOnly), time.Date(year, month, day+1, 0, 0, 0, 0, location)
+}
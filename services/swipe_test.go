@@ -1,16 +1,31 @@
 package services
 
 import (
+	"api/constants"
 	"api/models"
 	"api/repository"
 	"api/store"
+	"api/utils"
 	"context"
+	"encoding/json"
+	"errors"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"sync"
 	"testing"
+	"time"
 )
 
+var testSwipeLimits = models.SwipeLimits{DailyLikes: 100, DailyPasses: 500, DailySuperLikes: 1, DailyRewinds: 3, RewindWindow: 5 * time.Minute}
+
+// noBlocks returns a block repository in which no user blocked anyone.
+func noBlocks() *repository.MockBlockRepository {
+	blockRepo := new(repository.MockBlockRepository)
+	blockRepo.On("IsBlocked", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+	return blockRepo
+}
+
 func TestSwipeService_Swipe(t *testing.T) {
 	// Mock repositories
 	userRepo := new(repository.MockUserRepository)
@@ -21,7 +36,7 @@ func TestSwipeService_Swipe(t *testing.T) {
 	eventStore := store.NewEventStore(logrus.New())
 	logger := logrus.New()
 
-	swipeService := NewSwipeService(eventStore, logger, swipeRepo, matchRepo, userRepo)
+	swipeService := NewSwipeService(eventStore, logger, swipeRepo, matchRepo, userRepo, noBlocks(), testSwipeLimits)
 
 	// Test case data
 	userID := "user123"
@@ -33,12 +48,13 @@ func TestSwipeService_Swipe(t *testing.T) {
 
 	// Mock repository expectations
 	userRepo.On("GetUserById", mock.Anything, prospectID).Return(&models.User{}, nil)
+	userRepo.On("IncrementSwipeUsage", mock.Anything, userID, mock.Anything, mock.Anything, models.LikesCounter, testSwipeLimits.DailyLikes).Return(&models.User{}, nil)
 	swipeRepo.On("GetSwipeByUserAndProspect", mock.Anything, prospectID, userID).Return(&models.Swipe{}, nil)
 	swipeRepo.On("CreateSwipe", mock.Anything, mock.AnythingOfType("*models.Swipe")).Return(&models.Swipe{}, nil)
 	matchRepo.On("CreateMatch", mock.Anything, mock.AnythingOfType("*models.Match")).Return(&models.Match{}, nil).Maybe() // Adjusted to expect exactly one call
 
 	// Perform the swipe
-	response, err := swipeService.Swipe(context.Background(), userID, payload)
+	response, err := swipeService.Swipe(context.Background(), models.User{ID: userID}, payload)
 
 	// Assertions
 	assert.NoError(t, err)
@@ -50,3 +66,771 @@ func TestSwipeService_Swipe(t *testing.T) {
 	swipeRepo.AssertExpectations(t)
 	matchRepo.AssertExpectations(t)
 }
+
+// memorySwipeRepository stores swipes in memory with the unique user and prospect constraint of
+// the swipes collection. When inserted is set, CreateSwipe waits for it, which lets a test hold
+// every swiper between storing their swipe and reading the prospect's.
+type memorySwipeRepository struct {
+	repository.MockSwipeRepository
+	mu       sync.Mutex
+	swipes   map[[2]string]*models.Swipe
+	inserted *sync.WaitGroup
+}
+
+func (r *memorySwipeRepository) CreateSwipe(_ context.Context, swipe *models.Swipe) (*models.Swipe, error) {
+	r.mu.Lock()
+	key := [2]string{swipe.UserID, swipe.ProspectID}
+	if _, ok := r.swipes[key]; ok {
+		r.mu.Unlock()
+		return nil, repository.ErrDuplicateFound
+	}
+	r.swipes[key] = swipe
+	r.mu.Unlock()
+
+	if r.inserted != nil {
+		r.inserted.Done()
+		r.inserted.Wait()
+	}
+	return swipe, nil
+}
+
+func (r *memorySwipeRepository) GetSwipeByUserAndProspect(_ context.Context, userID, prospectID string) (*models.Swipe, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.swipes[[2]string{userID, prospectID}], nil
+}
+
+func (r *memorySwipeRepository) GetLatestSwipe(_ context.Context, userID string) (*models.Swipe, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var latest *models.Swipe
+	for _, swipe := range r.swipes {
+		if swipe.UserID == userID && (latest == nil || swipe.SwipeTime.After(latest.SwipeTime)) {
+			latest = swipe
+		}
+	}
+	return latest, nil
+}
+
+func (r *memorySwipeRepository) DeleteSwipe(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, swipe := range r.swipes {
+		if swipe.ID == id {
+			delete(r.swipes, key)
+		}
+	}
+	return nil
+}
+
+// memoryMatchRepository upserts matches in memory by pair key, like the matches collection.
+type memoryMatchRepository struct {
+	repository.MockMatchRepository
+	mu      sync.Mutex
+	matches map[string]*models.Match
+}
+
+func (r *memoryMatchRepository) CreateMatch(_ context.Context, match *models.Match) (*models.Match, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	match.PairKey = models.MatchPairKey(match.Profiles)
+	if existing, ok := r.matches[match.PairKey]; ok {
+		return existing, nil
+	}
+	r.matches[match.PairKey] = match
+	return match, nil
+}
+
+func (r *memoryMatchRepository) GetMatchByProfiles(_ context.Context, profiles []string) (*models.Match, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.matches[models.MatchPairKey(profiles)], nil
+}
+
+func (r *memoryMatchRepository) EndMatch(_ context.Context, id, userID string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, match := range r.matches {
+		if match.ID == id && match.Matched {
+			match.Matched, match.UnmatchedAt, match.UnmatchedBy = false, at, userID
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestSwipeService_Swipe_ConcurrentMutualLikesMatchOnce(t *testing.T) {
+	for _, holdAfterInsert := range []bool{true, false} {
+		for i := 0; i < 50; i++ {
+			userRepo := new(repository.MockUserRepository)
+			userRepo.On("GetUserById", mock.Anything, mock.Anything).Return(&models.User{}, nil)
+			userRepo.On("IncrementSwipeUsage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, models.LikesCounter, mock.Anything).Return(&models.User{}, nil)
+			swipeRepo := &memorySwipeRepository{swipes: make(map[[2]string]*models.Swipe)}
+			if holdAfterInsert {
+				swipeRepo.inserted = new(sync.WaitGroup)
+				swipeRepo.inserted.Add(2)
+			}
+			matchRepo := &memoryMatchRepository{matches: make(map[string]*models.Match)}
+			swipeService := NewSwipeService(store.NewEventStore(logrus.New()), logrus.New(), swipeRepo, matchRepo, userRepo, noBlocks(), testSwipeLimits)
+
+			responses := make([]*models.SwipeResponse, 2)
+			var wg sync.WaitGroup
+			for n, pair := range [][2]string{{"alice", "bob"}, {"bob", "alice"}} {
+				wg.Add(1)
+				go func(n int, userID, prospectID string) {
+					defer wg.Done()
+					response, err := swipeService.Swipe(context.Background(), models.User{ID: userID}, models.SwipePayload{ProspectID: prospectID, Interested: true})
+					assert.NoError(t, err)
+					responses[n] = response
+				}(n, pair[0], pair[1])
+			}
+			wg.Wait()
+
+			assert.Len(t, matchRepo.matches, 1)
+			match := matchRepo.matches[models.MatchPairKey([]string{"alice", "bob"})]
+			if assert.NotNil(t, match) {
+				for _, response := range responses {
+					if response.Matched {
+						assert.Equal(t, match.ID, response.MatchID)
+					}
+				}
+			}
+			if holdAfterInsert {
+				// Both swipes were stored before either was checked, so both swipers see the match.
+				assert.True(t, responses[0].Matched)
+				assert.True(t, responses[1].Matched)
+			}
+		}
+	}
+}
+
+func TestSwipeService_Swipe_FailedMatchCanBeRetried(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("GetUserById", mock.Anything, mock.Anything).Return(&models.User{}, nil)
+	userRepo.On("IncrementSwipeUsage", mock.Anything, "alice", mock.Anything, mock.Anything, models.LikesCounter, mock.Anything).Return(&models.User{}, nil)
+	userRepo.On("DecrementSwipeUsage", mock.Anything, "alice", mock.Anything, models.LikesCounter).Return(nil)
+	swipeRepo := &memorySwipeRepository{swipes: map[[2]string]*models.Swipe{
+		{"bob", "alice"}: {ID: "s1", UserID: "bob", ProspectID: "alice", Interested: true},
+	}}
+	matchRepo := new(repository.MockMatchRepository)
+	matchRepo.On("CreateMatch", mock.Anything, mock.Anything).Return((*models.Match)(nil), errors.New("connection reset")).Once()
+	matchRepo.On("CreateMatch", mock.Anything, mock.Anything).Return(&models.Match{ID: "m1"}, nil).Once()
+	swipeService := NewSwipeService(store.NewEventStore(logrus.New()), logrus.New(), swipeRepo, matchRepo, userRepo, noBlocks(), testSwipeLimits)
+	payload := models.SwipePayload{ProspectID: "bob", Interested: true}
+
+	// The swipe that made no match is taken back with its quota, so it can be retried.
+	_, err := swipeService.Swipe(context.Background(), models.User{ID: "alice"}, payload)
+	assert.ErrorIs(t, err, ErrFailedCreateMatch)
+	userRepo.AssertCalled(t, "DecrementSwipeUsage", mock.Anything, "alice", mock.Anything, models.LikesCounter)
+
+	response, err := swipeService.Swipe(context.Background(), models.User{ID: "alice"}, payload)
+	assert.NoError(t, err)
+	assert.True(t, response.Matched)
+	assert.Equal(t, "m1", response.MatchID)
+}
+
+func TestSwipeService_Swipe_PublishesMatchCreated(t *testing.T) {
+	eventStore := store.NewEventStore(logrus.New())
+	events := make(chan models.MatchEvent, 2)
+	assert.NoError(t, eventStore.Subscribe(constants.MatchCreatedTopic, func(event store.Event) error {
+		var data models.MatchEvent
+		assert.NoError(t, json.Unmarshal(event.Data(), &data))
+		events <- data
+		return nil
+	}))
+
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("GetUserById", mock.Anything, mock.Anything).Return(&models.User{}, nil)
+	userRepo.On("IncrementSwipeUsage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, models.LikesCounter, mock.Anything).Return(&models.User{}, nil)
+	swipeRepo := &memorySwipeRepository{swipes: make(map[[2]string]*models.Swipe)}
+	matchRepo := &memoryMatchRepository{matches: make(map[string]*models.Match)}
+	swipeService := NewSwipeService(eventStore, logrus.New(), swipeRepo, matchRepo, userRepo, noBlocks(), testSwipeLimits)
+
+	_, err := swipeService.Swipe(context.Background(), models.User{ID: "alice"}, models.SwipePayload{ProspectID: "bob", Interested: true})
+	assert.NoError(t, err)
+	response, err := swipeService.Swipe(context.Background(), models.User{ID: "bob"}, models.SwipePayload{ProspectID: "alice", Interested: true})
+	assert.NoError(t, err)
+	assert.True(t, response.Matched)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, response.MatchID, event.MatchID)
+		assert.ElementsMatch(t, []string{"alice", "bob"}, event.Profiles)
+	case <-time.After(time.Second):
+		t.Fatal("no match.created event published")
+	}
+
+	// A match that was already stored is not announced again.
+	delete(swipeRepo.swipes, [2]string{"bob", "alice"})
+	_, err = swipeService.Swipe(context.Background(), models.User{ID: "bob"}, models.SwipePayload{ProspectID: "alice", Interested: true})
+	assert.NoError(t, err)
+	select {
+	case event := <-events:
+		t.Fatalf("match.created published twice for %s", event.MatchID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSwipeService_Swipe_AlreadySwiped(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("GetUserById", mock.Anything, "bob").Return(&models.User{}, nil)
+	userRepo.On("IncrementSwipeUsage", mock.Anything, "alice", mock.Anything, mock.Anything, models.LikesCounter, testSwipeLimits.DailyLikes).Return(&models.User{}, nil)
+	userRepo.On("DecrementSwipeUsage", mock.Anything, "alice", mock.Anything, models.LikesCounter).Return(nil).Once()
+	swipeRepo := &memorySwipeRepository{swipes: make(map[[2]string]*models.Swipe)}
+	matchRepo := &memoryMatchRepository{matches: make(map[string]*models.Match)}
+	swipeService := NewSwipeService(store.NewEventStore(logrus.New()), logrus.New(), swipeRepo, matchRepo, userRepo, noBlocks(), testSwipeLimits)
+
+	_, err := swipeService.Swipe(context.Background(), models.User{ID: "alice"}, models.SwipePayload{ProspectID: "bob", Interested: true})
+	assert.NoError(t, err)
+
+	_, err = swipeService.Swipe(context.Background(), models.User{ID: "alice"}, models.SwipePayload{ProspectID: "bob", Interested: true})
+	assert.ErrorIs(t, err, ErrAlreadySwiped)
+	// The rejected swipe gives its quota back.
+	userRepo.AssertExpectations(t)
+}
+
+func TestSwipeService_Swipe_QuotaExceeded(t *testing.T) {
+	// 23:30 in New York on 16 October is already 17 October in UTC.
+	now := time.Date(2026, 10, 17, 3, 30, 0, 0, time.UTC)
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	testCases := []struct {
+		name          string
+		user          models.User
+		interested    bool
+		expectedKind  models.SwipeCounter
+		expectedLimit int
+	}{
+		{name: "likes", user: models.User{ID: "alice", Timezone: "America/New_York"}, interested: true, expectedKind: models.LikesCounter, expectedLimit: testSwipeLimits.DailyLikes},
+		{name: "passes", user: models.User{ID: "alice", Timezone: "America/New_York"}, interested: false, expectedKind: models.PassesCounter, expectedLimit: testSwipeLimits.DailyPasses},
+		{name: "own swipe budget", user: models.User{ID: "alice", Timezone: "America/New_York", DailySwipeBudget: 10}, interested: true, expectedKind: models.LikesCounter, expectedLimit: 10},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(repository.MockUserRepository)
+			userRepo.On("GetUserById", mock.Anything, "bob").Return(&models.User{}, nil)
+			userRepo.On("IncrementSwipeUsage", mock.Anything, "alice", models.SwipeUsage{Day: "2026-10-16", ResetsAt: time.Date(2026, 10, 17, 0, 0, 0, 0, newYork)}, now, tc.expectedKind, tc.expectedLimit).Return((*models.User)(nil), nil)
+			swipeRepo := new(repository.MockSwipeRepository)
+			swipeService := NewSwipeService(store.NewEventStore(logrus.New()), logrus.New(), swipeRepo, new(repository.MockMatchRepository), userRepo, noBlocks(), testSwipeLimits)
+			swipeService.now = func() time.Time { return now }
+
+			_, err := swipeService.Swipe(context.Background(), tc.user, models.SwipePayload{ProspectID: "bob", Interested: tc.interested})
+
+			var quotaErr *QuotaExceededError
+			if assert.ErrorAs(t, err, &quotaErr) {
+				assert.Equal(t, tc.expectedKind, quotaErr.Kind)
+				assert.Equal(t, tc.expectedLimit, quotaErr.Limit)
+				assert.True(t, quotaErr.ResetsAt.Equal(time.Date(2026, 10, 17, 0, 0, 0, 0, newYork)))
+			}
+			userRepo.AssertExpectations(t)
+			swipeRepo.AssertNotCalled(t, "CreateSwipe", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSwipeService_Swipe_TimezoneChangeDoesNotRefillQuota(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	resetsAt := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	// Alice used up her likes in UTC, then moved to Kiritimati, where it is already 18 October.
+	alice := models.User{ID: "alice", Timezone: "Pacific/Kiritimati",
+		SwipeUsage: &models.SwipeUsage{Day: "2026-10-17", ResetsAt: resetsAt, Likes: testSwipeLimits.DailyLikes}}
+
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("GetUserById", mock.Anything, "bob").Return(&models.User{}, nil)
+	// The new date is only used once the stored usage resets, which it has not; nothing is counted.
+	kiritimatiMidnight := time.Date(2026, 10, 19, 0, 0, 0, 0, time.FixedZone("LINT", 14*60*60))
+	userRepo.On("IncrementSwipeUsage", mock.Anything, "alice", mock.MatchedBy(func(period models.SwipeUsage) bool {
+		return period.Day == "2026-10-18" && period.ResetsAt.Equal(kiritimatiMidnight)
+	}), now, models.LikesCounter, testSwipeLimits.DailyLikes).Return((*models.User)(nil), nil)
+	swipeRepo := new(repository.MockSwipeRepository)
+	swipeService := NewSwipeService(store.NewEventStore(logrus.New()), logrus.New(), swipeRepo, new(repository.MockMatchRepository), userRepo, noBlocks(), testSwipeLimits)
+	swipeService.now = func() time.Time { return now }
+
+	_, err := swipeService.Swipe(context.Background(), alice, models.SwipePayload{ProspectID: "bob", Kind: models.LikeSwipe})
+
+	var quotaErr *QuotaExceededError
+	if assert.ErrorAs(t, err, &quotaErr) {
+		assert.True(t, quotaErr.ResetsAt.Equal(resetsAt), "resets at %s", quotaErr.ResetsAt)
+	}
+	assert.Equal(t, 0, swipeService.GetQuota(alice).LikesRemaining)
+	userRepo.AssertExpectations(t)
+	swipeRepo.AssertNotCalled(t, "CreateSwipe", mock.Anything, mock.Anything)
+}
+
+func TestSwipeService_GetQuota(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	swipeService := NewSwipeService(store.NewEventStore(logrus.New()), logrus.New(), nil, nil, nil, nil, testSwipeLimits)
+	swipeService.now = func() time.Time { return now }
+
+	testCases := []struct {
+		name     string
+		user     models.User
+		expected models.SwipeQuota
+	}{
+		{
+			name: "no swipes yet",
+			user: models.User{},
+			expected: models.SwipeQuota{LikeLimit: 100, LikesRemaining: 100, PassLimit: 500, PassesRemaining: 500, RewindLimit: 3, RewindsRemaining: 3,
+				SuperLikeLimit: 1, SuperLikesRemaining: 1,
+				ResetsAt: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "swipes today",
+			user: models.User{SwipeUsage: &models.SwipeUsage{Day: "2026-10-17", ResetsAt: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), Likes: 40, Passes: 501}},
+			expected: models.SwipeQuota{LikeLimit: 100, LikesRemaining: 60, PassLimit: 500, PassesRemaining: 0, RewindLimit: 3, RewindsRemaining: 3,
+				SuperLikeLimit: 1, SuperLikesRemaining: 1,
+				ResetsAt: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "swipes on an earlier day",
+			user: models.User{SwipeUsage: &models.SwipeUsage{Day: "2026-10-16", ResetsAt: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), Likes: 100, Passes: 20}},
+			expected: models.SwipeQuota{LikeLimit: 100, LikesRemaining: 100, PassLimit: 500, PassesRemaining: 500, RewindLimit: 3, RewindsRemaining: 3,
+				SuperLikeLimit: 1, SuperLikesRemaining: 1,
+				ResetsAt: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "own swipe budget in another time zone",
+			user: models.User{Timezone: "Asia/Tokyo", DailySwipeBudget: 10, SwipeUsage: &models.SwipeUsage{Day: "2026-10-17", ResetsAt: time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC), Likes: 4}},
+			expected: models.SwipeQuota{LikeLimit: 10, LikesRemaining: 6, PassLimit: 500, PassesRemaining: 500, RewindLimit: 3, RewindsRemaining: 3,
+				SuperLikeLimit: 1, SuperLikesRemaining: 1,
+				ResetsAt: time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)},
+		},
+		{
+			// It is already the next day in Kiritimati, but the quota used up in UTC stays used up
+			// until the UTC midnight it was due to reset at.
+			name: "time zone changed mid-day",
+			user: models.User{Timezone: "Pacific/Kiritimati", SwipeUsage: &models.SwipeUsage{Day: "2026-10-17", ResetsAt: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), Likes: 100, Passes: 500, SuperLikes: 1}},
+			expected: models.SwipeQuota{LikeLimit: 100, LikesRemaining: 0, PassLimit: 500, PassesRemaining: 0, RewindLimit: 3, RewindsRemaining: 3,
+				SuperLikeLimit: 1, SuperLikesRemaining: 0,
+				ResetsAt: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			quota := swipeService.GetQuota(tc.user)
+			assert.True(t, tc.expected.ResetsAt.Equal(quota.ResetsAt), "resets at %s", quota.ResetsAt)
+			quota.ResetsAt = tc.expected.ResetsAt
+			assert.Equal(t, tc.expected, *quota)
+		})
+	}
+}
+
+func TestSwipeService_Undo(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	recentPass := &models.Swipe{ID: "s1", UserID: "alice", ProspectID: "bob", SwipeTime: now.Add(-time.Minute)}
+	recentLike := &models.Swipe{ID: "s1", UserID: "alice", ProspectID: "bob", Interested: true, SwipeTime: now.Add(-time.Minute)}
+	oldPass := &models.Swipe{ID: "s1", UserID: "alice", ProspectID: "bob", SwipeTime: now.Add(-time.Hour)}
+	match := &models.Match{ID: "m1", Profiles: []string{"bob", "alice"}, Matched: true}
+	unmatched := &models.Match{ID: "m1", Profiles: []string{"bob", "alice"}}
+	messaged := &models.Match{ID: "m1", Profiles: []string{"bob", "alice"}, Matched: true, MatchedAt: now.Add(-time.Minute), LastActivityAt: now.Add(-time.Second)}
+	extended := &models.Match{ID: "m1", Profiles: []string{"bob", "alice"}, Matched: true, ExtendedBy: []string{"bob"}}
+
+	testCases := []struct {
+		name            string
+		latest          *models.Swipe
+		match           *models.Match
+		rewindCounted   *models.User
+		expectedErr     error
+		expectedCounter models.SwipeCounter
+		matchUsedSince  bool
+		rollsBackMatch  bool
+	}{
+		{name: "pass", latest: recentPass, rewindCounted: &models.User{}, expectedCounter: models.PassesCounter},
+		{name: "like without a match", latest: recentLike, rewindCounted: &models.User{}, expectedCounter: models.LikesCounter},
+		{name: "like that made a match", latest: recentLike, match: match, rewindCounted: &models.User{}, expectedCounter: models.LikesCounter, rollsBackMatch: true},
+		{name: "like whose match got a message meanwhile", latest: recentLike, match: match, matchUsedSince: true, rewindCounted: &models.User{}, expectedErr: ErrCannotUndoActiveMatch},
+		{name: "like whose match has ended", latest: recentLike, match: unmatched, expectedErr: ErrCannotUndoUnmatched},
+		{name: "like whose match has messages", latest: recentLike, match: messaged, expectedErr: ErrCannotUndoActiveMatch},
+		{name: "like whose match was extended", latest: recentLike, match: extended, expectedErr: ErrCannotUndoActiveMatch},
+		{name: "no swipes", expectedErr: ErrNothingToUndo},
+		{name: "outside the rewind window", latest: oldPass, expectedErr: ErrUndoWindowPassed},
+		{name: "no rewinds left", latest: recentPass, expectedErr: &QuotaExceededError{Kind: models.RewindsCounter, Limit: 3, ResetsAt: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			eventStore := store.NewEventStore(logrus.New())
+			events := make(chan store.Event, 1)
+			assert.NoError(t, eventStore.Subscribe(constants.MatchUnmatchedTopic, func(event store.Event) error {
+				events <- event
+				return nil
+			}))
+
+			userRepo := new(repository.MockUserRepository)
+			userRepo.On("IncrementSwipeUsage", mock.Anything, "alice", models.SwipeUsage{Day: "2026-10-17", ResetsAt: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)}, now, models.RewindsCounter, 3).Return(tc.rewindCounted, nil).Maybe()
+			userRepo.On("DecrementSwipeUsage", mock.Anything, "alice", "2026-10-17", tc.expectedCounter).Return(nil).Maybe()
+			userRepo.On("DecrementSwipeUsage", mock.Anything, "alice", "2026-10-17", models.RewindsCounter).Return(nil).Maybe()
+			swipeRepo := new(repository.MockSwipeRepository)
+			swipeRepo.On("GetLatestSwipe", mock.Anything, "alice").Return(tc.latest, nil)
+			swipeRepo.On("DeleteSwipe", mock.Anything, "s1").Return(nil).Maybe()
+			matchRepo := new(repository.MockMatchRepository)
+			matchRepo.On("GetMatchByProfiles", mock.Anything, []string{"alice", "bob"}).Return(tc.match, nil).Maybe()
+			matchRepo.On("DeleteQuietMatch", mock.Anything, "m1").Return(!tc.matchUsedSince, nil).Maybe()
+
+			swipeService := NewSwipeService(eventStore, logrus.New(), swipeRepo, matchRepo, userRepo, noBlocks(), testSwipeLimits)
+			swipeService.now = func() time.Time { return now }
+
+			undone, err := swipeService.Undo(context.Background(), models.User{ID: "alice"})
+
+			if tc.expectedErr != nil {
+				assert.Equal(t, tc.expectedErr, err)
+				swipeRepo.AssertNotCalled(t, "DeleteSwipe", mock.Anything, mock.Anything)
+				if tc.matchUsedSince {
+					// The match got a message after the checks: the rewind is given back, and
+					// the swipe stays with its match.
+					userRepo.AssertCalled(t, "DecrementSwipeUsage", mock.Anything, "alice", "2026-10-17", models.RewindsCounter)
+				} else {
+					matchRepo.AssertNotCalled(t, "DeleteQuietMatch", mock.Anything, mock.Anything)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.latest, undone)
+			swipeRepo.AssertCalled(t, "DeleteSwipe", mock.Anything, "s1")
+			// The undone swipe no longer counts towards the day's quota.
+			userRepo.AssertCalled(t, "DecrementSwipeUsage", mock.Anything, "alice", "2026-10-17", tc.expectedCounter)
+			if tc.rollsBackMatch {
+				matchRepo.AssertCalled(t, "DeleteQuietMatch", mock.Anything, "m1")
+				select {
+				case <-events:
+				case <-time.After(time.Second):
+					t.Fatal("no match.unmatched event published")
+				}
+				return
+			}
+			// Without a match to roll back, nothing is announced.
+			select {
+			case event := <-events:
+				t.Fatalf("unexpected match.unmatched event %s", event.Data())
+			case <-time.After(50 * time.Millisecond):
+			}
+			if tc.match == nil {
+				matchRepo.AssertNotCalled(t, "DeleteQuietMatch", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestSwipePayload_SwipeKind(t *testing.T) {
+	testCases := []struct {
+		name     string
+		payload  models.SwipePayload
+		expected models.SwipeKind
+		wantErr  bool
+	}{
+		{name: "interested without kind", payload: models.SwipePayload{ProspectID: "bob", Interested: true}, expected: models.LikeSwipe},
+		{name: "not interested without kind", payload: models.SwipePayload{ProspectID: "bob"}, expected: models.PassSwipe},
+		{name: "super-like", payload: models.SwipePayload{ProspectID: "bob", Kind: models.SuperLikeSwipe}, expected: models.SuperLikeSwipe},
+		{name: "kind wins over interested", payload: models.SwipePayload{ProspectID: "bob", Kind: models.PassSwipe, Interested: true}, expected: models.PassSwipe},
+		{name: "unknown kind", payload: models.SwipePayload{ProspectID: "bob", Kind: "maybe"}, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.payload.Validate()
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, tc.payload.SwipeKind())
+		})
+	}
+}
+
+func TestSwipeService_Swipe_SuperLike(t *testing.T) {
+	eventStore := store.NewEventStore(logrus.New())
+	events := make(chan models.SwipeEvent, 1)
+	assert.NoError(t, eventStore.Subscribe(constants.SwipeSuperLikedTopic, func(event store.Event) error {
+		var data models.SwipeEvent
+		assert.NoError(t, json.Unmarshal(event.Data(), &data))
+		events <- data
+		return nil
+	}))
+
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("GetUserById", mock.Anything, mock.Anything).Return(&models.User{}, nil)
+	userRepo.On("IncrementSwipeUsage", mock.Anything, "alice", mock.Anything, mock.Anything, models.SuperLikesCounter, testSwipeLimits.DailySuperLikes).Return(&models.User{}, nil)
+	userRepo.On("IncrementSwipeUsage", mock.Anything, "bob", mock.Anything, mock.Anything, models.LikesCounter, testSwipeLimits.DailyLikes).Return(&models.User{}, nil)
+	swipeRepo := &memorySwipeRepository{swipes: make(map[[2]string]*models.Swipe)}
+	matchRepo := &memoryMatchRepository{matches: make(map[string]*models.Match)}
+	swipeService := NewSwipeService(eventStore, logrus.New(), swipeRepo, matchRepo, userRepo, noBlocks(), testSwipeLimits)
+	swipedAt := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	swipeService.now = func() time.Time { return swipedAt }
+
+	response, err := swipeService.Swipe(context.Background(), models.User{ID: "alice"}, models.SwipePayload{ProspectID: "bob", Kind: models.SuperLikeSwipe})
+	assert.NoError(t, err)
+	assert.False(t, response.Matched)
+	stored := swipeRepo.swipes[[2]string{"alice", "bob"}]
+	assert.True(t, stored.Interested)
+	assert.Equal(t, models.SuperLikeSwipe, stored.Kind)
+
+	select {
+	case event := <-events:
+		assert.Equal(t, models.SwipeEvent{SwipeID: stored.ID, UserID: "alice", ProspectID: "bob", At: swipedAt}, event)
+	case <-time.After(time.Second):
+		t.Fatal("no swipe.super_liked event published")
+	}
+
+	// Bob likes back and learns that Alice's like was a super-like.
+	response, err = swipeService.Swipe(context.Background(), models.User{ID: "bob"}, models.SwipePayload{ProspectID: "alice", Kind: models.LikeSwipe})
+	assert.NoError(t, err)
+	assert.True(t, response.Matched)
+	assert.True(t, response.ProspectSuperLiked)
+	userRepo.AssertExpectations(t)
+}
+
+func TestSwipeService_GetReceivedLikes_Pagination(t *testing.T) {
+	swipeRepo := new(repository.MockSwipeRepository)
+	swipeService := NewSwipeService(store.NewEventStore(logrus.New()), logrus.New(), swipeRepo,
+		new(repository.MockMatchRepository), new(repository.MockUserRepository), noBlocks(), testSwipeLimits)
+
+	newest := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	likes := []*models.ReceivedLike{
+		{SwipeID: "s3", Kind: models.SuperLikeSwipe, LikedAt: newest, User: &models.User{ID: "carol"}},
+		{SwipeID: "s2", Kind: models.LikeSwipe, LikedAt: newest.Add(-time.Hour), User: &models.User{ID: "bob"}},
+		{SwipeID: "s1", Kind: models.LikeSwipe, LikedAt: newest.Add(-2 * time.Hour), User: &models.User{ID: "dave"}},
+	}
+	swipeRepo.On("GetReceivedLikes", mock.Anything, "alice", (*models.ReceivedLikeCursor)(nil), 3).Return(likes, nil)
+
+	page, nextCursor, err := swipeService.GetReceivedLikes(context.Background(), models.User{ID: "alice"}, models.Page{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, likes[:2], page)
+
+	var cursor models.ReceivedLikeCursor
+	assert.NoError(t, utils.DecodeCursor(nextCursor, &cursor))
+	assert.Equal(t, "s2", cursor.ID)
+	assert.True(t, cursor.LikedAt.Equal(likes[1].LikedAt))
+
+	swipeRepo.On("GetReceivedLikes", mock.Anything, "alice", &cursor, 3).Return(likes[2:], nil)
+	page, nextCursor, err = swipeService.GetReceivedLikes(context.Background(), models.User{ID: "alice"}, models.Page{Limit: 2, Cursor: nextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, likes[2:], page)
+	assert.Empty(t, nextCursor)
+
+	_, _, err = swipeService.GetReceivedLikes(context.Background(), models.User{ID: "alice"}, models.Page{Cursor: "not a cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestSwipeService_ListSwipes(t *testing.T) {
+	swipeRepo := new(repository.MockSwipeRepository)
+	swipeService := NewSwipeService(store.NewEventStore(logrus.New()), logrus.New(), swipeRepo,
+		new(repository.MockMatchRepository), new(repository.MockUserRepository), noBlocks(), testSwipeLimits)
+
+	newest := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	swipes := []*models.Swipe{
+		{ID: "s3", UserID: "alice", ProspectID: "carol", SwipeTime: newest},
+		{ID: "s2", UserID: "alice", ProspectID: "bob", SwipeTime: newest.Add(-time.Hour)},
+		{ID: "s1", UserID: "alice", ProspectID: "dave", SwipeTime: newest.Add(-2 * time.Hour)},
+	}
+	// Whatever user the filter names, only the caller's swipes are listed.
+	filter := models.SwipeFilter{UserID: "alice", MinSwipeDate: newest.Add(-24 * time.Hour)}
+	swipeRepo.On("ListSwipes", mock.Anything, filter, (*models.SwipeCursor)(nil), 3).Return(swipes, nil)
+
+	requested := filter
+	requested.UserID = "mallory"
+	page, nextCursor, err := swipeService.ListSwipes(context.Background(), models.User{ID: "alice"}, requested, models.Page{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, swipes[:2], page)
+
+	var cursor models.SwipeCursor
+	assert.NoError(t, utils.DecodeCursor(nextCursor, &cursor))
+	assert.Equal(t, "s2", cursor.ID)
+	assert.True(t, cursor.SwipeTime.Equal(swipes[1].SwipeTime))
+
+	swipeRepo.On("ListSwipes", mock.Anything, filter, &cursor, 3).Return(swipes[2:], nil)
+	page, nextCursor, err = swipeService.ListSwipes(context.Background(), models.User{ID: "alice"}, filter, models.Page{Limit: 2, Cursor: nextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, swipes[2:], page)
+	assert.Empty(t, nextCursor)
+}
+
+func TestSwipeService_Swipe_Blocked(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	swipeRepo := new(repository.MockSwipeRepository)
+	blockRepo := new(repository.MockBlockRepository)
+	swipeService := NewSwipeService(store.NewEventStore(logrus.New()), logrus.New(), swipeRepo,
+		new(repository.MockMatchRepository), userRepo, blockRepo, testSwipeLimits)
+
+	userRepo.On("GetUserById", mock.Anything, "bob").Return(&models.User{ID: "bob"}, nil)
+	blockRepo.On("IsBlocked", mock.Anything, "alice", "bob").Return(true, nil)
+
+	_, err := swipeService.Swipe(context.Background(), models.User{ID: "alice"}, models.SwipePayload{ProspectID: "bob", Kind: models.LikeSwipe})
+	assert.ErrorIs(t, err, ErrProspectBlocked)
+	// The swipe is rejected before it uses up any quota or is stored.
+	userRepo.AssertNotCalled(t, "IncrementSwipeUsage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	swipeRepo.AssertNotCalled(t, "CreateSwipe", mock.Anything, mock.Anything)
+}
+
+func TestSwipeService_Swipe_Deactivated(t *testing.T) {
+	testCases := []struct {
+		name        string
+		swiper      models.User
+		prospect    models.User
+		expectedErr error
+	}{
+		{name: "deactivated swiper", swiper: models.User{ID: "alice", Status: models.Deactivated}, prospect: models.User{ID: "bob"}, expectedErr: ErrSwiperDeactivated},
+		{name: "deactivated prospect", swiper: models.User{ID: "alice"}, prospect: models.User{ID: "bob", Status: models.Deactivated}, expectedErr: ErrProspectBlocked},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(repository.MockUserRepository)
+			swipeRepo := new(repository.MockSwipeRepository)
+			swipeService := NewSwipeService(store.NewEventStore(logrus.New()), logrus.New(), swipeRepo,
+				new(repository.MockMatchRepository), userRepo, noBlocks(), testSwipeLimits)
+
+			userRepo.On("GetUserById", mock.Anything, "bob").Return(&tc.prospect, nil)
+
+			_, err := swipeService.Swipe(context.Background(), tc.swiper, models.SwipePayload{ProspectID: "bob", Kind: models.LikeSwipe})
+			assert.ErrorIs(t, err, tc.expectedErr)
+			userRepo.AssertNotCalled(t, "IncrementSwipeUsage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			swipeRepo.AssertNotCalled(t, "CreateSwipe", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSwipeService_Swipe_PublishesLiked(t *testing.T) {
+	eventStore := store.NewEventStore(logrus.New())
+	events := make(chan models.SwipeEvent, 2)
+	assert.NoError(t, eventStore.Subscribe(constants.SwipeLikedTopic, func(event store.Event) error {
+		var data models.SwipeEvent
+		assert.NoError(t, json.Unmarshal(event.Data(), &data))
+		events <- data
+		return nil
+	}))
+
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("GetUserById", mock.Anything, mock.Anything).Return(&models.User{}, nil)
+	userRepo.On("IncrementSwipeUsage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, models.LikesCounter, mock.Anything).Return(&models.User{}, nil)
+	swipeRepo := &memorySwipeRepository{swipes: make(map[[2]string]*models.Swipe)}
+	matchRepo := &memoryMatchRepository{matches: make(map[string]*models.Match)}
+	swipeService := NewSwipeService(eventStore, logrus.New(), swipeRepo, matchRepo, userRepo, noBlocks(), testSwipeLimits)
+
+	_, err := swipeService.Swipe(context.Background(), models.User{ID: "alice"}, models.SwipePayload{ProspectID: "bob", Interested: true})
+	assert.NoError(t, err)
+	select {
+	case event := <-events:
+		assert.Equal(t, "alice", event.UserID)
+		assert.Equal(t, "bob", event.ProspectID)
+	case <-time.After(time.Second):
+		t.Fatal("no swipe.liked event published")
+	}
+
+	// Liking back makes a match, which is announced as such.
+	_, err = swipeService.Swipe(context.Background(), models.User{ID: "bob"}, models.SwipePayload{ProspectID: "alice", Interested: true})
+	assert.NoError(t, err)
+	select {
+	case event := <-events:
+		t.Fatalf("swipe.liked published for the like that made a match with %s", event.ProspectID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSwipeService_Swipe_Self(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	swipeRepo := new(repository.MockSwipeRepository)
+	matchRepo := new(repository.MockMatchRepository)
+	swipeService := NewSwipeService(store.NewEventStore(logrus.New()), logrus.New(), swipeRepo,
+		matchRepo, userRepo, noBlocks(), testSwipeLimits)
+
+	_, err := swipeService.Swipe(context.Background(), models.User{ID: "alice"}, models.SwipePayload{ProspectID: "alice", Kind: models.LikeSwipe})
+	assert.ErrorIs(t, err, ErrCannotSwipeSelf)
+	_, err = swipeService.LikeBack(context.Background(), models.User{ID: "alice"}, "alice")
+	assert.ErrorIs(t, err, ErrCannotSwipeSelf)
+
+	// Neither uses up any quota, is stored or makes a match.
+	userRepo.AssertNotCalled(t, "IncrementSwipeUsage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	swipeRepo.AssertNotCalled(t, "CreateSwipe", mock.Anything, mock.Anything)
+	matchRepo.AssertNotCalled(t, "CreateMatch", mock.Anything, mock.Anything)
+}
+
+func TestSwipeService_Swipe_BlockedWhileMatching(t *testing.T) {
+	eventStore := store.NewEventStore(logrus.New())
+	created := make(chan store.Event, 1)
+	assert.NoError(t, eventStore.Subscribe(constants.MatchCreatedTopic, func(event store.Event) error {
+		created <- event
+		return nil
+	}))
+
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("GetUserById", mock.Anything, "bob").Return(&models.User{ID: "bob"}, nil)
+	userRepo.On("IncrementSwipeUsage", mock.Anything, "alice", mock.Anything, mock.Anything, models.LikesCounter, mock.Anything).Return(&models.User{}, nil)
+	userRepo.On("DecrementSwipeUsage", mock.Anything, "alice", mock.Anything, models.LikesCounter).Return(nil)
+	swipeRepo := &memorySwipeRepository{swipes: map[[2]string]*models.Swipe{
+		{"bob", "alice"}: {ID: "s1", UserID: "bob", ProspectID: "alice", Interested: true},
+	}}
+	matchRepo := &memoryMatchRepository{matches: make(map[string]*models.Match)}
+	// Bob blocks Alice after her swipe was checked, but before its match was stored.
+	blockRepo := new(repository.MockBlockRepository)
+	blockRepo.On("IsBlocked", mock.Anything, "alice", "bob").Return(false, nil).Once()
+	blockRepo.On("IsBlocked", mock.Anything, "alice", "bob").Return(true, nil)
+	swipeService := NewSwipeService(eventStore, logrus.New(), swipeRepo, matchRepo, userRepo, blockRepo, testSwipeLimits)
+
+	_, err := swipeService.Swipe(context.Background(), models.User{ID: "alice"}, models.SwipePayload{ProspectID: "bob", Interested: true})
+	assert.ErrorIs(t, err, ErrProspectBlocked)
+
+	// The match is dissolved and never announced, and the swipe is taken back with its quota.
+	match := matchRepo.matches[models.MatchPairKey([]string{"alice", "bob"})]
+	if assert.NotNil(t, match) {
+		assert.False(t, match.Matched)
+	}
+	assert.NotContains(t, swipeRepo.swipes, [2]string{"alice", "bob"})
+	userRepo.AssertCalled(t, "DecrementSwipeUsage", mock.Anything, "alice", mock.Anything, models.LikesCounter)
+	select {
+	case event := <-created:
+		t.Fatalf("match.created published for a blocked pair: %s", event.Data())
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSwipeService_LikeBack(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("GetUserById", mock.Anything, mock.Anything).Return(&models.User{}, nil)
+	userRepo.On("IncrementSwipeUsage", mock.Anything, "alice", mock.Anything, mock.Anything, models.LikesCounter, mock.Anything).Return(&models.User{}, nil)
+	swipeRepo := &memorySwipeRepository{swipes: map[[2]string]*models.Swipe{
+		{"bob", "alice"}:   {ID: "s1", UserID: "bob", ProspectID: "alice", Interested: true},
+		{"carol", "alice"}: {ID: "s2", UserID: "carol", ProspectID: "alice", Interested: false},
+	}}
+	matchRepo := &memoryMatchRepository{matches: make(map[string]*models.Match)}
+	swipeService := NewSwipeService(store.NewEventStore(logrus.New()), logrus.New(), swipeRepo, matchRepo, userRepo, noBlocks(), testSwipeLimits)
+
+	// Only a user who liked Alice can be liked back; a pass or no swipe at all is no like.
+	for _, likerID := range []string{"carol", "dave"} {
+		_, err := swipeService.LikeBack(context.Background(), models.User{ID: "alice"}, likerID)
+		assert.ErrorIs(t, err, ErrLikeNotFound)
+	}
+	userRepo.AssertNotCalled(t, "IncrementSwipeUsage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	assert.NotContains(t, swipeRepo.swipes, [2]string{"alice", "dave"})
+
+	response, err := swipeService.LikeBack(context.Background(), models.User{ID: "alice"}, "bob")
+	assert.NoError(t, err)
+	assert.True(t, response.Matched)
+}
+
+func TestSwipeService_Undo_GivesBackTheDayTheSwipeWasCountedIn(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("GetUserById", mock.Anything, "bob").Return(&models.User{ID: "bob"}, nil)
+	// Alice swiped while her usage still ran on her previous time zone's date.
+	userRepo.On("IncrementSwipeUsage", mock.Anything, "alice", mock.Anything, now, models.PassesCounter, mock.Anything).
+		Return(&models.User{SwipeUsage: &models.SwipeUsage{Day: "2026-10-16"}}, nil)
+	userRepo.On("IncrementSwipeUsage", mock.Anything, "alice", mock.Anything, now, models.RewindsCounter, mock.Anything).Return(&models.User{}, nil)
+	userRepo.On("DecrementSwipeUsage", mock.Anything, "alice", "2026-10-16", models.PassesCounter).Return(nil)
+	swipeRepo := &memorySwipeRepository{swipes: make(map[[2]string]*models.Swipe)}
+	swipeService := NewSwipeService(store.NewEventStore(logrus.New()), logrus.New(), swipeRepo, new(repository.MockMatchRepository), userRepo, noBlocks(), testSwipeLimits)
+	swipeService.now = func() time.Time { return now }
+
+	alice := models.User{ID: "alice", Timezone: "Pacific/Auckland"}
+	_, err := swipeService.Swipe(context.Background(), alice, models.SwipePayload{ProspectID: "bob", Kind: models.PassSwipe})
+	assert.NoError(t, err)
+	swipe := swipeRepo.swipes[[2]string{"alice", "bob"}]
+	if assert.NotNil(t, swipe) {
+		assert.Equal(t, "2026-10-16", swipe.QuotaDay)
+	}
+
+	_, err = swipeService.Undo(context.Background(), alice)
+	assert.NoError(t, err)
+	userRepo.AssertCalled(t, "DecrementSwipeUsage", mock.Anything, "alice", "2026-10-16", models.PassesCounter)
+}
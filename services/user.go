@@ -8,9 +8,11 @@ import (
 	"api/utils"
 	"context"
 	"errors"
+	"fmt"
 	"github.com/bxcodec/faker/v3"
 	"github.com/gbrlsnchs/jwt/v3"
 	"github.com/sirupsen/logrus"
+	"sort"
 	"strings"
 	"time"
 )
@@ -23,34 +25,76 @@ var (
 	ErrProfileNotFoundByPhone = errors.New("sorry, account not found by phone")
 	ErrGenerateTokenFailed    = errors.New("sorry, failed to generate token")
 	ErrCalculateAgeFailed     = errors.New("sorry, failed to calculate age")
+	ErrNothingToUpdate        = errors.New("sorry, no profile fields to update")
+	ErrUpdateProfileFailed    = errors.New("sorry, failed to update profile")
+	ErrIncorrectPassword      = errors.New("sorry, the old password is incorrect")
+	ErrUpdatePasswordFailed   = errors.New("sorry, failed to update password")
+	ErrTokenRevoked           = errors.New("invalid token: token has been revoked")
+	ErrSavePreferencesFailed  = errors.New("sorry, failed to save preferences")
+	ErrInvalidCursor          = utils.ErrInvalidCursor
+	ErrAccountBanned          = errors.New("sorry, this account has been deactivated by a moderator")
+	ErrDeactivateFailed       = errors.New("sorry, failed to deactivate account")
+	ErrReactivateFailed       = errors.New("sorry, failed to reactivate account")
+	ErrLocationRequired       = errors.New("sorry, set your location to discover profiles")
 )
 
+// AccountSuspendedError is returned when a suspended user logs in or uses a token.
+type AccountSuspendedError struct {
+	Until time.Time
+}
+
+func (e *AccountSuspendedError) Error() string {
+	return fmt.Sprintf("sorry, this account is suspended until %s", e.Until.Format(time.RFC3339))
+}
+
 type UserService struct {
 	eventStore     store.EventStore
 	userRepository repository.UserRepository
 	logger         *logrus.Logger
 	jwtSecret      string
+	passwordPolicy models.PasswordPolicy
+	ranker         *utils.CompositeRanker
 }
 
+// TokenPayload is the JWT claim set. Version must match the user's TokenVersion for the
+// token to be accepted, so bumping TokenVersion revokes every token issued before. Role must
+// match the user's role too, so a role change takes effect on the next request.
 type TokenPayload struct {
-	Id string `json:"id"`
+	Id      string      `json:"id"`
+	Version int         `json:"ver"`
+	Role    models.Role `json:"role,omitempty"`
 	jwt.Payload
 }
 
-func NewUserService(eventStore store.EventStore, userRepository repository.UserRepository, logger *logrus.Logger, jwtSecret string) *UserService {
+func NewUserService(eventStore store.EventStore, userRepository repository.UserRepository, logger *logrus.Logger, jwtSecret string, passwordPolicy models.PasswordPolicy, ranker *utils.CompositeRanker) *UserService {
 	return &UserService{
 		eventStore:     eventStore,
 		userRepository: userRepository,
 		logger:         logger,
 		jwtSecret:      jwtSecret,
+		passwordPolicy: passwordPolicy,
+		ranker:         ranker,
 	}
 }
 
-// Register registers a new user.
+// Register registers a new user from the given registration payload.
 func (u UserService) Register(
 	ctx context.Context,
+	payload models.RegistrationPayload,
 ) (*models.RegistrationResponse, error) {
-	newUser := generateRandomUserPayload()
+	if err := u.passwordPolicy.Check(payload.Password); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	newUser := &models.User{
+		Name:         strings.TrimSpace(payload.Name),
+		Email:        normalizeEmail(payload.Email),
+		Password:     utils.EncryptPassword(payload.Password),
+		Gender:       payload.Gender,
+		DateOfBirth:  payload.DateOfBirth,
+		CreatedAt:    now,
+		LastActiveAt: now,
+	}
 	createdUser, err := u.userRepository.CreateUser(ctx, newUser)
 	if err != nil {
 		u.logger.WithContext(ctx).WithError(err).Error("failed to create user")
@@ -60,13 +104,6 @@ func (u UserService) Register(
 		return nil, ErrCreateProfileFailed
 	}
 
-	// Generate and set token for the created user
-	_, err = u.GenerateToken(createdUser)
-	if err != nil {
-		u.logger.WithContext(ctx).WithError(err).Error("failed to generate token for user")
-		return nil, ErrGenerateTokenFailed
-	}
-
 	age, err := utils.CalculateAge(newUser.DateOfBirth)
 	if err != nil {
 		u.logger.WithContext(ctx).WithError(err).Error("failed to calculate age")
@@ -74,12 +111,11 @@ func (u UserService) Register(
 	}
 
 	return &models.RegistrationResponse{
-		ID:       createdUser.ID,
-		Name:     newUser.Name,
-		Password: constants.DefaultPassword,
-		Age:      age,
-		Gender:   newUser.Gender,
-		Email:    newUser.Email,
+		ID:     createdUser.ID,
+		Name:   createdUser.Name,
+		Age:    age,
+		Gender: createdUser.Gender,
+		Email:  createdUser.Email,
 	}, nil
 }
 
@@ -95,7 +131,9 @@ func (u UserService) GenerateToken(profile *models.User) (*string, error) {
 			ExpirationTime: jwt.NumericDate(exp),
 			JWTID:          constants.TokenJWTID,
 		},
-		Id: profile.ID,
+		Id:      profile.ID,
+		Version: profile.TokenVersion,
+		Role:    profile.Role.OrDefault(),
 	}
 	token, err := jwt.Sign(payload, jwt.NewHS256([]byte(u.jwtSecret)))
 	if err != nil {
@@ -108,20 +146,27 @@ func (u UserService) GenerateToken(profile *models.User) (*string, error) {
 
 // Login logs in a user.
 func (u UserService) Login(ctx context.Context, email, password string) (*models.LoginResponse, error) {
-	lowercaseEmail := strings.ToLower(email)
-	profile, err := u.userRepository.GetUserByEmail(ctx, lowercaseEmail)
+	profile, err := u.userRepository.GetUserByEmail(ctx, normalizeEmail(email))
 	if err != nil {
 		return nil, errors.New("invalid email or password. Please try again")
 	}
 	if !utils.VerifyPasscode(profile.Password, password) {
 		return nil, errors.New("invalid email or password. Please try again")
 	}
+	if err := checkStanding(profile); err != nil {
+		return nil, err
+	}
 	token, err := u.GenerateToken(profile)
 	if err != nil {
 		return nil, errors.New("failed to generate authentication token")
 	}
+	now := time.Now()
+	if _, err := u.userRepository.UpdateUser(ctx, profile.ID, models.UserUpdate{LastActiveAt: &now}); err != nil {
+		u.logger.WithContext(ctx).WithError(err).Warn("failed to record user activity")
+	}
 	response := &models.LoginResponse{
-		Token: *token,
+		Token:       *token,
+		Deactivated: profile.IsDeactivated(),
 	}
 	return response, nil
 }
@@ -136,17 +181,196 @@ func (u UserService) GetProfile(ctx context.Context, id string) (*models.User, e
 	return profile, nil
 }
 
-// Discover returns a list of profiles that match the given filter.
-func (u UserService) Discover(ctx context.Context, user models.User, filter models.UserFilter) ([]*models.User, error) {
-	profiles, err := u.userRepository.Discover(ctx, filter, user)
+// ChangePassword replaces the user's password after verifying the old one. Every token issued
+// before the change is revoked, and a fresh token is returned for the current session.
+func (u UserService) ChangePassword(ctx context.Context, userID string, payload models.UpdatePasswordPayload) (*models.LoginResponse, error) {
+	profile, err := u.userRepository.GetUserById(ctx, userID)
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error("failed to get user profile by ID")
+		return nil, ErrProfileNotFoundById
+	}
+	if !utils.VerifyPasscode(profile.Password, payload.OldPassword) {
+		return nil, ErrIncorrectPassword
+	}
+	if err := u.passwordPolicy.Check(payload.NewPassword); err != nil {
+		return nil, err
+	}
+
+	profile, err = u.userRepository.UpdatePassword(ctx, userID, utils.EncryptPassword(payload.NewPassword))
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error("failed to update password")
+		return nil, ErrUpdatePasswordFailed
+	}
+
+	token, err := u.GenerateToken(profile)
+	if err != nil {
+		return nil, ErrGenerateTokenFailed
+	}
+	return &models.LoginResponse{Token: *token}, nil
+}
+
+// UpdateProfile applies the fields provided in the payload to the user's profile.
+// Fields missing from the payload are left unchanged.
+func (u UserService) UpdateProfile(ctx context.Context, userID string, payload models.UpdateUserPayload) (*models.User, error) {
+	if payload.IsEmpty() {
+		return nil, ErrNothingToUpdate
+	}
+	profile, err := u.userRepository.UpdateUser(ctx, userID, payload.ToUserUpdate())
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error("failed to update user profile")
+		return nil, ErrUpdateProfileFailed
+	}
+	profile.Age, _ = utils.CalculateAge(profile.DateOfBirth)
+	return profile, nil
+}
+
+// Deactivate deactivates the user's account, hiding them from discovery and other users' matches
+// until they reactivate it. The user can still log in, which offers to reactivate the account. A
+// ban can land after the token was checked, so an account a moderator deactivated is refused here,
+// as deactivating it again would let the user reactivate it.
+func (u UserService) Deactivate(ctx context.Context, userID string) (*models.User, error) {
+	profile, err := u.userRepository.DeactivateUser(ctx, userID)
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error("failed to deactivate account")
+		return nil, ErrDeactivateFailed
+	}
+	if profile == nil {
+		return nil, ErrAccountBanned
+	}
+	return profile, nil
+}
+
+// Reactivate reactivates an account the user deactivated. Tokens of banned accounts are rejected,
+// but a ban can land after the token was checked, so an account a moderator deactivated is
+// refused here too.
+func (u UserService) Reactivate(ctx context.Context, userID string) (*models.User, error) {
+	profile, err := u.userRepository.ReactivateUser(ctx, userID)
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error("failed to reactivate account")
+		return nil, ErrReactivateFailed
+	}
+	if profile == nil {
+		return nil, ErrAccountBanned
+	}
+	return profile, nil
+}
+
+// GetPreferences returns the user's saved dating preferences, or empty preferences if none are saved.
+func (u UserService) GetPreferences(ctx context.Context, user models.User) (*models.Preferences, error) {
+	if user.Preferences == nil {
+		return &models.Preferences{}, nil
+	}
+	return user.Preferences, nil
+}
+
+// SetPreferences replaces the user's saved dating preferences.
+func (u UserService) SetPreferences(ctx context.Context, userID string, preferences models.Preferences) (*models.Preferences, error) {
+	profile, err := u.userRepository.UpdateUser(ctx, userID, models.UserUpdate{Preferences: &preferences})
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error("failed to save user preferences")
+		return nil, ErrSavePreferencesFailed
+	}
+	return profile.Preferences, nil
+}
+
+// Discover returns one page of profiles that match the given filter, and the cursor of the next
+// page, which is empty on the last page. When no filter is given the user's saved preferences are
+// used instead, and their deal-breakers filter profiles out. The profiles that super-liked the user
+// come first, then everyone else, each ranked by the composite ranker against the user's
+// preferences. Ranking runs in Go, so it covers a window of candidates, the super-likers and then
+// the constants.DiscoverCandidateWindow nearest profiles, which is ranked in full before it is
+// paged. Every page is ranked at the time of the first, so the order holds across the pages. With
+// explain set, each profile carries the breakdown of its ranking score.
+func (u UserService) Discover(ctx context.Context, user models.User, filter models.UserFilter, page models.Page, explain bool) ([]*models.User, string, error) {
+	var after *models.DiscoverCursor
+	rankedAt := time.Now()
+	if page.Cursor != "" {
+		after = &models.DiscoverCursor{}
+		if err := utils.DecodeCursor(page.Cursor, after); err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+		if !after.RankedAt.IsZero() {
+			rankedAt = after.RankedAt
+		}
+	}
+
+	// Profiles are found around the user, so a user who registered without a location has to
+	// set one first.
+	if len(user.Location) != 2 {
+		return nil, "", ErrLocationRequired
+	}
+
+	if filter.IsEmpty() && user.Preferences != nil {
+		filter.MaxDistance = user.Preferences.MaxDistance
+		filter.Preferences = user.Preferences
+	}
+
+	candidates, err := u.userRepository.Discover(ctx, filter, user, constants.DiscoverCandidateWindow)
 	if err != nil {
 		u.logger.WithContext(ctx).WithError(err).Error("failed to discover profiles")
-		return nil, errors.New("failed to discover profiles")
+		return nil, "", errors.New("failed to discover profiles")
+	}
+	rankings := u.rankProfiles(user, candidates, rankedAt)
+	positionOf := func(profile *models.User) models.DiscoverCursor {
+		return discoverPosition(profile, rankings[profile], rankedAt)
+	}
+	// The candidates are read afresh for every page, so the page starts after the cursor's
+	// position rather than at an offset, which swipes recorded in between would shift.
+	if after != nil {
+		start := sort.Search(len(candidates), func(i int) bool {
+			return after.Before(positionOf(candidates[i]))
+		})
+		candidates = candidates[start:]
+	}
+
+	profiles, nextCursor, err := utils.Paginate(candidates, page.LimitOrDefault(), func(last *models.User) interface{} {
+		return positionOf(last)
+	})
+	if err != nil {
+		u.logger.WithContext(ctx).WithError(err).Error("failed to encode discover cursor")
+		return nil, "", errors.New("failed to discover profiles")
 	}
-	if len(profiles) == 0 {
-		return []*models.User{}, nil
+	for _, profile := range profiles {
+		hideRankingSignals(profile)
+		if explain {
+			ranking := rankings[profile]
+			profile.Ranking = &ranking
+		}
 	}
-	return profiles, nil
+	return profiles, nextCursor, nil
+}
+
+// hideRankingSignals clears the fields a discovered profile was ranked by that are not part of its
+// public profile, so discovery shows no more of a user than matches and likes do.
+func hideRankingSignals(profile *models.User) {
+	profile.Location = nil
+	profile.SwipeCount = 0
+	profile.Attractiveness = 0
+	profile.CreatedAt = time.Time{}
+	profile.LastActiveAt = time.Time{}
+}
+
+// rankProfiles orders profiles for the viewer in discover order, as ranked at the given time: those
+// that super-liked the viewer first, then by ranking score, best first, and by ID among equal
+// scores. It returns the ranking of each profile.
+func (u UserService) rankProfiles(viewer models.User, profiles []*models.User, rankedAt time.Time) map[*models.User]models.RankingExplanation {
+	preferences := viewer.Preferences
+	if preferences == nil {
+		preferences = &models.Preferences{}
+	}
+	rankings := make(map[*models.User]models.RankingExplanation, len(profiles))
+	for _, profile := range profiles {
+		rankings[profile] = u.ranker.Rank(&viewer, preferences, profile, rankedAt)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return discoverPosition(profiles[i], rankings[profiles[i]], rankedAt).Before(discoverPosition(profiles[j], rankings[profiles[j]], rankedAt))
+	})
+	return rankings
+}
+
+// discoverPosition returns the position of a profile ranked at the given time in discover order.
+func discoverPosition(profile *models.User, ranking models.RankingExplanation, rankedAt time.Time) models.DiscoverCursor {
+	return models.DiscoverCursor{SuperLiked: profile.SuperLikedYou, Score: ranking.Score, ID: profile.ID, RankedAt: rankedAt}
 }
 
 // VerifyAuthToken verifies the given token.
@@ -164,10 +388,33 @@ func (u UserService) VerifyAuthToken(ctx context.Context, token string) (*models
 		u.logger.WithContext(ctx).WithError(err).Error("failed to retrieve user from token")
 		return nil, errors.New("invalid token: user retrieval failed")
 	}
+	if payloadBody.Version != profile.TokenVersion {
+		return nil, ErrTokenRevoked
+	}
+	// Tokens issued before roles existed carry none; they belong to plain users.
+	if payloadBody.Role.OrDefault() != profile.Role.OrDefault() {
+		return nil, ErrTokenRevoked
+	}
+	// Moderation takes effect on the next request, not when the token expires.
+	if err := checkStanding(profile); err != nil {
+		return nil, err
+	}
 	return profile, nil
 }
 
-// SeedDefaultUsers seeds the database with default users.
+// checkStanding rejects users a moderator deactivated or suspended.
+func checkStanding(profile *models.User) error {
+	if profile.IsBanned() {
+		return ErrAccountBanned
+	}
+	if profile.IsSuspended(time.Now()) {
+		return &AccountSuspendedError{Until: profile.SuspendedUntil}
+	}
+	return nil
+}
+
+// SeedDefaultUsers seeds the database with random faker users. It is meant for local
+// development only; every seeded user shares constants.DefaultPassword.
 func (u UserService) SeedDefaultUsers(ctx context.Context) error {
 	currentUserCount, err := u.userRepository.GetUserCount(ctx)
 	if err != nil {
@@ -195,12 +442,17 @@ func generateRandomUserPayload() *models.User {
 	encryptedPassword := utils.EncryptPassword(constants.DefaultPassword)
 	name := faker.FirstName() + " " + faker.LastName()
 	lowercaseFirstName := strings.ToLower(faker.FirstName())
+	// Emails are unique, so the first name alone would collide.
+	suffix := utils.GenerateId()[18:]
+	now := time.Now()
 	return &models.User{
+		CreatedAt:        now,
+		LastActiveAt:     now,
 		Name:             name,
 		Password:         encryptedPassword,
 		DateOfBirth:      utils.GetRandomDOB().Format("2006-01-02"),
 		Gender:           models.Gender(utils.GetRandomGender()),
-		Email:            lowercaseFirstName + "@gmail.com",
+		Email:            lowercaseFirstName + "." + suffix + "@gmail.com",
 		Location:         utils.GetRandomLocationInNorthLondon(),
 		Ethnicity:        utils.GetRandomEthnicity(),
 		Pets:             utils.GetRandomPet(),
@@ -208,3 +460,8 @@ func generateRandomUserPayload() *models.User {
 		Height:           utils.GetRandomHeight(),
 	}
 }
+
+// normalizeEmail trims and lowercases an email so lookups are case-insensitive.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -1,8 +1,17 @@
 package services
 
 import (
+	"api/constants"
 	"api/models"
+	"api/repository"
+	"api/store"
+	"api/utils"
+	"context"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
 )
 
 type mockTokenGenerator struct {
@@ -13,3 +22,473 @@ func (m *mockTokenGenerator) GenerateToken(profile *models.User) (*string, error
 	args := m.Called(profile)
 	return args.Get(0).(*string), args.Error(1)
 }
+
+func TestUserService_Register(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, testRanker(t))
+
+	payload := models.RegistrationPayload{
+		Name:        "Jane Doe",
+		Email:       "  Jane.Doe@Example.com ",
+		Password:    "Correct horse 1",
+		Gender:      models.Female,
+		DateOfBirth: time.Now().AddDate(-30, 0, -1).Format(constants.DateOfBirthLayout),
+	}
+
+	var stored *models.User
+	userRepo.On("CreateUser", mock.Anything, mock.AnythingOfType("*models.User")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*models.User) }).
+		Return(&models.User{ID: "user123", Name: "Jane Doe", Email: "jane.doe@example.com", Gender: models.Female}, nil)
+
+	response, err := userService.Register(context.Background(), payload)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user123", response.ID)
+	assert.Equal(t, "jane.doe@example.com", stored.Email)
+	assert.NotEqual(t, payload.Password, stored.Password)
+	assert.True(t, utils.VerifyPasscode(stored.Password, payload.Password))
+	assert.Equal(t, 30, response.Age)
+	userRepo.AssertExpectations(t)
+}
+
+func TestRegistrationPayload_Validate_RejectsUnderage(t *testing.T) {
+	payload := models.RegistrationPayload{
+		Name:        "Jane Doe",
+		Email:       "jane@example.com",
+		Password:    "Correct horse 1",
+		Gender:      models.Female,
+		DateOfBirth: time.Now().AddDate(-17, 0, 0).Format(constants.DateOfBirthLayout),
+	}
+	assert.Error(t, payload.Validate())
+
+	payload.DateOfBirth = time.Now().AddDate(-18, 0, -1).Format(constants.DateOfBirthLayout)
+	assert.NoError(t, payload.Validate())
+}
+
+func TestUserService_UpdateProfile_OnlyProvidedFields(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, testRanker(t))
+
+	religion := models.Muslim
+	kids := "0"
+	payload := models.UpdateUserPayload{Religion: &religion, Kids: &kids}
+	assert.NoError(t, payload.Validate())
+
+	var update models.UserUpdate
+	userRepo.On("UpdateUser", mock.Anything, "user123", mock.AnythingOfType("models.UserUpdate")).
+		Run(func(args mock.Arguments) { update = args.Get(2).(models.UserUpdate) }).
+		Return(&models.User{ID: "user123", Religion: models.Muslim}, nil)
+
+	profile, err := userService.UpdateProfile(context.Background(), "user123", payload)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.Muslim, profile.Religion)
+	assert.Equal(t, models.Muslim, *update.Religion)
+	assert.Equal(t, 0, *update.Kids)
+	assert.Nil(t, update.Location)
+	assert.Nil(t, update.Bio)
+	userRepo.AssertExpectations(t)
+
+	_, err = userService.UpdateProfile(context.Background(), "user123", models.UpdateUserPayload{})
+	assert.ErrorIs(t, err, ErrNothingToUpdate)
+}
+
+func TestUpdateUserPayload_Validate(t *testing.T) {
+	invalidReligion := models.Religion("jedi")
+	negativeKids := "-1"
+	london, unknownZone := "Europe/London", "Mars/Olympus_Mons"
+	testCases := []struct {
+		name    string
+		payload models.UpdateUserPayload
+		wantErr bool
+	}{
+		{name: "valid location", payload: models.UpdateUserPayload{Location: []float64{-0.12, 51.5}}},
+		{name: "latitude out of range", payload: models.UpdateUserPayload{Location: []float64{-0.12, 151.5}}, wantErr: true},
+		{name: "incomplete location", payload: models.UpdateUserPayload{Location: []float64{-0.12}}, wantErr: true},
+		{name: "unknown religion", payload: models.UpdateUserPayload{Religion: &invalidReligion}, wantErr: true},
+		{name: "negative kids", payload: models.UpdateUserPayload{Kids: &negativeKids}, wantErr: true},
+		{name: "valid time zone", payload: models.UpdateUserPayload{Timezone: &london}},
+		{name: "unknown time zone", payload: models.UpdateUserPayload{Timezone: &unknownZone}, wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.payload.Validate()
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+var testPasswordPolicy = models.PasswordPolicy{MinLength: 8, RequireUppercase: true, RequireLowercase: true, RequireDigit: true}
+
+// testRanker returns a ranker with the default scorers and weights.
+func testRanker(t *testing.T) *utils.CompositeRanker {
+	scorers, err := utils.NewScorerRegistry(nil)
+	assert.NoError(t, err)
+	ranker, err := utils.NewCompositeRanker(scorers, nil)
+	assert.NoError(t, err)
+	return ranker
+}
+
+func TestUserService_ChangePassword_RevokesOldTokens(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, testRanker(t))
+
+	profile := &models.User{ID: "user123", Password: utils.EncryptPassword("OldPassw0rd")}
+	oldToken, err := userService.GenerateToken(profile)
+	assert.NoError(t, err)
+
+	changed := &models.User{ID: "user123", TokenVersion: 1}
+	userRepo.On("GetUserById", mock.Anything, "user123").Return(profile, nil).Once()
+	userRepo.On("UpdatePassword", mock.Anything, "user123", mock.AnythingOfType("string")).Return(changed, nil)
+	userRepo.On("GetUserById", mock.Anything, "user123").Return(changed, nil)
+
+	response, err := userService.ChangePassword(context.Background(), "user123", models.UpdatePasswordPayload{
+		OldPassword: "OldPassw0rd",
+		NewPassword: "NewPassw0rd",
+	})
+	assert.NoError(t, err)
+
+	_, err = userService.VerifyAuthToken(context.Background(), *oldToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	_, err = userService.VerifyAuthToken(context.Background(), response.Token)
+	assert.NoError(t, err)
+}
+
+func TestUserService_ChangePassword_Rejections(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, testRanker(t))
+
+	profile := &models.User{ID: "user123", Password: utils.EncryptPassword("OldPassw0rd")}
+	userRepo.On("GetUserById", mock.Anything, "user123").Return(profile, nil)
+
+	_, err := userService.ChangePassword(context.Background(), "user123", models.UpdatePasswordPayload{
+		OldPassword: "wrong",
+		NewPassword: "NewPassw0rd",
+	})
+	assert.ErrorIs(t, err, ErrIncorrectPassword)
+
+	_, err = userService.ChangePassword(context.Background(), "user123", models.UpdatePasswordPayload{
+		OldPassword: "OldPassw0rd",
+		NewPassword: "weakpassword",
+	})
+	assert.Error(t, err)
+	userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_Discover_FallsBackToPreferences(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, testRanker(t))
+
+	preferences := &models.Preferences{
+		MaxDistance: 25,
+		Religion:    models.Muslim,
+		Smoking:     models.SmokingPreference{Status: models.NoSmokingHabit},
+		Drinking:    models.DrinkingPreference{Status: models.NoDrinkingHabit, DealBreaker: true},
+	}
+	viewer := models.User{ID: "viewer", Location: []float64{-0.12, 51.5}, Preferences: preferences}
+
+	near := &models.User{ID: "near", Religion: models.Christian, Smoking: models.YesSmokingHabit}
+	far := &models.User{ID: "far", Religion: models.Muslim, Smoking: models.NoSmokingHabit}
+
+	userRepo.On("Discover", mock.Anything, mock.MatchedBy(func(filter models.UserFilter) bool {
+		return filter.MaxDistance == 25 && filter.Preferences == preferences
+	}), viewer, constants.DiscoverCandidateWindow).Return([]*models.User{near, far}, nil)
+
+	profiles, _, err := userService.Discover(context.Background(), viewer, models.UserFilter{}, models.Page{}, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.User{far, near}, profiles)
+	userRepo.AssertExpectations(t)
+}
+
+func TestUserService_Discover_RequiresLocation(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, testRanker(t))
+
+	// Users register without a location, and have none until they set one.
+	_, _, err := userService.Discover(context.Background(), models.User{ID: "viewer"}, models.UserFilter{}, models.Page{}, false)
+
+	assert.ErrorIs(t, err, ErrLocationRequired)
+	userRepo.AssertNotCalled(t, "Discover", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_Discover_ExplicitFilterIgnoresPreferences(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, testRanker(t))
+
+	viewer := models.User{ID: "viewer", Location: []float64{-0.12, 51.5}, Preferences: &models.Preferences{MaxDistance: 25}}
+	filter := models.UserFilter{MaxDistance: 5}
+
+	userRepo.On("Discover", mock.Anything, filter, viewer, constants.DiscoverCandidateWindow).Return([]*models.User{}, nil)
+
+	profiles, _, err := userService.Discover(context.Background(), viewer, filter, models.Page{}, false)
+
+	assert.NoError(t, err)
+	assert.Empty(t, profiles)
+	userRepo.AssertExpectations(t)
+}
+
+func TestUserService_Discover_Pagination(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, testRanker(t))
+
+	viewer := models.User{ID: "viewer", Location: []float64{-0.12, 51.5}}
+	filter := models.UserFilter{MaxDistance: 5}
+	near := models.User{ID: "a", Distance: 1.25, Attractiveness: 2}
+	further := models.User{ID: "b", Distance: 2.5, Attractiveness: 9}
+	furthest := models.User{ID: "c", Distance: 3.75, Attractiveness: 5}
+
+	userRepo.On("Discover", mock.Anything, filter, viewer, constants.DiscoverCandidateWindow).
+		Return(discoverWindow(near, further, furthest), nil).Once()
+
+	// The whole window is ranked before it is paged, so the best profiles lead wherever they are.
+	profiles, nextCursor, err := userService.Discover(context.Background(), viewer, filter, models.Page{Limit: 2}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, profileIDs(profiles))
+	assert.NotEmpty(t, nextCursor)
+
+	// The next page starts strictly after the last profile of the first page, however the
+	// window changed as the user swiped in between.
+	userRepo.On("Discover", mock.Anything, filter, viewer, constants.DiscoverCandidateWindow).
+		Return(discoverWindow(near, furthest), nil).Once()
+
+	profiles, nextCursor, err = userService.Discover(context.Background(), viewer, filter, models.Page{Limit: 2, Cursor: nextCursor}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, profileIDs(profiles))
+	assert.Empty(t, nextCursor)
+
+	_, _, err = userService.Discover(context.Background(), viewer, filter, models.Page{Cursor: "not a cursor"}, false)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	userRepo.AssertExpectations(t)
+}
+
+func TestUserService_Discover_ShowsOnlyThePublicProfile(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, testRanker(t))
+
+	viewer := models.User{ID: "viewer", Location: []float64{-0.12, 51.5}}
+	candidate := models.User{ID: "a", Name: "Amina", Age: 27, Distance: 1.25, Location: []float64{-0.12, 51.51},
+		SwipeCount: 40, Attractiveness: 7, CreatedAt: time.Now(), LastActiveAt: time.Now()}
+	userRepo.On("Discover", mock.Anything, mock.Anything, viewer, constants.DiscoverCandidateWindow).
+		Return(discoverWindow(candidate), nil)
+
+	profiles, _, err := userService.Discover(context.Background(), viewer, models.UserFilter{MaxDistance: 5}, models.Page{}, true)
+	assert.NoError(t, err)
+	// The signals the profile was ranked by are gone; its age and distance stay.
+	assert.Equal(t, []*models.User{{ID: "a", Name: "Amina", Age: 27, Distance: 1.25, Ranking: profiles[0].Ranking}}, profiles)
+	assert.NotNil(t, profiles[0].Ranking)
+}
+
+// discoverWindow returns fresh copies of the given users, as every Discover call reads them anew.
+func discoverWindow(users ...models.User) []*models.User {
+	window := make([]*models.User, len(users))
+	for i := range users {
+		user := users[i]
+		window[i] = &user
+	}
+	return window
+}
+
+// profileIDs returns the IDs of the given profiles, in order.
+func profileIDs(profiles []*models.User) []string {
+	ids := make([]string, len(profiles))
+	for i, profile := range profiles {
+		ids[i] = profile.ID
+	}
+	return ids
+}
+
+func TestUserService_Discover_PagesSuperLikersFirst(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, testRanker(t))
+
+	viewer := models.User{ID: "viewer", Location: []float64{-0.12, 51.5}}
+	filter := models.UserFilter{MaxDistance: 50}
+	superLiker := &models.User{ID: "a", Distance: 40, SuperLikedYou: true}
+	best := &models.User{ID: "b", Distance: 1, Attractiveness: 10}
+
+	userRepo.On("Discover", mock.Anything, filter, viewer, constants.DiscoverCandidateWindow).
+		Return([]*models.User{superLiker, best}, nil)
+
+	profiles, nextCursor, err := userService.Discover(context.Background(), viewer, filter, models.Page{Limit: 1}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.User{superLiker}, profiles)
+
+	// The cursor remembers that the page ended among the super-likers, so the next page goes on
+	// with the rest of them before everyone else.
+	var cursor models.DiscoverCursor
+	assert.NoError(t, utils.DecodeCursor(nextCursor, &cursor))
+	assert.Equal(t, models.DiscoverCursor{SuperLiked: true, Score: superLiker.Ranking.Score, ID: "a", RankedAt: cursor.RankedAt}, cursor)
+	assert.WithinDuration(t, time.Now(), cursor.RankedAt, time.Minute)
+
+	profiles, _, err = userService.Discover(context.Background(), viewer, filter, models.Page{Limit: 1, Cursor: nextCursor}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.User{best}, profiles)
+}
+
+func TestUserService_Discover_RanksEveryPageAtTheTimeOfTheFirst(t *testing.T) {
+	scorers, err := utils.NewScorerRegistry(nil)
+	assert.NoError(t, err)
+	ranker, err := utils.NewCompositeRanker(scorers, models.Weights{utils.RecencyScorer: 1})
+	assert.NoError(t, err)
+	userRepo := new(repository.MockUserRepository)
+	userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, ranker)
+
+	viewer := models.User{ID: "viewer", Location: []float64{-0.12, 51.5}}
+	filter := models.UserFilter{MaxDistance: 50}
+	joined := time.Now().Add(-30 * 24 * time.Hour)
+	newer := models.User{ID: "a", CreatedAt: joined}
+	older := models.User{ID: "b", CreatedAt: joined.Add(-60 * 24 * time.Hour)}
+	userRepo.On("Discover", mock.Anything, filter, viewer, constants.DiscoverCandidateWindow).
+		Return(discoverWindow(newer, older), nil).Once()
+	userRepo.On("Discover", mock.Anything, filter, viewer, constants.DiscoverCandidateWindow).
+		Return(discoverWindow(newer, older), nil).Once()
+
+	_, nextCursor, err := userService.Discover(context.Background(), viewer, filter, models.Page{Limit: 1}, false)
+	assert.NoError(t, err)
+
+	// Pretend the session started a month ago: the next page scores as it would have then, so it
+	// follows on from the cursor's score rather than from a score that has since decayed.
+	var cursor models.DiscoverCursor
+	assert.NoError(t, utils.DecodeCursor(nextCursor, &cursor))
+	cursor.RankedAt = joined
+	earlier, err := utils.EncodeCursor(cursor)
+	assert.NoError(t, err)
+
+	profiles, _, err := userService.Discover(context.Background(), viewer, filter, models.Page{Limit: 1, Cursor: earlier}, true)
+	assert.NoError(t, err)
+	if assert.Equal(t, []string{"b"}, profileIDs(profiles)) {
+		assert.Equal(t, ranker.Rank(&viewer, &models.Preferences{}, &older, joined), *profiles[0].Ranking)
+	}
+}
+
+func TestUserService_Discover_RanksAndExplains(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, testRanker(t))
+
+	viewer := models.User{ID: "viewer", Location: []float64{-0.12, 51.5}}
+	filter := models.UserFilter{MaxDistance: 50}
+	near := &models.User{ID: "near", Location: []float64{-0.12, 51.51}, Attractiveness: 2}
+	far := &models.User{ID: "far", Location: []float64{-0.3, 51.6}, Attractiveness: 9}
+
+	userRepo.On("Discover", mock.Anything, filter, viewer, constants.DiscoverCandidateWindow).
+		Return([]*models.User{near, far}, nil)
+
+	profiles, _, err := userService.Discover(context.Background(), viewer, filter, models.Page{}, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*models.User{far, near}, profiles)
+	for _, profile := range profiles {
+		assert.NotNil(t, profile.Ranking)
+		assert.Greater(t, profile.Ranking.Score, 0.0)
+	}
+	assert.Greater(t, near.Ranking.Components[utils.ProximityScorer], far.Ranking.Components[utils.ProximityScorer])
+	assert.Greater(t, far.Ranking.Components[utils.AttractivenessScorer], near.Ranking.Components[utils.AttractivenessScorer])
+}
+
+func TestUserService_ModeratedAccountsAreLockedOut(t *testing.T) {
+	suspendedUntil := time.Now().Add(24 * time.Hour)
+
+	testCases := []struct {
+		name        string
+		profile     models.User
+		expectedErr error
+	}{
+		{name: "in good standing", profile: models.User{}},
+		{name: "suspension over", profile: models.User{SuspendedUntil: time.Now().Add(-time.Hour)}},
+		{name: "suspended", profile: models.User{SuspendedUntil: suspendedUntil}, expectedErr: &AccountSuspendedError{Until: suspendedUntil}},
+		{name: "banned", profile: models.User{Status: models.Deactivated, DeactivationReason: models.ModerationDeactivation}, expectedErr: ErrAccountBanned},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			profile := tc.profile
+			profile.ID = "user123"
+			profile.Email = "user@example.com"
+			profile.Password = utils.EncryptPassword("Passw0rd")
+
+			userRepo := new(repository.MockUserRepository)
+			userRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(&profile, nil)
+			userRepo.On("GetUserById", mock.Anything, "user123").Return(&profile, nil)
+			userRepo.On("UpdateUser", mock.Anything, "user123", mock.Anything).Return(&profile, nil)
+			userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, testRanker(t))
+
+			_, err := userService.Login(context.Background(), "user@example.com", "Passw0rd")
+			assert.Equal(t, tc.expectedErr, err)
+
+			// Tokens issued before the moderation stop working too.
+			token, tokenErr := userService.GenerateToken(&profile)
+			assert.NoError(t, tokenErr)
+			_, err = userService.VerifyAuthToken(context.Background(), *token)
+			assert.Equal(t, tc.expectedErr, err)
+		})
+	}
+}
+
+func TestUserService_VerifyAuthToken_RejectsStaleRole(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, testRanker(t))
+
+	admin := &models.User{ID: "user123", Role: models.AdminRole}
+	token, err := userService.GenerateToken(admin)
+	assert.NoError(t, err)
+
+	// The role claim must match the stored role, so a demoted admin's token stops working.
+	userRepo.On("GetUserById", mock.Anything, "user123").Return(&models.User{ID: "user123"}, nil)
+	_, err = userService.VerifyAuthToken(context.Background(), *token)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+}
+
+func TestUserService_DeactivateAndReactivate(t *testing.T) {
+	userRepo := new(repository.MockUserRepository)
+	userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, testRanker(t))
+
+	profile := &models.User{ID: "user123", Email: "user@example.com", Password: utils.EncryptPassword("Passw0rd"),
+		Status: models.Deactivated, DeactivationReason: models.SelfDeactivation}
+	userRepo.On("DeactivateUser", mock.Anything, "user123").Return(profile, nil)
+
+	_, err := userService.Deactivate(context.Background(), "user123")
+	assert.NoError(t, err)
+
+	// Logging in again works, and offers to reactivate the account with the token.
+	userRepo.On("GetUserByEmail", mock.Anything, "user@example.com").Return(profile, nil)
+	userRepo.On("UpdateUser", mock.Anything, "user123", mock.Anything).Return(profile, nil)
+	userRepo.On("GetUserById", mock.Anything, "user123").Return(profile, nil)
+	response, err := userService.Login(context.Background(), "user@example.com", "Passw0rd")
+	assert.NoError(t, err)
+	assert.True(t, response.Deactivated)
+	_, err = userService.VerifyAuthToken(context.Background(), response.Token)
+	assert.NoError(t, err)
+
+	userRepo.On("ReactivateUser", mock.Anything, "user123").Return(&models.User{ID: "user123", Status: models.Activated}, nil)
+	reactivated, err := userService.Reactivate(context.Background(), "user123")
+	assert.NoError(t, err)
+	assert.False(t, reactivated.IsDeactivated())
+}
+
+func TestUserService_Reactivate_Banned(t *testing.T) {
+	// A moderator banned the account after its token was checked: the ban stands.
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("ReactivateUser", mock.Anything, "user123").Return((*models.User)(nil), nil)
+	userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, testRanker(t))
+
+	_, err := userService.Reactivate(context.Background(), "user123")
+	assert.ErrorIs(t, err, ErrAccountBanned)
+}
+
+func TestUserService_Deactivate_Banned(t *testing.T) {
+	// A moderator banned the account after its token was checked: the ban is not turned into a
+	// deactivation the user could undo.
+	userRepo := new(repository.MockUserRepository)
+	userRepo.On("DeactivateUser", mock.Anything, "user123").Return((*models.User)(nil), nil)
+	userService := NewUserService(store.NewEventStore(logrus.New()), userRepo, logrus.New(), "secret", testPasswordPolicy, testRanker(t))
+
+	_, err := userService.Deactivate(context.Background(), "user123")
+	assert.ErrorIs(t, err, ErrAccountBanned)
+	userRepo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything)
+}
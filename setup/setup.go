@@ -3,21 +3,30 @@ package setup
 import (
 	"api/config"
 	"api/middlewares"
+	"api/notifiers"
 	"api/repository"
 	"api/repository/mongodb"
 	"api/services"
 	"api/store"
+	"api/utils"
+	"context"
 	"fmt"
 	"github.com/sirupsen/logrus"
 )
 
 type ServiceDependencies struct {
-	EventStore   store.EventStore
-	Logger       *logrus.Logger
-	UserService  *services.UserService
-	SwipeService *services.SwipeService
-	MatchService *services.MatchService
-	Middlewares  *middlewares.SystemMiddleware
+	EventStore          store.EventStore
+	Logger              *logrus.Logger
+	UserService         *services.UserService
+	SwipeService        *services.SwipeService
+	MatchService        *services.MatchService
+	SafetyService       *services.SafetyService
+	ModerationService   *services.ModerationService
+	AdminService        *services.AdminService
+	MessageService      *services.MessageService
+	NotificationService *services.NotificationService
+	Gateway             *services.Gateway
+	Middlewares         *middlewares.SystemMiddleware
 }
 
 // ServiceInitializer is an interface for initializing services.
@@ -33,32 +42,85 @@ type MongoDBInitializer struct {
 
 func (m MongoDBInitializer) Init() (*ServiceDependencies, error) {
 	var (
-		eventStore      store.EventStore
-		userRepository  repository.UserRepository
-		swipeRepository repository.SwipesRepository
-		matchRepository repository.MatchRepository
+		eventStore             store.EventStore
+		userRepository         repository.UserRepository
+		swipeRepository        repository.SwipesRepository
+		matchRepository        repository.MatchRepository
+		blockRepository        repository.BlockRepository
+		reportRepository       repository.ReportRepository
+		auditRepository        repository.AuditRepository
+		messageRepository      repository.MessageRepository
+		notificationRepository repository.NotificationRepository
 	)
 
 	m.Logger.Info("Using MongoDB as the database")
-	mongoStore, err := mongodb.NewMongoConnection(m.Secrets.DatabaseUrl, m.Secrets.DatabaseName)
+	mongoStore, err := mongodb.NewMongoConnection(m.Secrets.DatabaseUrl, m.Secrets.DatabaseName, config.ServiceName)
 	if err != nil {
 		return nil, fmt.Errorf("error opening MongoDB database: %w", err)
 	}
 	userRepository = mongodb.NewUserRepo(mongoStore)
 	swipeRepository = mongodb.NewSwipeRepo(mongoStore)
 	matchRepository = mongodb.NewMatchRepo(mongoStore)
+	blockRepository = mongodb.NewBlockRepo(mongoStore)
+	reportRepository = mongodb.NewReportRepo(mongoStore)
+	auditRepository = mongodb.NewAuditRepo(mongoStore)
+	messageRepository = mongodb.NewMessageRepo(mongoStore)
+	notificationRepository = mongodb.NewNotificationRepo(mongoStore)
 
 	eventStore = store.NewEventStore(m.Logger)
 
-	userService := services.NewUserService(eventStore, userRepository, m.Logger, m.Secrets.JwtSecret)
+	scorers, err := utils.NewScorerRegistry(m.Secrets.CompatibilityWeights)
+	if err != nil {
+		return nil, fmt.Errorf("error configuring ranking: %w", err)
+	}
+	ranker, err := utils.NewCompositeRanker(scorers, m.Secrets.RankingWeights)
+	if err != nil {
+		return nil, fmt.Errorf("error configuring ranking: %w", err)
+	}
+
+	userService := services.NewUserService(eventStore, userRepository, m.Logger, m.Secrets.JwtSecret, m.Secrets.PasswordPolicy, ranker)
+
+	// Random faker users are only ever seeded locally, and only when explicitly requested.
+	if m.Secrets.SeedDefaultUsers {
+		if m.Secrets.Environment.IsNot(config.Local) {
+			m.Logger.Warnf("SEED_DEFAULT_USERS is ignored in the %s environment", m.Secrets.Environment)
+		} else if err := userService.SeedDefaultUsers(context.Background()); err != nil {
+			return nil, fmt.Errorf("error seeding default users: %w", err)
+		}
+	}
+
+	adminService := services.NewAdminService(m.Logger, userRepository, auditRepository, m.Secrets.PasswordPolicy)
+	if err := adminService.PromoteAdmins(context.Background(), m.Secrets.AdminUserIDs); err != nil {
+		return nil, fmt.Errorf("error promoting admins: %w", err)
+	}
+
+	gateway, err := services.NewGateway(eventStore, m.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("error starting the event gateway: %w", err)
+	}
+
+	channels, err := notifiers.New(m.Secrets.Notifiers, m.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("error configuring notifications: %w", err)
+	}
+	notificationService, err := services.NewNotificationService(eventStore, m.Logger, notificationRepository, userRepository, channels)
+	if err != nil {
+		return nil, fmt.Errorf("error starting notifications: %w", err)
+	}
 
 	return &ServiceDependencies{
-		EventStore:   eventStore,
-		Logger:       m.Logger,
-		UserService:  services.NewUserService(eventStore, userRepository, m.Logger, m.Secrets.JwtSecret),
-		SwipeService: services.NewSwipeService(eventStore, m.Logger, swipeRepository, matchRepository, userRepository),
-		MatchService: services.NewMatchService(eventStore, m.Logger, matchRepository),
-		Middlewares:  middlewares.NewSystemMiddleware(userService, m.Logger),
+		EventStore:          eventStore,
+		Logger:              m.Logger,
+		UserService:         userService,
+		SwipeService:        services.NewSwipeService(eventStore, m.Logger, swipeRepository, matchRepository, userRepository, blockRepository, m.Secrets.SwipeLimits),
+		MatchService:        services.NewMatchService(eventStore, m.Logger, matchRepository, m.Secrets.MatchExpiry),
+		SafetyService:       services.NewSafetyService(eventStore, m.Logger, blockRepository, reportRepository, matchRepository, userRepository),
+		ModerationService:   services.NewModerationService(m.Logger, reportRepository, auditRepository, userRepository),
+		AdminService:        adminService,
+		MessageService:      services.NewMessageService(eventStore, m.Logger, messageRepository, matchRepository, blockRepository, userRepository, m.Secrets.MatchExpiry),
+		NotificationService: notificationService,
+		Gateway:             gateway,
+		Middlewares:         middlewares.NewSystemMiddleware(userService, m.Logger),
 	}, nil
 }
 
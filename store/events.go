@@ -1,35 +1,72 @@
 package store
 
 import (
-	"fmt"
+	"api/constants"
 	"github.com/sirupsen/logrus"
+	"sync"
 )
 
 type eventStore struct {
 	eventsChannel chan Event
-	subscribers   map[string]SubscriptionHandler
+	mu            sync.RWMutex
+	subscribers   map[string][]*subscriber
 	logger        *logrus.Logger
 }
 
-func (e eventStore) Publish(topic string, data []byte) error {
+// subscriber is a handler with its own queue of events, drained in order by a single goroutine.
+type subscriber struct {
+	topic   string
+	handler SubscriptionHandler
+	// dropWhenFull subscribers miss the events published while their queue is full; the others
+	// queue every event, however far behind they are.
+	dropWhenFull bool
+	mu           sync.Mutex
+	queue        []Event
+	ready        chan struct{}
+}
+
+// SubscribeOption configures a subscription.
+type SubscribeOption func(*subscriber)
+
+// DropWhenFull makes a subscription miss the events published while constants.SubscriberBufferSize
+// events are already queued for it. It suits handlers that push events on to clients, which lose
+// events anyway when they fall behind; handlers that persist events must not use it.
+func DropWhenFull() SubscribeOption {
+	return func(sub *subscriber) {
+		sub.dropWhenFull = true
+	}
+}
+
+func (e *eventStore) Publish(topic string, data []byte) error {
 	event := Event{topic: topic, data: data}
 	e.eventsChannel <- event
 	return nil
 }
 
-func (e eventStore) Subscribe(topic string, handler SubscriptionHandler) error {
-	if _, ok := e.subscribers[topic]; ok {
-		return fmt.Errorf("duplicate subscription for topic: %s", topic)
+// Subscribe adds a handler for the topic. A topic can have any number of handlers, and every
+// handler gets every event of the topic, one at a time and in the order they were published,
+// unless it subscribed with DropWhenFull.
+func (e *eventStore) Subscribe(topic string, handler SubscriptionHandler, opts ...SubscribeOption) error {
+	sub := &subscriber{
+		topic:   topic,
+		handler: handler,
+		ready:   make(chan struct{}, 1),
 	}
+	for _, opt := range opts {
+		opt(sub)
+	}
+	go e.drain(sub)
 
-	e.subscribers[topic] = handler
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.subscribers[topic] = append(e.subscribers[topic], sub)
 	return nil
 }
 
 func NewEventStore(logger *logrus.Logger) EventStore {
 	evStore := &eventStore{
 		eventsChannel: make(chan Event, 10),
-		subscribers:   make(map[string]SubscriptionHandler),
+		subscribers:   make(map[string][]*subscriber),
 		logger:        logger,
 	}
 
@@ -37,24 +74,69 @@ func NewEventStore(logger *logrus.Logger) EventStore {
 	return evStore
 }
 
-// eventsToRespectiveSubscriptionHandlerConsumer does the following:
-func (e eventStore) eventsToRespectiveSubscriptionHandlerConsumer() {
+// eventsToRespectiveSubscriptionHandlerConsumer queues each published event for every handler
+// of its topic. Queueing never waits on a handler, so a slow handler holds up neither the others
+// nor the publishers.
+func (e *eventStore) eventsToRespectiveSubscriptionHandlerConsumer() {
 	for {
 		event, ok := <-e.eventsChannel
 		if !ok {
 			continue
 		}
 
-		handler, ok := e.subscribers[event.topic]
-		if !ok {
+		e.mu.RLock()
+		subscribers := e.subscribers[event.topic]
+		e.mu.RUnlock()
+		if len(subscribers) == 0 {
 			e.logger.Warnf("No registered subscriber for %s topic", event.topic)
 			continue
 		}
 
-		go func(ev Event, handler SubscriptionHandler) {
-			if err := handler(ev); err != nil {
-				e.logger.WithError(err).Errorf("%s returned an error when handling event.", ev.Topic())
+		for _, sub := range subscribers {
+			if !sub.enqueue(event) {
+				e.logger.Warnf("dropping %s event for a subscriber that fell behind", event.topic)
+			}
+		}
+	}
+}
+
+// enqueue queues the event for the subscriber, and reports whether it did.
+func (sub *subscriber) enqueue(event Event) bool {
+	sub.mu.Lock()
+	if sub.dropWhenFull && len(sub.queue) >= constants.SubscriberBufferSize {
+		sub.mu.Unlock()
+		return false
+	}
+	sub.queue = append(sub.queue, event)
+	sub.mu.Unlock()
+
+	select {
+	case sub.ready <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// next takes the oldest event queued for the subscriber, and reports whether there was one.
+func (sub *subscriber) next() (Event, bool) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	if len(sub.queue) == 0 {
+		return Event{}, false
+	}
+	event := sub.queue[0]
+	sub.queue[0] = Event{}
+	sub.queue = sub.queue[1:]
+	return event, true
+}
+
+// drain hands the events queued for the subscriber to its handler, one at a time.
+func (e *eventStore) drain(sub *subscriber) {
+	for range sub.ready {
+		for event, ok := sub.next(); ok; event, ok = sub.next() {
+			if err := sub.handler(event); err != nil {
+				e.logger.WithError(err).Errorf("%s returned an error when handling event.", event.Topic())
 			}
-		}(event, handler)
+		}
 	}
 }
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"api/constants"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestEventStore_EveryHandlerGetsEveryEvent(t *testing.T) {
+	eventStore := NewEventStore(logrus.New())
+	first, second := make(chan Event, 1), make(chan Event, 1)
+	assert.NoError(t, eventStore.Subscribe("match.created", func(event Event) error {
+		first <- event
+		return nil
+	}))
+	assert.NoError(t, eventStore.Subscribe("match.created", func(event Event) error {
+		second <- event
+		return nil
+	}))
+
+	assert.NoError(t, eventStore.Publish("match.created", []byte(`{"match_id":"m1"}`)))
+
+	for _, received := range []chan Event{first, second} {
+		select {
+		case event := <-received:
+			assert.Equal(t, "match.created", event.Topic())
+			assert.JSONEq(t, `{"match_id":"m1"}`, string(event.Data()))
+		case <-time.After(time.Second):
+			t.Fatal("a handler got no event")
+		}
+	}
+}
+
+func TestEventStore_HandlerGetsEventsInOrder(t *testing.T) {
+	eventStore := NewEventStore(logrus.New())
+	received := make(chan string, 5)
+	assert.NoError(t, eventStore.Subscribe("message.sent", func(event Event) error {
+		received <- string(event.Data())
+		return nil
+	}))
+
+	expected := []string{`"1"`, `"2"`, `"3"`, `"4"`, `"5"`}
+	for _, data := range expected {
+		assert.NoError(t, eventStore.Publish("message.sent", []byte(data)))
+	}
+
+	for _, data := range expected {
+		select {
+		case event := <-received:
+			assert.Equal(t, data, event)
+		case <-time.After(time.Second):
+			t.Fatal("the handler got no event")
+		}
+	}
+}
+
+func TestEventStore_SlowHandlerDoesNotHoldUpOthers(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	eventStore := NewEventStore(logger)
+	release := make(chan struct{})
+	defer close(release)
+	assert.NoError(t, eventStore.Subscribe("match.created", func(Event) error {
+		<-release
+		return nil
+	}))
+	received := make(chan Event, 1)
+	assert.NoError(t, eventStore.Subscribe("match.created", func(event Event) error {
+		received <- event
+		return nil
+	}))
+
+	// The stuck handler falls behind, while the other one keeps up.
+	for i := 0; i < constants.SubscriberBufferSize*2; i++ {
+		assert.NoError(t, eventStore.Publish("match.created", []byte(`{}`)))
+		select {
+		case <-received:
+		case <-time.After(time.Second):
+			t.Fatalf("the handler that kept up got %d events", i)
+		}
+	}
+}
+
+func TestEventStore_HandlerThatFellBehindGetsEveryEvent(t *testing.T) {
+	eventStore := NewEventStore(logrus.New())
+	release := make(chan struct{})
+	received := make(chan Event, constants.SubscriberBufferSize*2)
+	assert.NoError(t, eventStore.Subscribe("match.created", func(event Event) error {
+		<-release
+		received <- event
+		return nil
+	}))
+
+	// Far more events than a dropping subscription holds: none is lost once the handler catches up.
+	for i := 0; i < constants.SubscriberBufferSize*2; i++ {
+		assert.NoError(t, eventStore.Publish("match.created", []byte(`{}`)))
+	}
+	close(release)
+	for i := 0; i < constants.SubscriberBufferSize*2; i++ {
+		select {
+		case <-received:
+		case <-time.After(time.Second):
+			t.Fatalf("the handler got %d events", i)
+		}
+	}
+}
+
+func TestEventStore_DropWhenFull(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	eventStore := NewEventStore(logger)
+	release := make(chan struct{})
+	received := make(chan Event, constants.SubscriberBufferSize*2)
+	assert.NoError(t, eventStore.Subscribe("match.created", func(event Event) error {
+		<-release
+		received <- event
+		return nil
+	}, DropWhenFull()))
+	// A second handler tells when every event was queued.
+	queued := make(chan Event, constants.SubscriberBufferSize*2)
+	assert.NoError(t, eventStore.Subscribe("match.created", func(event Event) error {
+		queued <- event
+		return nil
+	}))
+
+	for i := 0; i < constants.SubscriberBufferSize*2; i++ {
+		assert.NoError(t, eventStore.Publish("match.created", []byte(`{}`)))
+	}
+	assert.Eventually(t, func() bool { return len(queued) == constants.SubscriberBufferSize*2 }, time.Second, time.Millisecond)
+	close(release)
+
+	// The handler got at most a full queue, and the one event it was handling when it fell behind.
+	assert.Eventually(t, func() bool { return len(received) >= constants.SubscriberBufferSize }, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.LessOrEqual(t, len(received), constants.SubscriberBufferSize+1)
+}
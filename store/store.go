@@ -3,7 +3,7 @@ package store
 type SubscriptionHandler func(event Event) error
 type EventStore interface {
 	Publish(topic string, data []byte) error
-	Subscribe(topic string, handler SubscriptionHandler) error
+	Subscribe(topic string, handler SubscriptionHandler, opts ...SubscribeOption) error
 }
 
 type Event struct {
@@ -20,7 +20,7 @@ func GetRandomLocationInNorthLondon() []float64 {
 	// Generate random latitude and longitude within North London bounding box
 	lat := rand.Float64()*(constants.NorthLondonCoordinates.MaxLat-constants.NorthLondonCoordinates.MinLat) + constants.NorthLondonCoordinates.MinLat
 	lon := rand.Float64()*(constants.NorthLondonCoordinates.MaxLon-constants.NorthLondonCoordinates.MinLon) + constants.NorthLondonCoordinates.MinLon
-	return []float64{lat, lon}
+	return []float64{lon, lat} // GeoJSON order
 }
 
 func GetRandomEthnicity() string {
@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"api/models"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Names of the built-in scorers, as used in the ranking weights config.
+const (
+	ProximityScorer      = "proximity"
+	AttractivenessScorer = "attractiveness"
+	CompatibilityScorer  = "compatibility"
+	RecencyScorer        = "recency"
+	ActivityScorer       = "activity"
+	SuperLikeScorer      = "super_like"
+)
+
+// recencyHalfLife and activityHalfLife control how fast the recency and activity signals decay.
+const (
+	recencyHalfLife  = 30 * 24 * time.Hour
+	activityHalfLife = 3 * 24 * time.Hour
+)
+
+// Scorer scores one ranking signal of a discovered profile for the viewing user, as of the time
+// the profiles are ranked at. Scores are normalised to 0-2.5, like the swipe calculator components.
+type Scorer interface {
+	Name() string
+	Score(viewingUser *models.User, preferences *models.Preferences, viewedUser *models.User, now time.Time) float64
+}
+
+// ScorerFunc adapts a plain function to a named Scorer.
+type ScorerFunc struct {
+	name  string
+	score func(viewingUser *models.User, preferences *models.Preferences, viewedUser *models.User, now time.Time) float64
+}
+
+// NewScorerFunc returns a Scorer with the given name that delegates to score.
+func NewScorerFunc(name string, score func(viewingUser *models.User, preferences *models.Preferences, viewedUser *models.User, now time.Time) float64) ScorerFunc {
+	return ScorerFunc{name: name, score: score}
+}
+
+func (s ScorerFunc) Name() string { return s.name }
+
+func (s ScorerFunc) Score(viewingUser *models.User, preferences *models.Preferences, viewedUser *models.User, now time.Time) float64 {
+	return s.score(viewingUser, preferences, viewedUser, now)
+}
+
+// ScorerRegistry holds the scorers available to a CompositeRanker, by name.
+type ScorerRegistry struct {
+	scorers map[string]Scorer
+}
+
+// NewScorerRegistry returns a registry with the built-in scorers. compatibilityWeights override
+// DefaultCompatibilityWeights for the compatibility scorer.
+func NewScorerRegistry(compatibilityWeights models.Weights) (*ScorerRegistry, error) {
+	for name := range compatibilityWeights {
+		if _, ok := DefaultCompatibilityWeights[name]; !ok {
+			return nil, fmt.Errorf("unknown compatibility preference: %s", name)
+		}
+	}
+	weights, err := mergeWeights(DefaultCompatibilityWeights, compatibilityWeights)
+	if err != nil {
+		return nil, fmt.Errorf("invalid compatibility weights: %w", err)
+	}
+
+	registry := &ScorerRegistry{scorers: make(map[string]Scorer)}
+	builtIns := []Scorer{
+		NewScorerFunc(ProximityScorer, func(viewingUser *models.User, _ *models.Preferences, viewedUser *models.User, _ time.Time) float64 {
+			return normalizedProximityScore(viewingUser, viewedUser)
+		}),
+		NewScorerFunc(AttractivenessScorer, func(_ *models.User, _ *models.Preferences, viewedUser *models.User, _ time.Time) float64 {
+			return normalizedAttractivenessScore(viewedUser)
+		}),
+		NewScorerFunc(CompatibilityScorer, func(_ *models.User, preferences *models.Preferences, viewedUser *models.User, _ time.Time) float64 {
+			if preferences == nil {
+				return 0
+			}
+			return calculateWeightedCompatibilityScore(preferences, viewedUser, weights) * maxSignalScore
+		}),
+		NewScorerFunc(RecencyScorer, func(_ *models.User, _ *models.Preferences, viewedUser *models.User, now time.Time) float64 {
+			return decayScore(viewedUser.CreatedAt, now, recencyHalfLife)
+		}),
+		NewScorerFunc(ActivityScorer, func(_ *models.User, _ *models.Preferences, viewedUser *models.User, now time.Time) float64 {
+			return decayScore(viewedUser.LastActiveAt, now, activityHalfLife)
+		}),
+		NewScorerFunc(SuperLikeScorer, func(_ *models.User, _ *models.Preferences, viewedUser *models.User, _ time.Time) float64 {
+			if viewedUser.SuperLikedYou {
+				return maxSignalScore
+			}
+			return 0
+		}),
+	}
+	for _, scorer := range builtIns {
+		if err := registry.Register(scorer); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// Register adds a scorer to the registry. Names must be unique.
+func (r *ScorerRegistry) Register(scorer Scorer) error {
+	if _, ok := r.scorers[scorer.Name()]; ok {
+		return fmt.Errorf("duplicate scorer: %s", scorer.Name())
+	}
+	r.scorers[scorer.Name()] = scorer
+	return nil
+}
+
+// Get returns the scorer registered under the given name.
+func (r *ScorerRegistry) Get(name string) (Scorer, bool) {
+	scorer, ok := r.scorers[name]
+	return scorer, ok
+}
+
+type weightedScorer struct {
+	scorer Scorer
+	weight float64
+}
+
+// CompositeRanker ranks discovered profiles with the weighted sum of several scorers.
+type CompositeRanker struct {
+	scorers []weightedScorer
+}
+
+// NewCompositeRanker combines the scorers of the registry. weights override
+// DefaultSwipeScoreWeights, and scorers with a zero weight are left out.
+func NewCompositeRanker(registry *ScorerRegistry, weights models.Weights) (*CompositeRanker, error) {
+	merged, err := mergeWeights(DefaultSwipeScoreWeights, weights)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ranking weights: %w", err)
+	}
+
+	names := make([]string, 0, len(merged))
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ranker := &CompositeRanker{}
+	for _, name := range names {
+		if merged[name] == 0 {
+			continue
+		}
+		scorer, ok := registry.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown scorer: %s", name)
+		}
+		ranker.scorers = append(ranker.scorers, weightedScorer{scorer: scorer, weight: merged[name]})
+	}
+	return ranker, nil
+}
+
+// Rank scores a discovered profile for the viewing user as of now, and explains the score by
+// scorer. Profiles ranked at the same now score the same however much later they are ranked.
+func (c *CompositeRanker) Rank(viewingUser *models.User, preferences *models.Preferences, viewedUser *models.User, now time.Time) models.RankingExplanation {
+	explanation := models.RankingExplanation{Components: make(map[string]float64, len(c.scorers))}
+	for _, ws := range c.scorers {
+		score := ws.scorer.Score(viewingUser, preferences, viewedUser, now)
+		explanation.Components[ws.scorer.Name()] = score
+		explanation.Score += score * ws.weight
+	}
+	// Constrain total score to a maximum of 10
+	explanation.Score = math.Min(math.Max(explanation.Score, 0), 10)
+	return explanation
+}
+
+// mergeWeights returns the defaults overridden by the given weights. New names are allowed,
+// so custom scorers can be weighted; negative weights are not.
+func mergeWeights(defaults, overrides models.Weights) (models.Weights, error) {
+	merged := make(models.Weights, len(defaults)+len(overrides))
+	for name, weight := range defaults {
+		merged[name] = weight
+	}
+	for name, weight := range overrides {
+		if weight < 0 {
+			return nil, fmt.Errorf("weight of %s must not be negative", name)
+		}
+		merged[name] = weight
+	}
+	return merged, nil
+}
+
+// decayScore scores a timestamp from maxSignalScore (now) down towards 0, halving every halfLife.
+func decayScore(at, now time.Time, halfLife time.Duration) float64 {
+	if at.IsZero() {
+		return 0
+	}
+	elapsed := math.Max(now.Sub(at).Hours(), 0)
+	return maxSignalScore * math.Pow(0.5, elapsed/halfLife.Hours())
+}
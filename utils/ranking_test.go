@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"api/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCompositeRanker_Weights(t *testing.T) {
+	viewer := &models.User{ID: "viewer", Location: []float64{-0.12, 51.5}, SwipeCount: 10}
+	fresh := &models.User{ID: "fresh", Location: []float64{-0.12, 51.5}, CreatedAt: time.Now(), LastActiveAt: time.Now()}
+	stale := &models.User{ID: "stale", Location: []float64{-0.12, 51.5}, CreatedAt: time.Now().AddDate(-1, 0, 0)}
+
+	scorers, err := NewScorerRegistry(nil)
+	assert.NoError(t, err)
+
+	ranker, err := NewCompositeRanker(scorers, nil)
+	assert.NoError(t, err)
+	explanation := ranker.Rank(viewer, nil, fresh, time.Now())
+	assert.NotContains(t, explanation.Components, RecencyScorer, "recency is off by default")
+
+	ranker, err = NewCompositeRanker(scorers, models.Weights{RecencyScorer: 1, ActivityScorer: 1})
+	assert.NoError(t, err)
+	freshRank := ranker.Rank(viewer, nil, fresh, time.Now())
+	staleRank := ranker.Rank(viewer, nil, stale, time.Now())
+	assert.Greater(t, freshRank.Components[RecencyScorer], staleRank.Components[RecencyScorer])
+	assert.Zero(t, staleRank.Components[ActivityScorer])
+	assert.Greater(t, freshRank.Score, staleRank.Score)
+}
+
+func TestCompositeRanker_InvalidWeights(t *testing.T) {
+	scorers, err := NewScorerRegistry(nil)
+	assert.NoError(t, err)
+
+	_, err = NewCompositeRanker(scorers, models.Weights{"popularity": 0.5})
+	assert.EqualError(t, err, "unknown scorer: popularity")
+
+	_, err = NewCompositeRanker(scorers, models.Weights{ProximityScorer: -1})
+	assert.Error(t, err)
+
+	_, err = NewScorerRegistry(models.Weights{"star_sign": 0.5})
+	assert.EqualError(t, err, "unknown compatibility preference: star_sign")
+}
+
+func TestScorerRegistry_Register(t *testing.T) {
+	scorers, err := NewScorerRegistry(nil)
+	assert.NoError(t, err)
+
+	verified := NewScorerFunc("verified", func(_ *models.User, _ *models.Preferences, viewedUser *models.User, _ time.Time) float64 {
+		if viewedUser.Bio != "" {
+			return maxSignalScore
+		}
+		return 0
+	})
+	assert.NoError(t, scorers.Register(verified))
+	assert.EqualError(t, scorers.Register(verified), "duplicate scorer: verified")
+
+	ranker, err := NewCompositeRanker(scorers, models.Weights{"verified": 1})
+	assert.NoError(t, err)
+	explanation := ranker.Rank(&models.User{}, nil, &models.User{Bio: "hello"}, time.Now())
+	assert.Equal(t, maxSignalScore, explanation.Components["verified"])
+}
+
+func TestCompositeRanker_BoostsSuperLikers(t *testing.T) {
+	viewer := &models.User{ID: "viewer", Location: []float64{-0.12, 51.5}}
+	// The nearer, more attractive profile still ranks below the one that super-liked the viewer.
+	nearby := &models.User{ID: "nearby", Location: []float64{-0.12, 51.5}, Attractiveness: 10}
+	superLiker := &models.User{ID: "super-liker", Location: []float64{-0.5, 51.8}, SuperLikedYou: true}
+
+	scorers, err := NewScorerRegistry(nil)
+	assert.NoError(t, err)
+
+	ranker, err := NewCompositeRanker(scorers, nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, ranker.Rank(viewer, nil, superLiker, time.Now()).Components, SuperLikeScorer, "super-likers are paged first, not scored up")
+
+	ranker, err = NewCompositeRanker(scorers, models.Weights{SuperLikeScorer: 1})
+	assert.NoError(t, err)
+	superLikerRank := ranker.Rank(viewer, nil, superLiker, time.Now())
+	assert.Equal(t, maxSignalScore, superLikerRank.Components[SuperLikeScorer])
+	assert.Greater(t, superLikerRank.Score, ranker.Rank(viewer, nil, nearby, time.Now()).Score)
+}
+
+func TestCompositeRanker_IgnoresTheViewersSwipeCount(t *testing.T) {
+	scorers, err := NewScorerRegistry(nil)
+	assert.NoError(t, err)
+	ranker, err := NewCompositeRanker(scorers, nil)
+	assert.NoError(t, err)
+
+	viewed := &models.User{ID: "viewed", Location: []float64{-0.12, 51.5}, Attractiveness: 6}
+	fresh := ranker.Rank(&models.User{Location: []float64{-0.12, 51.5}}, nil, viewed, time.Now())
+	busy := ranker.Rank(&models.User{Location: []float64{-0.12, 51.5}, SwipeCount: 50, DailySwipeBudget: 10}, nil, viewed, time.Now())
+	assert.Equal(t, fresh, busy)
+}
+
+func TestCompositeRanker_RanksAsOfNow(t *testing.T) {
+	scorers, err := NewScorerRegistry(nil)
+	assert.NoError(t, err)
+	ranker, err := NewCompositeRanker(scorers, models.Weights{RecencyScorer: 1})
+	assert.NoError(t, err)
+
+	joined := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	viewed := &models.User{ID: "viewed", CreatedAt: joined}
+	// Recency decays with the time the profile is ranked at, not with the time it is ranked.
+	assert.Equal(t, maxSignalScore, ranker.Rank(&models.User{}, nil, viewed, joined).Components[RecencyScorer])
+	assert.Equal(t, maxSignalScore/2, ranker.Rank(&models.User{}, nil, viewed, joined.Add(recencyHalfLife)).Components[RecencyScorer])
+}
@@ -7,12 +7,45 @@ import (
 
 const earthRadiusKm = 6371 // Earth's radius in kilometers
 const swipeCost = 0.5
+const maxSignalScore = 2.5 // every signal is normalised to 0-2.5
+
+// swipeCostWeight weighs the swipe cost in the swipe score. The cost is the viewing user's alone, so
+// it is not a discovery ranking signal: it would add the same to every profile they see.
+const swipeCostWeight = 0.25
+
+// DefaultSwipeScoreWeights are the weights of the swipe score signals. Any of them can be
+// overridden from config; see NewCompositeRanker.
+var DefaultSwipeScoreWeights = models.Weights{
+	ProximityScorer:      0.25,
+	AttractivenessScorer: 0.25,
+	CompatibilityScorer:  0.25,
+	RecencyScorer:        0,
+	ActivityScorer:       0,
+	// Super-likers are paged ahead of everyone else already, so scoring them up as well only
+	// inflates their scores.
+	SuperLikeScorer: 0,
+}
+
+// DefaultCompatibilityWeights are the weights of each preference in the compatibility score.
+var DefaultCompatibilityWeights = models.Weights{
+	"drinking":  0.3,
+	"smoking":   0.2,
+	"religion":  0.5,
+	"ethnicity": 0.2,
+	"age":       0.2,
+	"height":    0.1,
+}
 
 func calculateCompatibilityScore(userPreference *models.Preferences, match *models.User) float64 {
+	return calculateWeightedCompatibilityScore(userPreference, match, DefaultCompatibilityWeights)
+}
+
+func calculateWeightedCompatibilityScore(userPreference *models.Preferences, match *models.User, weights models.Weights) float64 {
 	compatibilityScore, totalWeight := 0.0, 0.0
 	dealBreakerFailed := false
 
-	drinkWeight, smokeWeight, religionWeight := 0.3, 0.2, 0.5
+	drinkWeight, smokeWeight, religionWeight := weights["drinking"], weights["smoking"], weights["religion"]
+	ethnicityWeight, ageWeight, heightWeight := weights["ethnicity"], weights["age"], weights["height"]
 
 	// Drinking preference
 	if userPreference.Drinking.Status == match.Drinking {
@@ -38,7 +71,31 @@ func calculateCompatibilityScore(userPreference *models.Preferences, match *mode
 	}
 	totalWeight += religionWeight
 
+	// Optional soft preferences only count once the user has set them, so they don't
+	// dilute the score of users who haven't.
+	if userPreference.Ethnicity != "" {
+		if string(userPreference.Ethnicity) == match.Ethnicity {
+			compatibilityScore += ethnicityWeight
+		}
+		totalWeight += ethnicityWeight
+	}
+	if isRangeSet(userPreference.AgeRange.Min, userPreference.AgeRange.Max) {
+		if isInRange(match.Age, userPreference.AgeRange.Min, userPreference.AgeRange.Max) {
+			compatibilityScore += ageWeight
+		}
+		totalWeight += ageWeight
+	}
+	if isRangeSet(userPreference.Height.Min, userPreference.Height.Max) {
+		if isInRange(int(match.Height), userPreference.Height.Min, userPreference.Height.Max) {
+			compatibilityScore += heightWeight
+		}
+		totalWeight += heightWeight
+	}
+
 	// Normalize the compatibility score
+	if totalWeight == 0 {
+		return 0
+	}
 	normalizedScore := compatibilityScore / totalWeight
 
 	// If any dealbreaker condition failed, return a reduced score
@@ -49,13 +106,27 @@ func calculateCompatibilityScore(userPreference *models.Preferences, match *mode
 	return normalizedScore
 }
 
+// isRangeSet reports whether a min/max preference has at least one bound; zero means "no bound".
+func isRangeSet(min, max int) bool {
+	return min > 0 || max > 0
+}
+
+// isInRange reports whether value lies within a min/max preference where zero means "no bound".
+func isInRange(value, min, max int) bool {
+	return (min == 0 || value >= min) && (max == 0 || value <= max)
+}
+
 func toRadians(deg float64) float64 {
 	return deg * math.Pi / 180
 }
 
+// calculateProximityScore scores the distance between two GeoJSON [longitude, latitude] locations.
 func calculateProximityScore(userLocation, viewedUserLocation []float64) float64 {
-	lat1, lon1 := toRadians(userLocation[0]), toRadians(userLocation[1])
-	lat2, lon2 := toRadians(viewedUserLocation[0]), toRadians(viewedUserLocation[1])
+	if len(userLocation) != 2 || len(viewedUserLocation) != 2 {
+		return 0
+	}
+	lon1, lat1 := toRadians(userLocation[0]), toRadians(userLocation[1])
+	lon2, lat2 := toRadians(viewedUserLocation[0]), toRadians(viewedUserLocation[1])
 
 	dlat := lat2 - lat1
 	dlon := lon2 - lon1
@@ -75,28 +146,30 @@ func calculateSwipeCostScore(user models.User) float64 {
 }
 
 func calculateSwipeScore(viewingUser, viewedUser models.User, compatibilityScore float64) float64 {
-	// Adjust weights if necessary
-	proximityWeight := 0.25
-	attractivenessWeight := 0.25
-	swipeCostWeight := 0.25
-	compatibilityWeight := 0.25
-
-	// normalization scales based on the expected data range
-	proximityScore := calculateProximityScore(viewingUser.Location, viewedUser.Location) * 2.5
-	attractivenessScore := float64(viewedUser.Attractiveness) / 10 * 2.5                //  attractiveness is scored out of 10
-	swipeCostScore := math.Min(calculateSwipeCostScore(viewingUser)/swipeCost*2.5, 2.5) // Normalize based on your swipe cost logic
-	normalizedCompatibilityScore := compatibilityScore * 2.5                            // Normalize compatibility score
+	weights := DefaultSwipeScoreWeights
 
 	totalScore :=
-		proximityScore*proximityWeight +
-			attractivenessScore*attractivenessWeight +
-			swipeCostScore*swipeCostWeight +
-			normalizedCompatibilityScore*compatibilityWeight
+		normalizedProximityScore(&viewingUser, &viewedUser)*weights[ProximityScorer] +
+			normalizedAttractivenessScore(&viewedUser)*weights[AttractivenessScorer] +
+			normalizedSwipeCostScore(&viewingUser)*swipeCostWeight +
+			compatibilityScore*maxSignalScore*weights[CompatibilityScorer] // Normalize compatibility score
 
 	// Constrain total score to a maximum of 10
 	return math.Min(math.Max(totalScore, 0), 10)
 }
 
+func normalizedProximityScore(viewingUser, viewedUser *models.User) float64 {
+	return calculateProximityScore(viewingUser.Location, viewedUser.Location) * maxSignalScore
+}
+
+func normalizedAttractivenessScore(viewedUser *models.User) float64 {
+	return float64(viewedUser.Attractiveness) / 10 * maxSignalScore //  attractiveness is scored out of 10
+}
+
+func normalizedSwipeCostScore(viewingUser *models.User) float64 {
+	return math.Min(calculateSwipeCostScore(*viewingUser)/swipeCost*maxSignalScore, maxSignalScore) // Normalize based on your swipe cost logic
+}
+
 func updateSwipeRating(currentSwipeRating float64, successfulMatches, unsuccessfulMatches int) float64 {
 	dailySuccessRate := float64(successfulMatches) / math.Max(1, float64(unsuccessfulMatches))
 
@@ -1,10 +1,15 @@
 package utils
 
 import (
+	"api/models"
 	"bytes"
+	cryptorand "crypto/rand"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"github.com/oklog/ulid/v2"
 	"golang.org/x/crypto/bcrypt"
+	"math/big"
 	"math/rand"
 	"strings"
 	"time"
@@ -49,6 +54,69 @@ func UnPack(in interface{}, target interface{}) error {
 	return nil
 }
 
+// EncodeCursor encodes a pagination position as an opaque, URL-safe cursor.
+func EncodeCursor(position interface{}) (string, error) {
+	b, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Paginate trims items, fetched with one more than the limit to learn whether there is a next
+// page, back to the limit. It returns the page, never nil, and the cursor of the next page, which
+// encodes the position cursorOf gives for the last item of the page, or is empty on the last page.
+func Paginate[T any](items []T, limit int, cursorOf func(T) interface{}) ([]T, string, error) {
+	if len(items) <= limit {
+		if items == nil {
+			items = []T{}
+		}
+		return items, "", nil
+	}
+	items = items[:limit]
+	cursor, err := EncodeCursor(cursorOf(items[limit-1]))
+	if err != nil {
+		return nil, "", err
+	}
+	return items, cursor, nil
+}
+
+// ErrInvalidCursor is returned for a cursor that EncodeCursor did not produce.
+var ErrInvalidCursor = errors.New("sorry, the cursor is invalid")
+
+// FetchPage reads the page of items after the position the page's cursor encodes, or the first page
+// without a cursor. fetch reads the items after the given position, nil on the first page, up to
+// one more than the page size, so the extra item tells whether there is a next page. cursorOf gives
+// the position of an item to start the next page after. It fails with ErrInvalidCursor on a cursor
+// that is not one, and with the error of fetch when fetch fails.
+func FetchPage[T any, C any](page models.Page, fetch func(after *C, limit int) ([]T, error), cursorOf func(T) C) ([]T, string, error) {
+	var after *C
+	if page.Cursor != "" {
+		after = new(C)
+		if err := DecodeCursor(page.Cursor, after); err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+	}
+
+	limit := page.LimitOrDefault()
+	items, err := fetch(after, limit+1)
+	if err != nil {
+		return nil, "", err
+	}
+	return Paginate(items, limit, func(last T) interface{} {
+		return cursorOf(last)
+	})
+}
+
+// DecodeCursor decodes a cursor produced by EncodeCursor into target.
+func DecodeCursor(cursor string, target interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, target)
+}
+
 func EncryptPassword(password string) string {
 	encrypted, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(encrypted)
@@ -58,3 +126,49 @@ func VerifyPasscode(hashedPasscode, providedPassword string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPasscode), []byte(providedPassword))
 	return err == nil
 }
+
+// temporaryPasswordClasses are the characters of temporary passwords, by class. Look-alike
+// characters are left out, as temporary passwords are read out and typed by hand.
+var temporaryPasswordClasses = []string{
+	"ABCDEFGHJKLMNPQRSTUVWXYZ",
+	"abcdefghijkmnopqrstuvwxyz",
+	"23456789",
+	"!#$%&*+-=?@",
+}
+
+// GenerateTemporaryPassword returns a random password of the given length, at least 8, with at
+// least one character of every class, so it meets any password policy of that length.
+func GenerateTemporaryPassword(length int) (string, error) {
+	length = max(length, 8)
+	password := make([]byte, 0, length)
+	all := strings.Join(temporaryPasswordClasses, "")
+	for i := 0; i < length; i++ {
+		chars := all
+		if i < len(temporaryPasswordClasses) {
+			chars = temporaryPasswordClasses[i]
+		}
+		c, err := randomIndex(len(chars))
+		if err != nil {
+			return "", err
+		}
+		password = append(password, chars[c])
+	}
+	// Shuffle, so the guaranteed characters are not always first.
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password), nil
+}
+
+// randomIndex returns a cryptographically random index below n.
+func randomIndex(n int) (int, error) {
+	i, err := cryptorand.Int(cryptorand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
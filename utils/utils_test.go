@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"api/models"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	type position struct {
+		ID int `json:"id"`
+	}
+	cursorOf := func(item int) interface{} { return position{ID: item} }
+
+	testCases := []struct {
+		name           string
+		items          []int
+		expected       []int
+		expectedCursor *position
+	}{
+		{name: "nothing fetched", items: nil, expected: []int{}},
+		{name: "empty page", items: []int{}, expected: []int{}},
+		{name: "fewer than the limit", items: []int{1, 2}, expected: []int{1, 2}},
+		{name: "exactly the limit", items: []int{1, 2, 3}, expected: []int{1, 2, 3}},
+		{name: "one over the limit", items: []int{1, 2, 3, 4}, expected: []int{1, 2, 3}, expectedCursor: &position{ID: 3}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, cursor, err := Paginate(tc.items, 3, cursorOf)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, page)
+			if tc.expectedCursor == nil {
+				assert.Empty(t, cursor)
+				return
+			}
+			// The cursor points at the last item of the page, not at the extra one.
+			var decoded position
+			assert.NoError(t, DecodeCursor(cursor, &decoded))
+			assert.Equal(t, *tc.expectedCursor, decoded)
+		})
+	}
+}
+
+func TestFetchPage(t *testing.T) {
+	type position struct {
+		ID int `json:"id"`
+	}
+	items := []int{1, 2, 3, 4, 5}
+	// fetch reads the items after the position, as a repository would.
+	var fetched []int
+	fetch := func(after *position, limit int) ([]int, error) {
+		rest := items
+		if after != nil {
+			rest = items[after.ID:]
+		}
+		fetched = rest[:min(limit, len(rest))]
+		return fetched, nil
+	}
+	cursorOf := func(item int) position { return position{ID: item} }
+
+	page, cursor, err := FetchPage(models.Page{Limit: 2}, fetch, cursorOf)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, page)
+	// One item more than the page size is fetched to learn that there is a next page.
+	assert.Equal(t, []int{1, 2, 3}, fetched)
+
+	page, cursor, err = FetchPage(models.Page{Limit: 2, Cursor: cursor}, fetch, cursorOf)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3, 4}, page)
+
+	page, cursor, err = FetchPage(models.Page{Limit: 2, Cursor: cursor}, fetch, cursorOf)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{5}, page)
+	assert.Empty(t, cursor)
+
+	_, _, err = FetchPage(models.Page{Cursor: "not a cursor"}, fetch, cursorOf)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	failed := errors.New("connection reset")
+	_, _, err = FetchPage(models.Page{}, func(*position, int) ([]int, error) { return nil, failed }, cursorOf)
+	assert.ErrorIs(t, err, failed)
+}
+
+func TestPaginate_CursorError(t *testing.T) {
+	_, _, err := Paginate([]int{1, 2}, 1, func(int) interface{} { return func() {} })
+	assert.Error(t, err)
+}